@@ -4,31 +4,27 @@ import (
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
-	"github.com/wagoodman/dive/dive"
 	"os"
+	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/export"
 )
 
 // doAnalyzeCmd takes a docker image tag, digest, or id and displays the
 // image analysis to the screen
 func doAnalyzeCmd(cmd *cobra.Command, args []string) {
 
-	if len(args) == 0 {
+	if len(args) == 0 && ciImageList == "" {
 		printVersionFlag, err := cmd.PersistentFlags().GetBool("version")
 		if err == nil && printVersionFlag {
 			printVersion(cmd, args)
 			return
 		}
 
-		fmt.Println("No image argument given")
-		os.Exit(1)
-	}
-
-	userImage := args[0]
-	if userImage == "" {
-		fmt.Println("No image argument given")
+		fmt.Fprintln(os.Stderr, "No image argument given")
 		os.Exit(1)
 	}
 
@@ -37,24 +33,72 @@ func doAnalyzeCmd(cmd *cobra.Command, args []string) {
 	isCi, ciConfig, err := configureCi()
 
 	if err != nil {
-		fmt.Printf("ci configuration error: %v\n", err)
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "ci configuration error: %v\n", err)
+		os.Exit(ci.ExitCodeMisconfigured)
+	}
+
+	reportFormat, err := getCiReportFormat()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ci configuration error: %v\n", err)
+		os.Exit(ci.ExitCodeMisconfigured)
 	}
 
-	var sourceType dive.ImageSource
-	var imageStr string
+	if baselineFile != "" && !isCi && exportFile == "" {
+		fmt.Fprintln(os.Stderr, "the --baseline option requires --ci or --json")
+		os.Exit(1)
+	}
 
-	sourceType, imageStr = dive.DeriveImageSource(userImage)
+	if (exportDetail || exportTree) && exportFile == "" {
+		fmt.Fprintln(os.Stderr, "the --json-detail and --json-tree options require --json")
+		os.Exit(1)
+	}
 
-	if sourceType == dive.SourceUnknown {
-		sourceStr := viper.GetString("source")
-		sourceType = dive.ParseImageSource(sourceStr)
-		if sourceType == dive.SourceUnknown {
-			fmt.Printf("unable to determine image source: %v\n", sourceStr)
+	if exportFormat != "json" {
+		if exportFile == "" {
+			fmt.Fprintln(os.Stderr, "the --json-format option requires --json")
 			os.Exit(1)
 		}
+		if !isExportFormat(exportFormat) {
+			fmt.Fprintf(os.Stderr, "unsupported export format: '%s' (supported: json, %s)\n", exportFormat, strings.Join(export.Formats, ", "))
+			os.Exit(1)
+		}
+		if exportDetail || exportTree {
+			fmt.Fprintln(os.Stderr, "the --json-detail and --json-tree options require the json export format")
+			os.Exit(1)
+		}
+	}
 
-		imageStr = userImage
+	if cmd.Flags().Changed("treemap-layer") && treemapFile == "" {
+		fmt.Fprintln(os.Stderr, "the --treemap-layer option requires --treemap")
+		os.Exit(1)
+	}
+
+	if cmd.Flags().Changed("metrics-top-dirs") && metricsFile == "" {
+		fmt.Fprintln(os.Stderr, "the --metrics-top-dirs option requires --metrics")
+		os.Exit(1)
+	}
+	if metricsTopDirs < 0 {
+		fmt.Fprintln(os.Stderr, "the --metrics-top-dirs option must not be negative")
+		os.Exit(1)
+	}
+
+	if viper.GetInt("base-layers") < 1 {
+		fmt.Fprintln(os.Stderr, "the --base-layers option must be at least 1")
+		os.Exit(1)
+	}
+
+	if countStdoutOutputs(exportFile, htmlFile, treemapFile, metricsFile, ciReportFile) > 1 {
+		fmt.Fprintln(os.Stderr, "only one output can be written to stdout ('-')")
+		os.Exit(1)
+	}
+
+	if !isOutput(outputFormat) {
+		fmt.Fprintf(os.Stderr, "unsupported output: '%s' (supported: %s)\n", outputFormat, strings.Join(runtime.Outputs, ", "))
+		os.Exit(1)
+	}
+	if outputFormat == runtime.OutputJSONLines && countStdoutOutputs(exportFile, htmlFile, treemapFile, metricsFile, ciReportFile) > 0 {
+		fmt.Fprintln(os.Stderr, "the jsonl output cannot be combined with an output that is written to stdout ('-')")
+		os.Exit(1)
 	}
 
 	ignoreErrors, err := cmd.PersistentFlags().GetBool("ignore-errors")
@@ -62,12 +106,108 @@ func doAnalyzeCmd(cmd *cobra.Command, args []string) {
 		logrus.Error("unable to get 'ignore-errors' option:", err)
 	}
 
+	if len(args) > 1 || ciImageList != "" {
+		doAnalyzeImagesCmd(cmd, args, ciConfig, reportFormat)
+		return
+	}
+
+	userImage := args[0]
+	if userImage == "" {
+		fmt.Fprintln(os.Stderr, "No image argument given")
+		os.Exit(1)
+	}
+
+	sourceType, imageStr := deriveImageSource(userImage)
+
+	var layer *int
+	if cmd.Flags().Changed("treemap-layer") {
+		layer = &treemapLayer
+	}
+
 	runtime.Run(runtime.Options{
-		Ci:           isCi,
-		Source:       sourceType,
-		Image:        imageStr,
-		ExportFile:   exportFile,
-		CiConfig:     ciConfig,
-		IgnoreErrors: viper.GetBool("ignore-errors") || ignoreErrors,
+		Ci:             isCi,
+		Source:         sourceType,
+		Image:          imageStr,
+		ExportFile:     exportFile,
+		ExportFormat:   exportFormat,
+		ExportDetail:   exportDetail,
+		ExportTree:     exportTree,
+		HTMLFile:       htmlFile,
+		TreemapFile:    treemapFile,
+		TreemapLayer:   layer,
+		MetricsFile:    metricsFile,
+		MetricsTopDirs: metricsTopDirs,
+		Output:         outputFormat,
+		CiConfig:       ciConfig,
+		IgnoreErrors:   viper.GetBool("ignore-errors") || ignoreErrors,
+		BaseImage:      viper.GetString("base-image"),
+		BaseLayers:     viper.GetInt("base-layers"),
+		BaselineFile:   baselineFile,
+		CiReportFormat: reportFormat,
+		CiReportFile:   ciReportFile,
+	})
+}
+
+func isExportFormat(format string) bool {
+	for _, supported := range export.Formats {
+		if format == supported {
+			return true
+		}
+	}
+	return false
+}
+
+func isOutput(output string) bool {
+	for _, supported := range runtime.Outputs {
+		if output == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// countStdoutOutputs returns the number of the given output files that write to stdout
+func countStdoutOutputs(paths ...string) int {
+	var count int
+	for _, path := range paths {
+		if path == runtime.StdoutPath {
+			count++
+		}
+	}
+	return count
+}
+
+// doAnalyzeImagesCmd evaluates several images against the CI rules
+func doAnalyzeImagesCmd(cmd *cobra.Command, args []string, ciConfig *viper.Viper, reportFormat string) {
+	if !isCi {
+		fmt.Fprintln(os.Stderr, "multiple images can only be evaluated with --ci")
+		os.Exit(1)
+	}
+	if exportFile != "" || baselineFile != "" || htmlFile != "" || treemapFile != "" {
+		fmt.Fprintln(os.Stderr, "the --json, --html, --treemap, and --baseline options cannot be used with multiple images (give a baseline in the CI config of each image instead)")
+		os.Exit(1)
+	}
+
+	images, err := getCiImages(cmd, args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot read image list: %v\n", err)
+		os.Exit(ci.ExitCodeMisconfigured)
+	}
+	if len(images) == 0 {
+		fmt.Fprintln(os.Stderr, "No image argument given")
+		os.Exit(1)
+	}
+
+	runtime.RunCiImages(runtime.Options{
+		Ci:             true,
+		CiConfig:       ciConfig,
+		CiImages:       images,
+		MetricsFile:    metricsFile,
+		MetricsTopDirs: metricsTopDirs,
+		Output:         outputFormat,
+		BaseImage:      viper.GetString("base-image"),
+		BaseLayers:     viper.GetInt("base-layers"),
+		CiReportFormat: reportFormat,
+		CiReportFile:   ciReportFile,
 	})
 }
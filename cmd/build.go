@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
 	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/ci"
 )
 
 // buildCmd represents the build command
@@ -27,11 +31,27 @@ func doBuildCmd(cmd *cobra.Command, args []string) {
 	// todo: allow for an engine flag to be passed to dive but not the container engine
 	engine := viper.GetString("container-engine")
 
+	reportFormat, err := getCiReportFormat()
+	if err != nil {
+		fmt.Printf("ci configuration error: %v\n", err)
+		os.Exit(ci.ExitCodeMisconfigured)
+	}
+
+	if viper.GetInt("base-layers") < 1 {
+		fmt.Fprintln(os.Stderr, "the --base-layers option must be at least 1")
+		os.Exit(1)
+	}
+
 	runtime.Run(runtime.Options{
-		Ci:         isCi,
-		Source:     dive.ParseImageSource(engine),
-		BuildArgs:  args,
-		ExportFile: exportFile,
-		CiConfig:   ciConfig,
+		Ci:             isCi,
+		Source:         dive.ParseImageSource(engine),
+		BuildArgs:      args,
+		ExportFile:     exportFile,
+		CiConfig:       ciConfig,
+		BaseImage:      viper.GetString("base-image"),
+		BaseLayers:     viper.GetInt("base-layers"),
+		BaselineFile:   baselineFile,
+		CiReportFormat: reportFormat,
+		CiReportFile:   ciReportFile,
 	})
 }
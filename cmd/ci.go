@@ -1,13 +1,19 @@
 package cmd
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 
+	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/ci"
 )
 
 func configureCi() (bool, *viper.Viper, error) {
@@ -16,24 +22,125 @@ func configureCi() (bool, *viper.Viper, error) {
 	isCi = isCi || isCiFromEnv
 
 	if isCi {
-		ciConfig.SetConfigType("yaml")
+		if err := readCiConfig(ciConfig, ciConfigFile); err != nil {
+			return isCi, nil, err
+		}
+	}
 
-		if _, err := os.Stat(ciConfigFile); !os.IsNotExist(err) {
-			fmt.Printf("  Using CI config: %s\n", ciConfigFile)
+	return isCi, ciConfig, nil
+}
 
-			fileBytes, err := ioutil.ReadFile(ciConfigFile)
-			if err != nil {
-				return isCi, nil, err
-			}
+// readCiConfig reads the given CI config file into the config, leaving the defaults (given by the flags) in place if
+// the file does not exist.
+func readCiConfig(config *viper.Viper, path string) error {
+	config.SetConfigType("yaml")
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		fmt.Fprintln(os.Stderr, "  Using default CI config")
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "  Using CI config: %s\n", path)
 
-			err = ciConfig.ReadConfig(bytes.NewBuffer(fileBytes))
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := config.ReadConfig(bytes.NewBuffer(fileBytes)); err != nil {
+		return err
+	}
+	config.SetConfigFile(path)
+	resolveCiConfigPaths(config, path)
+	return nil
+}
+
+// resolveCiConfigPaths makes the relative paths of the given CI config (read from the given file) relative to the
+// directory of the file instead of the working directory.
+func resolveCiConfigPaths(config *viper.Viper, path string) {
+	baseline := config.GetString("baseline")
+	if baseline != "" && !filepath.IsAbs(baseline) {
+		config.Set("baseline", filepath.Join(filepath.Dir(path), baseline))
+	}
+}
+
+// getCiReportFormat returns the validated machine-readable CI report format ("" for the default text report).
+func getCiReportFormat() (string, error) {
+	if ciReportFormat == "" || ciReportFormat == "text" {
+		return "", nil
+	}
+	for _, format := range ci.ReportFormats {
+		if ciReportFormat == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported CI report format: '%s' (supported: text, %s)", ciReportFormat, strings.Join(ci.ReportFormats, ", "))
+}
+
+// getCiImages returns the images to evaluate in a single CI run: the given image arguments (evaluated with the shared
+// CI config) followed by the images in the --ci-image-list file. Each line of that file names an image, optionally
+// followed by the CI config file for that image. Empty lines and lines starting with '#' are ignored.
+func getCiImages(cmd *cobra.Command, args []string) ([]runtime.CiImage, error) {
+	var images []runtime.CiImage
+	for _, arg := range args {
+		source, image := deriveImageSource(arg)
+		images = append(images, runtime.CiImage{Image: image, Source: source})
+	}
+
+	if ciImageList == "" {
+		return images, nil
+	}
+
+	file, err := os.Open(ciImageList)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("%s:%d: expected an image and an optional CI config file, got '%s'", ciImageList, lineNumber, line)
+		}
+
+		source, image := deriveImageSource(fields[0])
+		ciImage := runtime.CiImage{Image: image, Source: source}
+		if len(fields) == 2 {
+			// the CI config file is relative to the image list
+			ciImage.CiConfigFile = fields[1]
+			if !filepath.IsAbs(ciImage.CiConfigFile) {
+				ciImage.CiConfigFile = filepath.Join(filepath.Dir(ciImageList), ciImage.CiConfigFile)
+			}
+			ciImage.CiConfig, err = newCiConfig(cmd, ciImage.CiConfigFile)
 			if err != nil {
-				return isCi, nil, err
+				return nil, fmt.Errorf("%s:%d: cannot read CI config: %v", ciImageList, lineNumber, err)
 			}
-		} else {
-			fmt.Println("  Using default CI config")
 		}
+		images = append(images, ciImage)
 	}
+	return images, scanner.Err()
+}
 
-	return isCi, ciConfig, nil
+// newCiConfig reads the given CI config file, the rule thresholds given as flags take precedence.
+func newCiConfig(cmd *cobra.Command, path string) (*viper.Viper, error) {
+	config := viper.New()
+	config.SetConfigType("yaml")
+	if err := bindCiRuleFlags(config, cmd); err != nil {
+		return nil, err
+	}
+
+	fileBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := config.ReadConfig(bytes.NewBuffer(fileBytes)); err != nil {
+		return nil, err
+	}
+	config.SetConfigFile(path)
+	resolveCiConfigPaths(config, path)
+	return config, nil
 }
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/utils"
+	"gopkg.in/yaml.v2"
+)
+
+var lintConfigFile string
+
+// ciCmd groups the commands that support the CI integration
+var ciCmd = &cobra.Command{
+	Use:   "ci",
+	Short: "Commands that support the CI integration (see --ci).",
+}
+
+// ciLintCmd represents the ci lint command
+var ciLintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Validates a CI config (and the rule flags) without analyzing an image.",
+	Args:  cobra.NoArgs,
+	Run:   doCiLintCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(ciCmd)
+	ciCmd.AddCommand(ciLintCmd)
+	ciLintCmd.Flags().StringVar(&lintConfigFile, "ci-config", ".dive-ci", "The yaml file that drives the validation rules.")
+	addCiRuleFlags(ciLintCmd)
+}
+
+// doCiLintCmd validates all rules of the CI config, reports unknown keys, and shows the effective configuration. The
+// exit code is ci.ExitCodeMisconfigured if anything is wrong with the config.
+func doCiLintCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	config := viper.New()
+	if err := bindCiRuleFlags(config, cmd); err != nil {
+		fmt.Printf("ci configuration error: %v\n", err)
+		os.Exit(ci.ExitCodeMisconfigured)
+	}
+	if err := readCiConfig(config, lintConfigFile); err != nil {
+		fmt.Printf("ci configuration error: %v\n", err)
+		os.Exit(ci.ExitCodeMisconfigured)
+	}
+
+	effective, err := yaml.Marshal(ci.EffectiveConfig(config))
+	if err != nil {
+		fmt.Printf("cannot render the effective configuration: %v\n", err)
+		os.Exit(ci.ExitCodeMisconfigured)
+	}
+	fmt.Println(utils.TitleFormat("Effective configuration:"))
+	fmt.Print(string(effective))
+
+	linter := ci.Lint(config)
+	fmt.Println(linter.Report())
+	if !linter.Valid() {
+		os.Exit(ci.ExitCodeMisconfigured)
+	}
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/runtime"
+)
+
+var diffExportFile string
+var diffSummary bool
+var diffDepth int
+var diffSideBySide bool
+
+// diffCmd represents the diff command
+var diffCmd = &cobra.Command{
+	Use:   "diff IMAGE_A IMAGE_B",
+	Short: "Compares the final filesystems of two images (e.g. before and after a base image bump).",
+	Args:  cobra.ExactArgs(2),
+	Run:   doDiffCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().StringVarP(&diffExportFile, "json", "j", "", "Skip the interactive TUI and write the directory size changes to a given file ('-' for stdout).")
+	diffCmd.Flags().BoolVar(&diffSummary, "summary", false, "Skip the interactive TUI and print the directory size changes.")
+	diffCmd.Flags().IntVar(&diffDepth, "depth", 3, "The directory depth to report size changes for (with --json or --summary).")
+	diffCmd.Flags().BoolVar(&diffSideBySide, "side-by-side", false, "Start the interactive TUI with both file trees shown next to each other.")
+}
+
+// doDiffCmd takes two docker image tags, digests, or ids and displays the differences between them
+func doDiffCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	beforeSource, beforeImage := deriveImageSource(args[0])
+	afterSource, afterImage := deriveImageSource(args[1])
+
+	ignoreErrors, err := cmd.Flags().GetBool("ignore-errors")
+	if err != nil {
+		logrus.Error("unable to get 'ignore-errors' option:", err)
+	}
+
+	if diffSideBySide {
+		viper.Set("filetree.side-by-side", true)
+	}
+
+	runtime.RunDiff(runtime.DiffOptions{
+		Before:       beforeImage,
+		BeforeSource: beforeSource,
+		After:        afterImage,
+		AfterSource:  afterSource,
+		IgnoreErrors: viper.GetBool("ignore-errors") || ignoreErrors,
+		ExportFile:   diffExportFile,
+		Summary:      diffSummary,
+		Depth:        diffDepth,
+	})
+}
+
+// deriveImageSource determines the image source from the given image argument (falling back to the configured
+// source), exiting if no source can be determined.
+func deriveImageSource(userImage string) (dive.ImageSource, string) {
+	sourceType, imageStr := dive.DeriveImageSource(userImage)
+
+	if sourceType == dive.SourceUnknown {
+		sourceStr := viper.GetString("source")
+		sourceType = dive.ParseImageSource(sourceStr)
+		if sourceType == dive.SourceUnknown {
+			fmt.Printf("unable to determine image source: %v\n", sourceStr)
+			os.Exit(1)
+		}
+
+		imageStr = userImage
+	}
+	return sourceType, imageStr
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/runtime"
+)
+
+// loadCmd represents the load command
+var loadCmd = &cobra.Command{
+	Use:   "load EXPORT_FILE",
+	Short: "Explores a previous analysis (a --json export written with --json-detail) without fetching the image.",
+	Args:  cobra.ExactArgs(1),
+	Run:   doLoadCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(loadCmd)
+}
+
+// doLoadCmd rebuilds the analysis from the given export file and displays it to the screen
+func doLoadCmd(cmd *cobra.Command, args []string) {
+	initLogging()
+
+	ignoreErrors, err := cmd.Flags().GetBool("ignore-errors")
+	if err != nil {
+		logrus.Error("unable to get 'ignore-errors' option:", err)
+	}
+
+	runtime.RunLoad(runtime.LoadOptions{
+		ExportFile:   args[0],
+		IgnoreErrors: viper.GetBool("ignore-errors") || ignoreErrors,
+	})
+}
@@ -9,6 +9,9 @@ import (
 
 	"github.com/wagoodman/dive/dive"
 	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/runtime"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/export"
 
 	"github.com/mitchellh/go-homedir"
 	log "github.com/sirupsen/logrus"
@@ -18,17 +21,30 @@ import (
 
 var cfgFile string
 var exportFile string
+var exportDetail bool
+var exportTree bool
+var exportFormat string
+var htmlFile string
+var treemapFile string
+var treemapLayer int
+var metricsFile string
+var metricsTopDirs int
+var outputFormat string
+var baselineFile string
+var ciReportFormat string
+var ciReportFile string
 var ciConfigFile string
+var ciImageList string
 var ciConfig = viper.New()
 var isCi bool
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:   "dive [IMAGE]",
+	Use:   "dive [IMAGE...]",
 	Short: "Docker Image Visualizer & Explorer",
 	Long: `This tool provides a way to discover and explore the contents of a docker image. Additionally the tool estimates
 the amount of wasted space and identifies the offending files from the image.`,
-	Args: cobra.MaximumNArgs(1),
+	Args: cobra.ArbitraryArgs,
 	Run:  doAnalyzeCmd,
 }
 
@@ -51,17 +67,27 @@ func initCli() {
 	rootCmd.PersistentFlags().BoolP("version", "v", false, "display version number")
 	rootCmd.PersistentFlags().BoolP("ignore-errors", "i", false, "ignore image parsing errors and run the analysis anyway")
 	rootCmd.Flags().BoolVar(&isCi, "ci", false, "Skip the interactive TUI and validate against CI rules (same as env var CI=true)")
-	rootCmd.Flags().StringVarP(&exportFile, "json", "j", "", "Skip the interactive TUI and write the layer analysis statistics to a given file.")
+	rootCmd.Flags().StringVarP(&exportFile, "json", "j", "", "Skip the interactive TUI and write the layer analysis statistics to a given file ('-' for stdout).")
+	rootCmd.Flags().StringVar(&exportFormat, "json-format", "json", "(only valid with --json given) the format of the export file. Allowed values: json, "+strings.Join(export.Formats, ", ")+" (the tables of layers and inefficient files).")
+	rootCmd.Flags().BoolVar(&exportDetail, "json-detail", false, "(only valid with --json given) include the added, modified, and removed files of every layer in the export.")
+	rootCmd.Flags().BoolVar(&exportTree, "json-tree", false, "(only valid with --json given) include every file of the final (stacked) filesystem in the export.")
+	rootCmd.Flags().StringVar(&htmlFile, "html", "", "Skip the interactive TUI and write a self-contained HTML report of the analysis to a given file.")
+	rootCmd.Flags().StringVar(&treemapFile, "treemap", "", "Skip the interactive TUI and write an SVG treemap of the final filesystem (colored by the layer that provides each file) to a given file.")
+	rootCmd.Flags().IntVar(&treemapLayer, "treemap-layer", 0, "(only valid with --treemap given) show only the files of the layer with the given index.")
+	rootCmd.Flags().StringVar(&metricsFile, "metrics", "", "Skip the interactive TUI and write the image statistics as Prometheus metrics to a given file (e.g. for the textfile collector of the node exporter).")
+	rootCmd.Flags().IntVar(&metricsTopDirs, "metrics-top-dirs", 10, "(only valid with --metrics given) the number of largest directories reported in the metrics.")
+	rootCmd.Flags().StringVar(&outputFormat, "output", "text", "The format of the progress and result messages. Allowed values: "+strings.Join(runtime.Outputs, ", ")+" (one JSON event per line, skips the interactive TUI).")
+	rootCmd.Flags().StringVar(&baselineFile, "baseline", "", "(only valid with --ci or --json given) compare the analysis against a previous --json export and report any regressions.")
 	rootCmd.Flags().StringVar(&ciConfigFile, "ci-config", ".dive-ci", "If CI=true in the environment, use the given yaml to drive validation rules.")
-
-	rootCmd.Flags().String("lowestEfficiency", "0.9", "(only valid with --ci given) lowest allowable image efficiency (as a ratio between 0-1), otherwise CI validation will fail.")
-	rootCmd.Flags().String("highestWastedBytes", "disabled", "(only valid with --ci given) highest allowable bytes wasted, otherwise CI validation will fail.")
-	rootCmd.Flags().String("highestUserWastedPercent", "0.1", "(only valid with --ci given) highest allowable percentage of bytes wasted (as a ratio between 0-1), otherwise CI validation will fail.")
-
-	for _, key := range []string{"lowestEfficiency", "highestWastedBytes", "highestUserWastedPercent"} {
-		if err := ciConfig.BindPFlag(fmt.Sprintf("rules.%s", key), rootCmd.Flags().Lookup(key)); err != nil {
-			log.Fatalf("Unable to bind '%s' flag: %v", key, err)
-		}
+	rootCmd.Flags().StringVar(&ciReportFormat, "ci-report-format", "text", "(only valid with --ci given) the format of the CI report. Allowed values: text, "+strings.Join(ci.ReportFormats, ", "))
+	rootCmd.Flags().StringVar(&ciReportFile, "ci-report-file", "", "(only valid with --ci given) write the CI report (in the --ci-report-format) to the given file, the text report is still shown.")
+	rootCmd.Flags().StringVar(&ciImageList, "ci-image-list", "", "(only valid with --ci given) evaluate all images listed in the given file (one image per line, optionally followed by its own CI config file).")
+	rootCmd.Flags().String("base-image", "", "The image the analyzed image was built from. Layers shared with it are not counted as user layers.")
+	rootCmd.Flags().Int("base-layers", 1, "The number of bottom-most layers that belong to the base image (ignored when --base-image is given).")
+
+	addCiRuleFlags(rootCmd)
+	if err := bindCiRuleFlags(ciConfig, rootCmd); err != nil {
+		log.Fatal(err)
 	}
 
 	if err := ciConfig.BindPFlag("ignore-errors", rootCmd.PersistentFlags().Lookup("ignore-errors")); err != nil {
@@ -69,6 +95,31 @@ func initCli() {
 	}
 }
 
+// ciRuleFlags are the flags that set the threshold of a CI rule.
+var ciRuleFlags = []string{"lowestEfficiency", "highestWastedBytes", "highestUserWastedPercent", "highestImageSize", "highestLayerSize", "highestLayerCount", "highestFileSize"}
+
+// addCiRuleFlags adds a flag for the threshold of each CI rule to the given command.
+func addCiRuleFlags(cmd *cobra.Command) {
+	cmd.Flags().String("lowestEfficiency", ci.DefaultRuleConfigs["lowestEfficiency"], "(only valid with --ci given) lowest allowable image efficiency (as a ratio between 0-1), otherwise CI validation will fail.")
+	cmd.Flags().String("highestWastedBytes", ci.DefaultRuleConfigs["highestWastedBytes"], "(only valid with --ci given) highest allowable bytes wasted, otherwise CI validation will fail.")
+	cmd.Flags().String("highestUserWastedPercent", ci.DefaultRuleConfigs["highestUserWastedPercent"], "(only valid with --ci given) highest allowable percentage of bytes wasted (as a ratio between 0-1), otherwise CI validation will fail.")
+
+	cmd.Flags().String("highestImageSize", ci.DefaultRuleConfigs["highestImageSize"], "(only valid with --ci given) highest allowable image size, otherwise CI validation will fail.")
+	cmd.Flags().String("highestLayerSize", ci.DefaultRuleConfigs["highestLayerSize"], "(only valid with --ci given) highest allowable size of a single layer, otherwise CI validation will fail.")
+	cmd.Flags().String("highestLayerCount", ci.DefaultRuleConfigs["highestLayerCount"], "(only valid with --ci given) highest allowable number of layers, otherwise CI validation will fail.")
+	cmd.Flags().String("highestFileSize", ci.DefaultRuleConfigs["highestFileSize"], "(only valid with --ci given) highest allowable size of a single file in the final image, otherwise CI validation will fail.")
+}
+
+// bindCiRuleFlags lets the CI rule flags of the given command provide the rule thresholds of the given CI config.
+func bindCiRuleFlags(config *viper.Viper, cmd *cobra.Command) error {
+	for _, key := range ciRuleFlags {
+		if err := config.BindPFlag(fmt.Sprintf("rules.%s", key), cmd.Flags().Lookup(key)); err != nil {
+			return fmt.Errorf("unable to bind '%s' flag: %v", key, err)
+		}
+	}
+	return nil
+}
+
 // initConfig reads in config file and ENV variables if set.
 func initConfig() {
 	var err error
@@ -83,6 +134,7 @@ func initConfig() {
 	// keybindings: layer view
 	viper.SetDefault("keybinding.compare-all", "ctrl+a")
 	viper.SetDefault("keybinding.compare-layer", "ctrl+l")
+	viper.SetDefault("keybinding.compare-range", "ctrl+r")
 	// keybindings: filetree view
 	viper.SetDefault("keybinding.toggle-collapse-dir", "space")
 	viper.SetDefault("keybinding.toggle-collapse-all-dir", "ctrl+space")
@@ -92,6 +144,9 @@ func initConfig() {
 	viper.SetDefault("keybinding.toggle-modified-files", "ctrl+m")
 	viper.SetDefault("keybinding.toggle-unmodified-files", "ctrl+u")
 	viper.SetDefault("keybinding.toggle-wrap-tree", "ctrl+p")
+	viper.SetDefault("keybinding.toggle-path-history", "ctrl+o")
+	viper.SetDefault("keybinding.toggle-side-by-side", "ctrl+d")
+	viper.SetDefault("keybinding.toggle-permission-audit", "ctrl+t")
 	viper.SetDefault("keybinding.page-up", "pgup")
 	viper.SetDefault("keybinding.page-down", "pgdn")
 
@@ -102,6 +157,7 @@ func initConfig() {
 	viper.SetDefault("filetree.collapse-dir", false)
 	viper.SetDefault("filetree.pane-width", 0.5)
 	viper.SetDefault("filetree.show-attributes", true)
+	viper.SetDefault("filetree.side-by-side", false)
 
 	viper.SetDefault("container-engine", "docker")
 	viper.SetDefault("ignore-errors", false)
@@ -112,6 +168,13 @@ func initConfig() {
 		os.Exit(1)
 	}
 
+	for _, key := range []string{"base-image", "base-layers"} {
+		if err = viper.BindPFlag(key, rootCmd.Flags().Lookup(key)); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+
 	viper.SetEnvPrefix("DIVE")
 	// replace all - with _ when looking for matching environment variables
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
@@ -127,7 +190,8 @@ func initConfig() {
 	}
 	err = viper.ReadInConfig()
 	if err == nil {
-		fmt.Println("Using config file:", viper.ConfigFileUsed())
+		// stderr keeps stdout clean for outputs that are written to stdout
+		fmt.Fprintln(os.Stderr, "Using config file:", viper.ConfigFileUsed())
 	} else if cfgFile != "" {
 		fmt.Println(err)
 		os.Exit(0)
@@ -0,0 +1,142 @@
+package dive
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/export"
+)
+
+// ResolverProvider returns the resolver for the given image source.
+type ResolverProvider func(source ImageSource) (image.Resolver, error)
+
+// ProgressFunc receives a message for every step of an analysis (e.g. "Fetching image...").
+type ProgressFunc func(message string)
+
+// AnalyzeOptions configures Analyze, the zero value analyzes an image of the docker engine.
+type AnalyzeOptions struct {
+	Source     ImageSource      // the source of references without a source prefix ("docker://"), docker if not given
+	Resolvers  ResolverProvider // provides the resolver for the image source, GetImageResolver if not given
+	BaseImage  string           // the image the analyzed image was built from (fetched from the same source)
+	BaseLayers int              // the number of layers of the base image (ignored when BaseImage is given), 1 if not given
+	Progress   ProgressFunc     // receives progress messages, if given
+}
+
+// Analysis is the result of analyzing a single image.
+type Analysis struct {
+	Reference string // the image reference without the source prefix
+	Source    ImageSource
+	Image     *image.Image
+	Result    *image.AnalysisResult
+}
+
+// Analyze fetches and analyzes the given image reference (e.g. "alpine:latest" or "docker-archive://image.tar"). The
+// context is only checked between the steps of the analysis: it does not cancel resolver.Fetch, so a running fetch
+// (e.g. a pull or an archive export of the docker engine) runs to completion before the cancellation is noticed.
+func Analyze(ctx context.Context, ref string, options AnalyzeOptions) (*Analysis, error) {
+	source, reference := DeriveImageSource(ref)
+	if source == SourceUnknown {
+		source, reference = options.Source, ref
+		if source == SourceUnknown {
+			source = SourceDockerEngine
+		}
+	}
+
+	resolvers := options.Resolvers
+	if resolvers == nil {
+		resolvers = GetImageResolver
+	}
+	resolver, err := resolvers(source)
+	if err != nil {
+		return nil, err
+	}
+
+	options.progress(fmt.Sprintf("Fetching image... %s://%s", source, reference))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	img, err := resolver.Fetch(reference)
+	if err != nil {
+		return nil, fmt.Errorf("cannot fetch image: %v", err)
+	}
+
+	baseLayers := options.BaseLayers
+	if options.BaseImage != "" {
+		options.progress(fmt.Sprintf("Fetching base image... %s", options.BaseImage))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		baseImg, err := resolver.Fetch(options.BaseImage)
+		if err != nil {
+			return nil, fmt.Errorf("cannot fetch base image: %v", err)
+		}
+		baseLayers = image.BaseLayerCount(img, baseImg)
+		if baseLayers == 0 {
+			return nil, fmt.Errorf("image does not share any layers with base image '%s'", options.BaseImage)
+		}
+	}
+	if baseLayers == 0 {
+		baseLayers = 1
+	}
+
+	options.progress("Analyzing image...")
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	result, err := img.AnalyzeWithBaseLayers(baseLayers)
+	if err != nil {
+		return nil, fmt.Errorf("cannot analyze image: %v", err)
+	}
+
+	return &Analysis{
+		Reference: reference,
+		Source:    source,
+		Image:     img,
+		Result:    result,
+	}, nil
+}
+
+func (options AnalyzeOptions) progress(message string) {
+	if options.Progress != nil {
+		options.Progress(message)
+	}
+}
+
+// Export returns the export of the given analysis (as written by "dive --json"), see Export.Marshal and Export.Format
+// to render it.
+func Export(analysis *Analysis) (*export.Export, error) {
+	exp := export.NewExport(analysis.Result)
+	if err := exp.AddDirectories(analysis.Result); err != nil {
+		return nil, err
+	}
+	return exp, nil
+}
+
+// EvaluateCi evaluates the CI rules of the given config (see NewCiConfig) against the given analysis. The baseline rules
+// compare the analysis to the given baseline export (nil if there is none). The evaluator holds the result of every
+// rule (see CiEvaluator.Pass and CiEvaluator.FormatReport).
+func EvaluateCi(analysis *Analysis, config *viper.Viper, baseline *export.Export) (*ci.CiEvaluator, error) {
+	evaluator := ci.NewCiEvaluator(config)
+	if baseline != nil {
+		exp, err := Export(analysis)
+		if err != nil {
+			return nil, err
+		}
+		evaluator.SetRegression(export.NewRegression(baseline, exp))
+	}
+	evaluator.Evaluate(analysis.Result)
+	return evaluator, nil
+}
+
+// NewCiConfig returns a CI config with the default rules of "dive --ci", the rules can be overridden by setting the
+// "rules.<rule>" keys (e.g. "rules.lowestEfficiency").
+func NewCiConfig() *viper.Viper {
+	config := viper.New()
+	for rule, value := range ci.DefaultRuleConfigs {
+		config.SetDefault("rules."+rule, value)
+	}
+	return config
+}
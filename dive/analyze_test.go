@@ -0,0 +1,134 @@
+package dive
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+const testArchive = "../.data/test-docker-image.tar"
+
+func TestAnalyze(t *testing.T) {
+	var messages []string
+	analysis, err := Analyze(context.Background(), "docker-archive://"+testArchive, AnalyzeOptions{
+		BaseLayers: 2,
+		Progress: func(message string) {
+			messages = append(messages, message)
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to analyze image: %v", err)
+	}
+
+	if analysis.Source != SourceDockerArchive || analysis.Reference != testArchive {
+		t.Errorf("unexpected image: %s://%s", analysis.Source, analysis.Reference)
+	}
+	if len(analysis.Result.Layers) != 14 || analysis.Result.BaseLayers != 2 {
+		t.Errorf("expected 14 layers (2 base layers), got %d (%d base layers)", len(analysis.Result.Layers), analysis.Result.BaseLayers)
+	}
+
+	expected := []string{"Fetching image... docker-archive://" + testArchive, "Analyzing image..."}
+	if fmt.Sprint(messages) != fmt.Sprint(expected) {
+		t.Errorf("expected progress %q, got %q", expected, messages)
+	}
+
+	exp, err := Export(analysis)
+	if err != nil {
+		t.Fatalf("unable to export: %v", err)
+	}
+	if len(exp.Image.Directories) == 0 {
+		t.Errorf("expected the exported directory sizes")
+	}
+	if len(exp.Layer) != 14 {
+		t.Errorf("expected 14 exported layers, got %d", len(exp.Layer))
+	}
+
+	// the user layers of the test image waste more than the default 10%
+	config := NewCiConfig()
+	config.Set("rules.highestUserWastedPercent", "disabled")
+	if evaluator, _ := EvaluateCi(analysis, config, nil); !evaluator.Pass {
+		t.Errorf("expected the CI rules to pass: %s", evaluator.Report())
+	}
+
+	config.Set("rules.lowestEfficiency", "0.999")
+	if evaluator, _ := EvaluateCi(analysis, config, nil); evaluator.Pass {
+		t.Errorf("expected the strict CI rules to fail")
+	}
+
+	config = NewCiConfig()
+	config.Set("rules.highestUserWastedPercent", "disabled")
+	config.Set("rules.maxSizeIncrease", "0")
+	evaluator, err := EvaluateCi(analysis, config, exp)
+	if err != nil {
+		t.Fatalf("unable to evaluate the CI rules: %v", err)
+	}
+	if !evaluator.Pass || !strings.Contains(evaluator.Report(), "PASS: maxSizeIncrease") {
+		t.Errorf("expected no regression relative to the same image: %s", evaluator.Report())
+	}
+}
+
+type archiveOnlyResolver struct {
+	fetched []string
+}
+
+func (r *archiveOnlyResolver) Fetch(id string) (*image.Image, error) {
+	r.fetched = append(r.fetched, id)
+	return docker.NewResolverFromArchive().Fetch(testArchive)
+}
+
+func (r *archiveOnlyResolver) Build(args []string) (*image.Image, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func TestAnalyzeWithResolvers(t *testing.T) {
+	resolver := &archiveOnlyResolver{}
+	var sources []ImageSource
+	resolvers := func(source ImageSource) (image.Resolver, error) {
+		sources = append(sources, source)
+		return resolver, nil
+	}
+
+	analysis, err := Analyze(context.Background(), "dive-example:latest", AnalyzeOptions{
+		Source:    SourcePodmanEngine,
+		Resolvers: resolvers,
+		BaseImage: "dive-base:latest",
+	})
+	if err != nil {
+		t.Fatalf("unable to analyze image: %v", err)
+	}
+
+	if fmt.Sprint(sources) != "[podman]" {
+		t.Errorf("expected a podman resolver, got %v", sources)
+	}
+	if fmt.Sprint(resolver.fetched) != "[dive-example:latest dive-base:latest]" {
+		t.Errorf("unexpected fetched images: %v", resolver.fetched)
+	}
+	// the base image is the image itself
+	if analysis.Result.BaseLayers != 14 {
+		t.Errorf("expected 14 base layers, got %d", analysis.Result.BaseLayers)
+	}
+}
+
+func TestAnalyzeErrors(t *testing.T) {
+	failed := func(source ImageSource) (image.Resolver, error) {
+		return nil, fmt.Errorf("no resolver")
+	}
+	if _, err := Analyze(context.Background(), "dive-example", AnalyzeOptions{Resolvers: failed}); err == nil || err.Error() != "no resolver" {
+		t.Errorf("expected the resolver error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := Analyze(ctx, "docker-archive://"+testArchive, AnalyzeOptions{}); err != context.Canceled {
+		t.Errorf("expected the context error, got %v", err)
+	}
+
+	_, err := Analyze(context.Background(), "docker-archive://does-not-exist.tar", AnalyzeOptions{})
+	if err == nil {
+		t.Errorf("expected an error for a missing archive")
+	}
+}
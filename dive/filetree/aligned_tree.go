@@ -0,0 +1,25 @@
+package filetree
+
+// NewAlignedTree returns a copy of the layout tree where every node takes the payload of the node with the same path in
+// the source tree. Nodes that do not exist in the source tree are kept as placeholders (rendered as empty rows), so
+// both trees render the same number of rows. The DiffType and ViewInfo of each layout node is preserved.
+func NewAlignedTree(layout, source *FileTree) *FileTree {
+	tree := layout.Copy()
+	tree.aligned = true
+
+	err := tree.VisitDepthChildFirst(func(node *FileNode) error {
+		sourceNode, _ := source.GetNode(node.Path())
+		if sourceNode == nil {
+			node.Data.FileInfo = FileInfo{Path: node.Path()}
+			node.Data.ViewInfo.Placeholder = true
+			return nil
+		}
+		node.Data.FileInfo = *sourceNode.Data.FileInfo.Copy()
+		node.Data.ViewInfo.Placeholder = false
+		return nil
+	}, nil)
+	if err != nil {
+		return nil
+	}
+	return tree
+}
@@ -0,0 +1,45 @@
+package filetree
+
+import (
+	"testing"
+)
+
+func TestNewAlignedTree(t *testing.T) {
+	layout := NewFileTree()
+	source := NewFileTree()
+
+	for _, p := range []string{"/etc/hosts", "/etc/passwd", "/opt/app"} {
+		_, _, err := layout.AddPath(p, FileInfo{Path: p, TypeFlag: 1, hash: 1, Size: 10})
+		checkError(t, err, "could not setup test")
+	}
+	_, _, err := source.AddPath("/etc/passwd", FileInfo{Path: "/etc/passwd", TypeFlag: 1, hash: 2, Size: 25})
+	checkError(t, err, "could not setup test")
+
+	tree := NewAlignedTree(layout, source)
+
+	expected := `├── etc
+│   ├── hosts
+│   └── passwd
+└── opt
+    └── app
+`
+	if actual := layout.String(false); actual != expected {
+		t.Errorf("expected the layout tree to be untouched:\n--->%s<---\nGot:\n--->%s<---", expected, actual)
+	}
+
+	expected = `├── etc
+
+│   └── passwd
+
+
+`
+	if actual := tree.String(false); actual != expected {
+		t.Errorf("expected aligned tree string:\n--->%s<---\nGot:\n--->%s<---", expected, actual)
+	}
+
+	node, err := tree.GetNode("/etc/passwd")
+	checkError(t, err, "could not get aligned node")
+	if node.Data.FileInfo.Size != 25 {
+		t.Errorf("expected the source payload (size=25), got size=%d", node.Data.FileInfo.Size)
+	}
+}
@@ -0,0 +1,121 @@
+package filetree
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PermissionIssue is a set of risky permission or ownership properties of a single file.
+type PermissionIssue int
+
+const (
+	SpecialPermissionIssue    PermissionIssue = 1 << iota // setuid or setgid file
+	WorldWritableIssue                                    // writable by any user (directories with the sticky bit are exempt)
+	UnknownOwnerIssue                                     // owned by a uid that is missing from /etc/passwd
+	UnexpectedExecutableIssue                             // executable file outside of the expected executable paths
+)
+
+// DefaultExecutablePaths are the paths where executable files are expected to be found.
+var DefaultExecutablePaths = []string{
+	"/bin/**",
+	"/sbin/**",
+	"/usr/**",
+	"/lib/**",
+	"/lib64/**",
+	"/opt/**",
+}
+
+// PermissionAudit flags files with risky permissions or ownership.
+type PermissionAudit struct {
+	knownUids       map[int]bool
+	executablePaths []string
+}
+
+// NewPermissionAudit prepares an audit of the files in the given (stacked) tree. The owner check is skipped when the
+// tree has no /etc/passwd, and the executable check is skipped when no executable paths are given.
+func NewPermissionAudit(tree *FileTree, executablePaths []string) *PermissionAudit {
+	return &PermissionAudit{
+		knownUids:       KnownUids(tree),
+		executablePaths: executablePaths,
+	}
+}
+
+// Issues returns all permission issues found for the given node.
+func (audit *PermissionAudit) Issues(node *FileNode) PermissionIssue {
+	var issues PermissionIssue
+	info := node.Data.FileInfo
+	if node.IsWhiteout() || info.TypeFlag == tar.TypeSymlink {
+		return issues
+	}
+
+	if !info.IsDir && info.Mode&(os.ModeSetuid|os.ModeSetgid) != 0 {
+		issues |= SpecialPermissionIssue
+	}
+	if info.Mode.Perm()&0002 != 0 && !(info.IsDir && info.Mode&os.ModeSticky != 0) {
+		issues |= WorldWritableIssue
+	}
+	if audit.knownUids != nil && info.Uid >= 0 && !audit.knownUids[info.Uid] {
+		issues |= UnknownOwnerIssue
+	}
+	if len(audit.executablePaths) > 0 && !info.IsDir && info.Mode.Perm()&0111 != 0 {
+		expected := false
+		for _, pattern := range audit.executablePaths {
+			if MatchGlob(pattern, node.Path()) {
+				expected = true
+				break
+			}
+		}
+		if !expected {
+			issues |= UnexpectedExecutableIssue
+		}
+	}
+	return issues
+}
+
+// Has indicates if all of the given issues are part of this set.
+func (issue PermissionIssue) Has(other PermissionIssue) bool {
+	return issue&other == other
+}
+
+func (issue PermissionIssue) String() string {
+	var names []string
+	if issue.Has(SpecialPermissionIssue) {
+		names = append(names, "setuid/setgid")
+	}
+	if issue.Has(WorldWritableIssue) {
+		names = append(names, "world-writable")
+	}
+	if issue.Has(UnknownOwnerIssue) {
+		names = append(names, "unknown owner")
+	}
+	if issue.Has(UnexpectedExecutableIssue) {
+		names = append(names, "unexpected executable")
+	}
+	return strings.Join(names, ", ")
+}
+
+// KnownUids returns the user ids defined in the /etc/passwd file of the given tree (nil if there is no such file).
+func KnownUids(tree *FileTree) map[int]bool {
+	node, err := tree.GetNode("/etc/passwd")
+	if err != nil || node == nil || node.Data.FileInfo.Contents == nil {
+		return nil
+	}
+
+	uids := make(map[int]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(node.Data.FileInfo.Contents))
+	for scanner.Scan() {
+		// name:password:uid:gid:gecos:home:shell
+		fields := strings.Split(scanner.Text(), ":")
+		if len(fields) < 3 {
+			continue
+		}
+		if uid, err := strconv.Atoi(fields[2]); err == nil {
+			uids[uid] = true
+		}
+	}
+	return uids
+}
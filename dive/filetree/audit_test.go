@@ -0,0 +1,63 @@
+package filetree
+
+import (
+	"archive/tar"
+	"os"
+	"testing"
+)
+
+func TestPermissionAudit(t *testing.T) {
+	tree := NewFileTree()
+	files := []FileInfo{
+		{Path: "/etc/passwd", TypeFlag: tar.TypeReg, Mode: 0644, Contents: []byte("root:x:0:0:root:/root:/bin/sh\napp:x:1000:1000::/home/app:/bin/sh\n")},
+		{Path: "/bin/su", TypeFlag: tar.TypeReg, Mode: 0755 | os.ModeSetuid},
+		{Path: "/bin/ls", TypeFlag: tar.TypeReg, Mode: 0755},
+		{Path: "/tmp", TypeFlag: tar.TypeDir, Mode: 0777 | os.ModeDir | os.ModeSticky, IsDir: true},
+		{Path: "/data", TypeFlag: tar.TypeDir, Mode: 0777 | os.ModeDir, IsDir: true},
+		{Path: "/home/app/run.sh", TypeFlag: tar.TypeReg, Mode: 0755, Uid: 1000},
+		{Path: "/home/app/notes.txt", TypeFlag: tar.TypeReg, Mode: 0644, Uid: 1001},
+		{Path: "/bin/sh", TypeFlag: tar.TypeSymlink, Mode: 0777, Linkname: "busybox"},
+	}
+	for _, file := range files {
+		if _, _, err := tree.AddPath(file.Path, file); err != nil {
+			t.Fatalf("could not setup test: %v", err)
+		}
+	}
+
+	expected := map[string]PermissionIssue{
+		"/etc/passwd":         0,
+		"/bin/su":             SpecialPermissionIssue,
+		"/bin/ls":             0,
+		"/tmp":                0,
+		"/data":               WorldWritableIssue,
+		"/home/app/run.sh":    UnexpectedExecutableIssue,
+		"/home/app/notes.txt": UnknownOwnerIssue,
+		"/bin/sh":             0,
+	}
+
+	audit := NewPermissionAudit(tree, DefaultExecutablePaths)
+	for path, expectedIssues := range expected {
+		node, err := tree.GetNode(path)
+		if err != nil {
+			t.Fatalf("could not find node %q: %v", path, err)
+		}
+		if actual := audit.Issues(node); actual != expectedIssues {
+			t.Errorf("%s: expected issues '%v', got '%v'", path, expectedIssues, actual)
+		}
+	}
+}
+
+func TestPermissionAuditWithoutPasswd(t *testing.T) {
+	tree := NewFileTree()
+	node, _, err := tree.AddPath("/app/file", FileInfo{Path: "/app/file", TypeFlag: tar.TypeReg, Mode: 0644, Uid: 1234})
+	if err != nil {
+		t.Fatalf("could not setup test: %v", err)
+	}
+
+	if uids := KnownUids(tree); uids != nil {
+		t.Errorf("expected no known uids, got %v", uids)
+	}
+	if issues := NewPermissionAudit(tree, nil).Issues(node); issues != 0 {
+		t.Errorf("expected no issues, got '%v'", issues)
+	}
+}
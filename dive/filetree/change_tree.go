@@ -0,0 +1,55 @@
+package filetree
+
+import (
+	"path"
+)
+
+// NewChangeTree returns a tree that, when stacked on top of the lower tree, results in the upper tree. Every upper path
+// is included as-is, and every lower path that is missing from the upper tree is represented by a whiteout (only the
+// top-most missing path is whited out, not its children).
+func NewChangeTree(lower, upper *FileTree) (*FileTree, error) {
+	changes := upper.Copy()
+
+	visitor := func(node *FileNode) error {
+		if upperNode, _ := upper.GetNode(node.Path()); upperNode != nil {
+			return nil
+		}
+		parentPath, name := path.Split(node.Path())
+		whiteoutPath := path.Join(parentPath, whiteoutPrefix+name)
+		_, _, err := changes.AddPath(whiteoutPath, FileInfo{Path: whiteoutPath})
+		return err
+	}
+
+	// only descend into paths that still exist (as directories) in the upper tree
+	evaluator := func(node *FileNode) bool {
+		if node.Parent == nil || node.Parent == lower.Root {
+			return true
+		}
+		upperParent, _ := upper.GetNode(node.Parent.Path())
+		if upperParent == nil {
+			return false
+		}
+		return upperParent.Data.FileInfo.IsDir || !upperParent.IsLeaf()
+	}
+
+	if err := lower.VisitDepthParentFirst(visitor, evaluator); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// DiffTrees returns a copy of the lower tree where every node is marked (Added, Removed, Modified, or Unmodified)
+// relative to the upper tree. Both trees are expected to be complete (stacked) filesystems.
+func DiffTrees(lower, upper *FileTree) (*FileTree, []PathError, error) {
+	changes, err := NewChangeTree(lower, upper)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tree := lower.Copy()
+	failed, err := tree.CompareAndMark(changes)
+	if err != nil {
+		return nil, failed, err
+	}
+	return tree, failed, nil
+}
@@ -0,0 +1,83 @@
+package filetree
+
+import (
+	"testing"
+)
+
+func TestDiffTrees(t *testing.T) {
+	lower := NewFileTree()
+	upper := NewFileTree()
+
+	lowerPaths := map[string]uint64{
+		"/etc/hosts":        1,
+		"/etc/passwd":       2,
+		"/usr/bin/tool":     3,
+		"/usr/bin/old-tool": 4,
+		"/var/cache/a":      5,
+		"/var/cache/b":      6,
+	}
+	upperPaths := map[string]uint64{
+		"/etc/hosts":    1,
+		"/etc/passwd":   20,
+		"/usr/bin/tool": 3,
+		"/opt/app/run":  7,
+	}
+
+	for p, hash := range lowerPaths {
+		_, _, err := lower.AddPath(p, FileInfo{Path: p, TypeFlag: 1, hash: hash})
+		checkError(t, err, "could not setup test")
+	}
+	for p, hash := range upperPaths {
+		_, _, err := upper.AddPath(p, FileInfo{Path: p, TypeFlag: 1, hash: hash})
+		checkError(t, err, "could not setup test")
+	}
+
+	tree, failed, err := DiffTrees(lower, upper)
+	checkError(t, err, "could not diff trees")
+	if len(failed) > 0 {
+		t.Fatalf("expected no path errors, got %+v", failed)
+	}
+
+	expected := map[string]DiffType{
+		"/etc":              Modified,
+		"/etc/hosts":        Unmodified,
+		"/etc/passwd":       Modified,
+		"/usr":              Modified,
+		"/usr/bin/tool":     Unmodified,
+		"/usr/bin/old-tool": Removed,
+		"/var":              Removed,
+		"/var/cache/a":      Removed,
+		"/var/cache/b":      Removed,
+		"/opt":              Added,
+		"/opt/app/run":      Added,
+	}
+
+	for p, diffType := range expected {
+		node, err := tree.GetNode(p)
+		if err != nil {
+			t.Errorf("expected path %s: %v", p, err)
+			continue
+		}
+		if err := AssertDiffType(node, diffType); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// the inputs must not be altered
+	if _, err := lower.GetNode("/opt"); err == nil {
+		t.Errorf("expected the lower tree to be left untouched")
+	}
+	for _, name := range []string{".wh.var", ".wh.old-tool"} {
+		changes, _ := NewChangeTree(lower, upper)
+		found := false
+		_ = changes.VisitDepthChildFirst(func(node *FileNode) error {
+			if node.Name == name {
+				found = true
+			}
+			return nil
+		}, nil)
+		if !found {
+			t.Errorf("expected a whiteout named %s in the change tree", name)
+		}
+	}
+}
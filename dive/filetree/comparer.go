@@ -30,16 +30,18 @@ func (index TreeIndexKey) String() string {
 }
 
 type Comparer struct {
-	refTrees   []*FileTree
-	trees      map[TreeIndexKey]*FileTree
-	pathErrors map[TreeIndexKey][]PathError
+	refTrees    []*FileTree
+	trees       map[TreeIndexKey]*FileTree
+	stackedTree map[[2]int]*FileTree
+	pathErrors  map[TreeIndexKey][]PathError
 }
 
 func NewComparer(refTrees []*FileTree) Comparer {
 	return Comparer{
-		refTrees:   refTrees,
-		trees:      make(map[TreeIndexKey]*FileTree),
-		pathErrors: make(map[TreeIndexKey][]PathError),
+		refTrees:    refTrees,
+		trees:       make(map[TreeIndexKey]*FileTree),
+		stackedTree: make(map[[2]int]*FileTree),
+		pathErrors:  make(map[TreeIndexKey][]PathError),
 	}
 }
 
@@ -68,6 +70,26 @@ func (cmp *Comparer) GetTree(key TreeIndexKey) (*FileTree, error) {
 	return value, nil
 }
 
+// GetStackedTree returns the given range of trees stacked into a single tree, without marking any differences. This
+// is the complete filesystem as seen from the top-most tree in the range.
+func (cmp *Comparer) GetStackedTree(start, stop int) (*FileTree, error) {
+	rangeKey := [2]int{start, stop}
+	if value, exists := cmp.stackedTree[rangeKey]; exists {
+		return value, nil
+	}
+
+	if start < 0 || stop > len(cmp.refTrees)-1 {
+		return nil, fmt.Errorf("invalid tree range given: %d-%d of %d", start, stop, len(cmp.refTrees)-1)
+	}
+
+	value, _, err := StackTreeRange(cmp.refTrees, start, stop)
+	if err != nil {
+		return nil, err
+	}
+	cmp.stackedTree[rangeKey] = value
+	return value, nil
+}
+
 func (cmp *Comparer) get(key TreeIndexKey) (*FileTree, []PathError, error) {
 	newTree, pathErrors, err := StackTreeRange(cmp.refTrees, key.bottomTreeStart, key.bottomTreeStop)
 	if err != nil {
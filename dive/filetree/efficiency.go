@@ -10,6 +10,7 @@ import (
 type EfficiencyData struct {
 	Path              string
 	Nodes             []*FileNode
+	Sizes             []int64 // the bytes that each of the nodes adds to the CumulativeSize
 	CumulativeSize    int64
 	minDiscoveredSize int64
 }
@@ -36,6 +37,12 @@ func (efs EfficiencySlice) Less(i, j int) bool {
 // 1. Files that are duplicated across layers discounts your score, weighted by file size
 // 2. Files that are removed discounts your score, weighted by the original file size
 func Efficiency(trees []*FileTree) (float64, EfficiencySlice) {
+	return EfficiencyWithIgnore(trees, nil)
+}
+
+// EfficiencyWithIgnore is the same as Efficiency, however, paths for which the ignore function returns true are not
+// considered inefficient (their duplicated or removed bytes do not discount the score).
+func EfficiencyWithIgnore(trees []*FileTree, ignore func(path string) bool) (float64, EfficiencySlice) {
 	efficiencyMap := make(map[string]*EfficiencyData)
 	inefficientMatches := make(EfficiencySlice, 0)
 	currentTree := 0
@@ -95,8 +102,9 @@ func Efficiency(trees []*FileTree) (float64, EfficiencySlice) {
 			data.minDiscoveredSize = sizeBytes
 		}
 		data.Nodes = append(data.Nodes, node)
+		data.Sizes = append(data.Sizes, sizeBytes)
 
-		if len(data.Nodes) == 2 {
+		if len(data.Nodes) == 2 && (ignore == nil || !ignore(path)) {
 			inefficientMatches = append(inefficientMatches, data)
 		}
 
@@ -117,9 +125,13 @@ func Efficiency(trees []*FileTree) (float64, EfficiencySlice) {
 	var minimumPathSizes int64
 	var discoveredPathSizes int64
 
-	for _, value := range efficiencyMap {
+	for path, value := range efficiencyMap {
 		minimumPathSizes += value.minDiscoveredSize
-		discoveredPathSizes += value.CumulativeSize
+		if ignore != nil && len(value.Nodes) > 1 && ignore(path) {
+			discoveredPathSizes += value.minDiscoveredSize
+		} else {
+			discoveredPathSizes += value.CumulativeSize
+		}
 	}
 	var score float64
 	if discoveredPathSizes == 0 {
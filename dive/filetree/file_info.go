@@ -2,12 +2,20 @@ package filetree
 
 import (
 	"archive/tar"
+	"bytes"
+	"fmt"
 	"github.com/cespare/xxhash"
-	"github.com/sirupsen/logrus"
 	"io"
 	"os"
+	"strings"
 )
 
+// retainedContentPaths are the (small) files whose contents are kept in memory, since they are needed after the image
+// has been parsed (/etc/passwd, to check that the user ids that own files are known, see KnownUids).
+var retainedContentPaths = map[string]bool{
+	"etc/passwd": true,
+}
+
 // FileInfo contains tar metadata for a specific FileNode
 type FileInfo struct {
 	Path     string
@@ -19,13 +27,25 @@ type FileInfo struct {
 	Uid      int
 	Gid      int
 	IsDir    bool
+	Contents []byte // only populated for retained files (see retainedContentPaths)
 }
 
 // NewFileInfoFromTarHeader extracts the metadata from a tar header and file contents and generates a new FileInfo object.
-func NewFileInfoFromTarHeader(reader *tar.Reader, header *tar.Header, path string) FileInfo {
+func NewFileInfoFromTarHeader(reader *tar.Reader, header *tar.Header, path string) (FileInfo, error) {
 	var hash uint64
+	var contents []byte
 	if header.Typeflag != tar.TypeDir {
-		hash = getHashFromReader(reader)
+		var err error
+		if header.Typeflag == tar.TypeReg && isRetainedContentPath(path) {
+			var buffer bytes.Buffer
+			hash, err = getHashFromReader(io.TeeReader(reader, &buffer))
+			contents = buffer.Bytes()
+		} else {
+			hash, err = getHashFromReader(reader)
+		}
+		if err != nil {
+			return FileInfo{}, fmt.Errorf("unable to read file '%s': %v", path, err)
+		}
 	}
 
 	return FileInfo{
@@ -38,10 +58,11 @@ func NewFileInfoFromTarHeader(reader *tar.Reader, header *tar.Header, path strin
 		Uid:      header.Uid,
 		Gid:      header.Gid,
 		IsDir:    header.FileInfo().IsDir(),
-	}
+		Contents: contents,
+	}, nil
 }
 
-func NewFileInfo(realPath, path string, info os.FileInfo) FileInfo {
+func NewFileInfo(realPath, path string, info os.FileInfo) (FileInfo, error) {
 	var err error
 
 	// todo: don't use tar types here, create our own...
@@ -54,7 +75,7 @@ func NewFileInfo(realPath, path string, info os.FileInfo) FileInfo {
 
 		linkName, err = os.Readlink(realPath)
 		if err != nil {
-			logrus.Panic("unable to read link:", realPath, err)
+			return FileInfo{}, fmt.Errorf("unable to read link '%s': %v", realPath, err)
 		}
 
 	} else if info.IsDir() {
@@ -66,13 +87,23 @@ func NewFileInfo(realPath, path string, info os.FileInfo) FileInfo {
 	}
 
 	var hash uint64
+	var contents []byte
 	if fileType != tar.TypeDir {
 		file, err := os.Open(realPath)
 		if err != nil {
-			logrus.Panic("unable to read file:", realPath)
+			return FileInfo{}, fmt.Errorf("unable to read file '%s': %v", realPath, err)
 		}
 		defer file.Close()
-		hash = getHashFromReader(file)
+		if fileType == tar.TypeReg && isRetainedContentPath(path) {
+			var buffer bytes.Buffer
+			hash, err = getHashFromReader(io.TeeReader(file, &buffer))
+			contents = buffer.Bytes()
+		} else {
+			hash, err = getHashFromReader(file)
+		}
+		if err != nil {
+			return FileInfo{}, fmt.Errorf("unable to read file '%s': %v", realPath, err)
+		}
 	}
 
 	return FileInfo{
@@ -83,10 +114,11 @@ func NewFileInfo(realPath, path string, info os.FileInfo) FileInfo {
 		Size:     size,
 		Mode:     info.Mode(),
 		// todo: support UID/GID
-		Uid:   -1,
-		Gid:   -1,
-		IsDir: info.IsDir(),
-	}
+		Uid:      -1,
+		Gid:      -1,
+		IsDir:    info.IsDir(),
+		Contents: contents,
+	}, nil
 }
 
 // Copy duplicates a FileInfo
@@ -104,9 +136,24 @@ func (data *FileInfo) Copy() *FileInfo {
 		Uid:      data.Uid,
 		Gid:      data.Gid,
 		IsDir:    data.IsDir,
+		Contents: data.Contents,
 	}
 }
 
+// Hash returns the (xxhash64) hash of the file contents, zero for directories.
+func (data *FileInfo) Hash() uint64 {
+	return data.hash
+}
+
+// SetHash sets the hash of the file contents (e.g. when the info is restored from an export).
+func (data *FileInfo) SetHash(hash uint64) {
+	data.hash = hash
+}
+
+func isRetainedContentPath(path string) bool {
+	return retainedContentPaths[strings.TrimPrefix(path, "/")]
+}
+
 // Compare determines the DiffType between two FileInfos based on the type and contents of each given FileInfo
 func (data *FileInfo) Compare(other FileInfo) DiffType {
 	if data.TypeFlag == other.TypeFlag {
@@ -120,14 +167,14 @@ func (data *FileInfo) Compare(other FileInfo) DiffType {
 	return Modified
 }
 
-func getHashFromReader(reader io.Reader) uint64 {
+func getHashFromReader(reader io.Reader) (uint64, error) {
 	h := xxhash.New()
 
 	buf := make([]byte, 1024)
 	for {
 		n, err := reader.Read(buf)
 		if err != nil && err != io.EOF {
-			logrus.Panic(err)
+			return 0, err
 		}
 		if n == 0 {
 			break
@@ -135,9 +182,9 @@ func getHashFromReader(reader io.Reader) uint64 {
 
 		_, err = h.Write(buf[:n])
 		if err != nil {
-			logrus.Panic(err)
+			return 0, err
 		}
 	}
 
-	return h.Sum64()
+	return h.Sum64(), nil
 }
@@ -157,8 +157,9 @@ func (node *FileNode) MetadataString() string {
 	} else {
 		sizer := func(curNode *FileNode) error {
 			// don't include file sizes of children that have been removed (unless the node in question is a removed dir,
-			// then show the accumulated size of removed files)
-			if curNode.Data.DiffType != Removed || node.Data.DiffType == Removed {
+			// then show the accumulated size of removed files). Aligned trees only hold the files that exist on their
+			// side of the comparison, so all sizes are included.
+			if curNode.Data.DiffType != Removed || node.Data.DiffType == Removed || node.Tree.aligned {
 				sizeBytes += curNode.Data.FileInfo.Size
 			}
 			return nil
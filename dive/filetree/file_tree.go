@@ -29,6 +29,9 @@ type FileTree struct {
 	FileSize uint64
 	Name     string
 	Id       uuid.UUID
+
+	// aligned indicates that the tree mirrors the structure of another tree (see NewAlignedTree)
+	aligned bool
 }
 
 // NewFileTree creates an empty FileTree
@@ -121,6 +124,11 @@ func (tree *FileTree) renderStringTreeBetween(startRow, stopRow int, showAttribu
 	for idx := range params {
 		currentParams := params[idx]
 
+		if currentParams.node.Data.ViewInfo.Placeholder {
+			result += newLine
+			continue
+		}
+
 		if showAttributes {
 			result += currentParams.node.MetadataString() + " "
 		}
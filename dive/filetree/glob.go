@@ -0,0 +1,56 @@
+package filetree
+
+import (
+	"path"
+	"strings"
+)
+
+// MatchGlob reports whether the given absolute path matches the glob pattern. Patterns follow path.Match syntax for
+// each path element, additionally "**" matches any number (including zero) of path elements. A pattern that does not
+// start with "/" or "**" is anchored to the root (e.g. "etc/passwd" is the same as "/etc/passwd").
+func MatchGlob(pattern, filePath string) bool {
+	return matchGlobElements(splitGlobPath(pattern), splitGlobPath(filePath))
+}
+
+// ValidateGlob returns an error if the glob pattern is malformed.
+func ValidateGlob(pattern string) error {
+	for _, element := range splitGlobPath(pattern) {
+		if element == "**" {
+			continue
+		}
+		if _, err := path.Match(element, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func splitGlobPath(value string) []string {
+	value = strings.Trim(path.Clean("/"+value), "/")
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, "/")
+}
+
+func matchGlobElements(pattern, elements []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// try to match the remaining pattern against every possible suffix
+			for idx := 0; idx <= len(elements); idx++ {
+				if matchGlobElements(pattern[1:], elements[idx:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(elements) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], elements[0]); err != nil || !matched {
+			return false
+		}
+		pattern, elements = pattern[1:], elements[1:]
+	}
+	return len(elements) == 0
+}
@@ -0,0 +1,43 @@
+package filetree
+
+import "testing"
+
+func TestMatchGlob(t *testing.T) {
+	table := []struct {
+		pattern  string
+		path     string
+		expected bool
+	}{
+		{"/etc/passwd", "/etc/passwd", true},
+		{"etc/passwd", "/etc/passwd", true},
+		{"/etc/passwd", "/etc/passwd-", false},
+		{"/etc/*", "/etc/passwd", true},
+		{"/etc/*", "/etc/ssl/certs", false},
+		{"**/.git", "/.git", true},
+		{"**/.git", "/app/src/.git", true},
+		{"**/.git", "/app/src/.git/config", false},
+		{"/root/.ssh/**", "/root/.ssh/id_rsa", true},
+		{"/root/.ssh/**", "/root/.ssh/keys/id_rsa", true},
+		{"/root/.ssh/**", "/root/.sshd", false},
+		{"**/*.pem", "/etc/ssl/private/server.pem", true},
+		{"**/*.pem", "/etc/ssl/private/server.pem.bak", false},
+		{"/usr/**/bin/*", "/usr/bin/python", true},
+		{"/usr/**/bin/*", "/usr/local/bin/python", true},
+		{"[", "/[", false},
+	}
+
+	for _, test := range table {
+		if actual := MatchGlob(test.pattern, test.path); actual != test.expected {
+			t.Errorf("MatchGlob(%q, %q): expected %v, got %v", test.pattern, test.path, test.expected, actual)
+		}
+	}
+}
+
+func TestValidateGlob(t *testing.T) {
+	if err := ValidateGlob("/root/**/*.pem"); err != nil {
+		t.Errorf("expected a valid pattern, got %v", err)
+	}
+	if err := ValidateGlob("/root/[.pem"); err == nil {
+		t.Errorf("expected an invalid pattern")
+	}
+}
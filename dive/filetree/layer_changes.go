@@ -0,0 +1,63 @@
+package filetree
+
+// FileChange is a single path that was added, modified, or removed by a tree (layer).
+type FileChange struct {
+	Path     string
+	DiffType DiffType
+	FileInfo FileInfo // the info as of the tree that made the change (the last known info for removed paths)
+}
+
+// LayerChanges returns the paths that every tree changed relative to the stack of all trees below it (the same
+// comparison that is shown for a single layer in the UI, see CompareAndMark), in tree order. Directories are only
+// reported as modified when their own metadata changed (not when only their contents changed).
+func LayerChanges(trees []*FileTree) ([][]FileChange, []PathError, error) {
+	changes, _, errors, err := StackWithLayerChanges(trees)
+	return changes, errors, err
+}
+
+// StackWithLayerChanges is the same as LayerChanges, additionally returning the stack of all trees (the final
+// filesystem) that is built along the way.
+func StackWithLayerChanges(trees []*FileTree) ([][]FileChange, *FileTree, []PathError, error) {
+	changes := make([][]FileChange, len(trees))
+	errors := make([]PathError, 0)
+	stacked := NewFileTree()
+
+	for idx, tree := range trees {
+		marked := stacked.Copy()
+		pathErrors, err := marked.CompareAndMark(tree)
+		errors = append(errors, pathErrors...)
+		if err != nil {
+			return nil, nil, errors, err
+		}
+
+		layerChanges := make([]FileChange, 0)
+		err = marked.VisitDepthParentFirst(func(node *FileNode) error {
+			diffType := node.Data.DiffType
+			if diffType == Modified {
+				lowerNode, _ := stacked.GetNode(node.Path())
+				if lowerNode != nil && lowerNode.Data.FileInfo.Compare(node.Data.FileInfo) == Unmodified {
+					return nil
+				}
+			}
+			if diffType != Unmodified {
+				layerChanges = append(layerChanges, FileChange{
+					Path:     node.Path(),
+					DiffType: diffType,
+					FileInfo: *node.Data.FileInfo.Copy(),
+				})
+			}
+			return nil
+		}, nil)
+		if err != nil {
+			return nil, nil, errors, err
+		}
+		changes[idx] = layerChanges
+
+		pathErrors, err = stacked.Stack(tree)
+		errors = append(errors, pathErrors...)
+		if err != nil {
+			return nil, nil, errors, err
+		}
+	}
+	return changes, stacked, errors, nil
+}
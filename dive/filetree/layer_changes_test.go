@@ -0,0 +1,76 @@
+package filetree
+
+import (
+	"testing"
+)
+
+func TestLayerChanges(t *testing.T) {
+	trees := make([]*FileTree, 3)
+	for idx := range trees {
+		trees[idx] = NewFileTree()
+	}
+
+	for _, path := range []string{"/etc/passwd", "/etc/hosts", "/tmp/cache/a", "/tmp/cache/b"} {
+		_, _, err := trees[0].AddPath(path, FileInfo{Path: path, TypeFlag: 1, hash: 123, Size: 100})
+		checkError(t, err, "could not setup test")
+	}
+
+	// content change, an unchanged file, and a new file in an existing directory
+	_, _, err := trees[1].AddPath("/etc/passwd", FileInfo{Path: "/etc/passwd", TypeFlag: 1, hash: 456, Size: 120})
+	checkError(t, err, "could not setup test")
+	_, _, err = trees[1].AddPath("/etc/hosts", FileInfo{Path: "/etc/hosts", TypeFlag: 1, hash: 123, Size: 100})
+	checkError(t, err, "could not setup test")
+	_, _, err = trees[1].AddPath("/etc/group", FileInfo{Path: "/etc/group", TypeFlag: 1, hash: 789, Size: 10})
+	checkError(t, err, "could not setup test")
+
+	// directory removed
+	_, _, err = trees[2].AddPath("/tmp/.wh.cache", *BlankFileChangeInfo("/tmp/.wh.cache"))
+	checkError(t, err, "could not setup test")
+
+	expected := [][]FileChange{
+		{
+			{Path: "/etc", DiffType: Added},
+			{Path: "/etc/hosts", DiffType: Added},
+			{Path: "/etc/passwd", DiffType: Added},
+			{Path: "/tmp", DiffType: Added},
+			{Path: "/tmp/cache", DiffType: Added},
+			{Path: "/tmp/cache/a", DiffType: Added},
+			{Path: "/tmp/cache/b", DiffType: Added},
+		},
+		{
+			{Path: "/etc/group", DiffType: Added},
+			{Path: "/etc/passwd", DiffType: Modified},
+		},
+		{
+			{Path: "/tmp/cache", DiffType: Removed},
+			{Path: "/tmp/cache/a", DiffType: Removed},
+			{Path: "/tmp/cache/b", DiffType: Removed},
+		},
+	}
+
+	actual, pathErrors, err := LayerChanges(trees)
+	checkError(t, err, "could not compute layer changes")
+	if len(pathErrors) > 0 {
+		t.Fatalf("unexpected path errors: %+v", pathErrors)
+	}
+
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d layers, got %d", len(expected), len(actual))
+	}
+	for layer := range expected {
+		if len(expected[layer]) != len(actual[layer]) {
+			t.Errorf("layer %d: expected %d changes, got %d: %+v", layer, len(expected[layer]), len(actual[layer]), actual[layer])
+			continue
+		}
+		for idx, change := range actual[layer] {
+			if expected[layer][idx].Path != change.Path || expected[layer][idx].DiffType != change.DiffType {
+				t.Errorf("layer %d, change %d: expected %s (%v), got %s (%v)", layer, idx, expected[layer][idx].Path, expected[layer][idx].DiffType, change.Path, change.DiffType)
+			}
+		}
+	}
+
+	// removed paths report the last known info
+	if actual[2][1].FileInfo.Size != 100 {
+		t.Errorf("expected the removed file to retain its size, got %d", actual[2][1].FileInfo.Size)
+	}
+}
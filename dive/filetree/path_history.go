@@ -0,0 +1,77 @@
+package filetree
+
+import (
+	"path"
+	"strings"
+)
+
+// PathChange describes how a single tree (layer) affected a given path.
+type PathChange struct {
+	TreeIndex   int
+	DiffType    DiffType
+	FileInfo    FileInfo
+	HashChanged bool
+}
+
+// PathHistory returns every tree (layer) that touches the given path, in tree order. A tree touches a path when it
+// contains the path itself or a whiteout for the path (or for any of its parent directories).
+func PathHistory(trees []*FileTree, filePath string) []PathChange {
+	filePath = path.Clean("/" + filePath)
+	changes := make([]PathChange, 0)
+
+	var previous *FileInfo
+	for idx, tree := range trees {
+		if isWhitedOut(tree, filePath) {
+			// only report a removal if there was something to remove
+			if previous != nil {
+				changes = append(changes, PathChange{
+					TreeIndex: idx,
+					DiffType:  Removed,
+					FileInfo:  *previous,
+				})
+			}
+			previous = nil
+			// note: the same layer may re-add the path after removing it (e.g. replacing a directory with a file)
+		}
+
+		node, err := tree.GetNode(filePath)
+		if err != nil || node == nil {
+			continue
+		}
+
+		change := PathChange{
+			TreeIndex: idx,
+			FileInfo:  *node.Data.FileInfo.Copy(),
+		}
+		if previous == nil {
+			change.DiffType = Added
+		} else {
+			change.DiffType = previous.Compare(node.Data.FileInfo)
+			change.HashChanged = previous.hash != node.Data.FileInfo.hash
+		}
+
+		changes = append(changes, change)
+		previous = node.Data.FileInfo.Copy()
+	}
+	return changes
+}
+
+// isWhitedOut indicates if the given tree contains a whiteout for the given path or any of its parent directories.
+func isWhitedOut(tree *FileTree, filePath string) bool {
+	nodeNames := strings.Split(strings.Trim(filePath, "/"), "/")
+	node := tree.Root
+	for _, name := range nodeNames {
+		if name == "" {
+			continue
+		}
+		if _, exists := node.Children[whiteoutPrefix+name]; exists {
+			return true
+		}
+		child, exists := node.Children[name]
+		if !exists {
+			return false
+		}
+		node = child
+	}
+	return false
+}
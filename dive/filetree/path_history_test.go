@@ -0,0 +1,67 @@
+package filetree
+
+import (
+	"testing"
+)
+
+func TestPathHistory(t *testing.T) {
+	trees := make([]*FileTree, 5)
+	for idx := range trees {
+		trees[idx] = NewFileTree()
+	}
+
+	_, _, err := trees[0].AddPath("/etc/passwd", FileInfo{Path: "/etc/passwd", TypeFlag: 1, hash: 123, Size: 100})
+	checkError(t, err, "could not setup test")
+
+	// an unrelated change
+	_, _, err = trees[1].AddPath("/etc/hosts", FileInfo{Path: "/etc/hosts", TypeFlag: 1, hash: 456, Size: 20})
+	checkError(t, err, "could not setup test")
+
+	// content change
+	_, _, err = trees[2].AddPath("/etc/passwd", FileInfo{Path: "/etc/passwd", TypeFlag: 1, hash: 789, Size: 120})
+	checkError(t, err, "could not setup test")
+
+	// parent directory removed
+	_, _, err = trees[3].AddPath("/.wh.etc", *BlankFileChangeInfo("/.wh.etc"))
+	checkError(t, err, "could not setup test")
+
+	// re-added with the same contents
+	_, _, err = trees[4].AddPath("/etc/passwd", FileInfo{Path: "/etc/passwd", TypeFlag: 1, hash: 789, Size: 120})
+	checkError(t, err, "could not setup test")
+
+	expected := []PathChange{
+		{TreeIndex: 0, DiffType: Added},
+		{TreeIndex: 2, DiffType: Modified, HashChanged: true},
+		{TreeIndex: 3, DiffType: Removed},
+		{TreeIndex: 4, DiffType: Added},
+	}
+
+	actual := PathHistory(trees, "/etc/passwd")
+
+	if len(expected) != len(actual) {
+		t.Fatalf("expected %d changes, got %d: %+v", len(expected), len(actual), actual)
+	}
+
+	for idx, change := range actual {
+		if expected[idx].TreeIndex != change.TreeIndex {
+			t.Errorf("change %d: expected tree index %d, got %d", idx, expected[idx].TreeIndex, change.TreeIndex)
+		}
+		if expected[idx].DiffType != change.DiffType {
+			t.Errorf("change %d: expected diff type %v, got %v", idx, expected[idx].DiffType, change.DiffType)
+		}
+		if expected[idx].HashChanged != change.HashChanged {
+			t.Errorf("change %d: expected hash changed=%v, got %v", idx, expected[idx].HashChanged, change.HashChanged)
+		}
+	}
+}
+
+func TestPathHistory_MissingPath(t *testing.T) {
+	tree := NewFileTree()
+	_, _, err := tree.AddPath("/etc/.wh.passwd", *BlankFileChangeInfo("/etc/.wh.passwd"))
+	checkError(t, err, "could not setup test")
+
+	actual := PathHistory([]*FileTree{tree}, "/etc/passwd")
+	if len(actual) != 0 {
+		t.Errorf("expected no changes, got %+v", actual)
+	}
+}
@@ -2,8 +2,9 @@ package filetree
 
 // ViewInfo contains UI specific detail for a specific FileNode
 type ViewInfo struct {
-	Collapsed bool
-	Hidden    bool
+	Collapsed   bool
+	Hidden      bool
+	Placeholder bool // rendered as an empty row, keeping the rows of two aligned trees in parity
 }
 
 // NewViewInfo creates a default ViewInfo
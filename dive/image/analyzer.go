@@ -13,8 +13,10 @@ type AnalysisResult struct {
 	RefTrees          []*filetree.FileTree
 	Efficiency        float64
 	SizeBytes         uint64
+	BaseLayers        int     // the number of bottom-most layers that make up the base image
 	UserSizeByes      uint64  // this is all bytes except for the base image
-	WastedUserPercent float64 // = wasted-bytes/user-size-bytes
+	WastedUserBytes   uint64  // the wasted bytes of the copies added by user (non-base image) layers only
+	WastedUserPercent float64 // = wasted-user-bytes/user-size-bytes
 	WastedBytes       uint64
 	Inefficiencies    filetree.EfficiencySlice
 }
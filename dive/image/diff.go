@@ -0,0 +1,169 @@
+package image
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// Diff describes how the final filesystem of one image differs from the final filesystem of another.
+type Diff struct {
+	Before       *Image
+	After        *Image
+	SharedLayers int // the number of bottom-most layers both images have in common (matched by digest)
+
+	BeforeTree *filetree.FileTree // the stacked filesystem of the before image
+	AfterTree  *filetree.FileTree // the stacked filesystem of the after image
+	ChangeTree *filetree.FileTree // the tree that transforms the before filesystem into the after filesystem
+	Tree       *filetree.FileTree // the before filesystem, marked with the changes found in the after filesystem
+}
+
+// DirectoryDelta describes the change in the size of a single directory between two images.
+type DirectoryDelta struct {
+	Path       string
+	BeforeSize int64
+	AfterSize  int64
+}
+
+// Delta returns the (possibly negative) size difference of the directory.
+func (delta DirectoryDelta) Delta() int64 {
+	return delta.AfterSize - delta.BeforeSize
+}
+
+// FileChanges counts the files (not directories) that were added, removed, or modified between the two images.
+type FileChanges struct {
+	Added    int
+	Removed  int
+	Modified int
+}
+
+// Compare stacks the layers of both images and marks the differences between the two resulting filesystems.
+func Compare(before, after *Image) (*Diff, []filetree.PathError, error) {
+	diff := Diff{
+		Before:       before,
+		After:        after,
+		SharedLayers: BaseLayerCount(after, before),
+	}
+
+	var errors []filetree.PathError
+	var err error
+	var pathErrors []filetree.PathError
+
+	diff.BeforeTree, pathErrors, err = stackImage(before)
+	errors = append(errors, pathErrors...)
+	if err != nil {
+		return nil, errors, err
+	}
+
+	diff.AfterTree, pathErrors, err = stackImage(after)
+	errors = append(errors, pathErrors...)
+	if err != nil {
+		return nil, errors, err
+	}
+
+	diff.ChangeTree, err = filetree.NewChangeTree(diff.BeforeTree, diff.AfterTree)
+	if err != nil {
+		return nil, errors, err
+	}
+
+	diff.Tree = diff.BeforeTree.Copy()
+	pathErrors, err = diff.Tree.CompareAndMark(diff.ChangeTree)
+	errors = append(errors, pathErrors...)
+	if err != nil {
+		return nil, errors, err
+	}
+
+	return &diff, errors, nil
+}
+
+// stackImage squashes all layers of the given image into a single tree.
+func stackImage(img *Image) (*filetree.FileTree, []filetree.PathError, error) {
+	if len(img.Trees) == 0 {
+		return nil, nil, fmt.Errorf("image has no layers")
+	}
+	return filetree.StackTreeRange(img.Trees, 0, len(img.Trees)-1)
+}
+
+// FileChanges counts the changed files between both images.
+func (diff *Diff) FileChanges() FileChanges {
+	var changes FileChanges
+	_ = diff.Tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		if node.Data.FileInfo.IsDir || !node.IsLeaf() {
+			return nil
+		}
+		switch node.Data.DiffType {
+		case filetree.Added:
+			changes.Added++
+		case filetree.Removed:
+			changes.Removed++
+		case filetree.Modified:
+			changes.Modified++
+		}
+		return nil
+	}, nil)
+	return changes
+}
+
+// DirectoryDeltas returns the size of every directory (up to the given depth, where "/" is depth 0) in both images,
+// considering only the directories whose size changed. The largest changes (in either direction) are listed first.
+func (diff *Diff) DirectoryDeltas(depth int) []DirectoryDelta {
+	return CompareDirectorySizes(DirectorySizes(diff.BeforeTree, depth), DirectorySizes(diff.AfterTree, depth))
+}
+
+// CompareDirectorySizes returns the directories whose size differs between the given directory sizes (see
+// DirectorySizes). The largest changes (in either direction) are listed first.
+func CompareDirectorySizes(before, after map[string]int64) []DirectoryDelta {
+	deltas := make([]DirectoryDelta, 0)
+	for dir, size := range before {
+		if after[dir] != size {
+			deltas = append(deltas, DirectoryDelta{Path: dir, BeforeSize: size, AfterSize: after[dir]})
+		}
+	}
+	for dir, size := range after {
+		if _, exists := before[dir]; !exists {
+			deltas = append(deltas, DirectoryDelta{Path: dir, AfterSize: size})
+		}
+	}
+
+	sort.Slice(deltas, func(i, j int) bool {
+		left, right := abs(deltas[i].Delta()), abs(deltas[j].Delta())
+		if left != right {
+			return left > right
+		}
+		return deltas[i].Path < deltas[j].Path
+	})
+	return deltas
+}
+
+// DirectorySizes sums the size of all files in the tree into each of their parent directories (up to the given depth).
+func DirectorySizes(tree *filetree.FileTree, depth int) map[string]int64 {
+	sizes := make(map[string]int64)
+	sizes["/"] = 0
+	_ = tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		if node.Data.FileInfo.IsDir {
+			return nil
+		}
+		parents := strings.Split(strings.Trim(path.Dir(node.Path()), "/"), "/")
+		sizes["/"] += node.Data.FileInfo.Size
+		dir := ""
+		for idx, name := range parents {
+			if name == "" || idx >= depth {
+				break
+			}
+			dir += "/" + name
+			sizes[dir] += node.Data.FileInfo.Size
+		}
+		return nil
+	}, nil)
+	return sizes
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -3,27 +3,58 @@ package docker
 import (
 	"fmt"
 	"github.com/wagoodman/dive/dive/image"
+	"io"
 	"os"
 )
 
-type archiveResolver struct{}
+type archiveResolver struct {
+	cache       *LayerCache
+	layerParsed LayerParsedFunc
+}
 
 func NewResolverFromArchive() *archiveResolver {
 	return &archiveResolver{}
 }
 
 func (r *archiveResolver) Fetch(path string) (*image.Image, error) {
+	img, err := NewImageArchiveWithCache(func() (io.ReadCloser, error) {
+		return os.Open(path)
+	}, r.cache, r.layerParsed)
+	if err != nil {
+		return nil, err
+	}
+	return img.ToImage()
+}
+
+// DiffIDs returns the diff_ids of the layers of the given archive, without parsing the layers.
+func (r *archiveResolver) DiffIDs(path string) ([]string, error) {
 	reader, err := os.Open(path)
 	if err != nil {
 		return nil, err
 	}
 	defer reader.Close()
 
-	img, err := NewImageArchive(reader)
+	img := &ImageArchive{}
+	jsonFiles, _, err := img.readArchive(reader, func(string) bool {
+		return false
+	})
 	if err != nil {
 		return nil, err
 	}
-	return img.ToImage()
+	if err := img.readMetadata(jsonFiles); err != nil {
+		return nil, err
+	}
+	return img.config.RootFs.DiffIds, nil
+}
+
+// SetLayerCache shares parsed layers with all other users of the given cache.
+func (r *archiveResolver) SetLayerCache(cache *LayerCache) {
+	r.cache = cache
+}
+
+// SetLayerParsedFunc reports every layer of the fetched images to the given func while it is parsed.
+func (r *archiveResolver) SetLayerParsedFunc(layerParsed LayerParsedFunc) {
+	r.layerParsed = layerParsed
 }
 
 func (r *archiveResolver) Build(args []string) (*image.Image, error) {
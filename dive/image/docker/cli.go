@@ -18,7 +18,8 @@ func runDockerCmd(cmdStr string, args ...string) error {
 	cmd := exec.Command("docker", allArgs...)
 	cmd.Env = os.Environ()
 
-	cmd.Stdout = os.Stdout
+	// the output of the engine is progress, stdout is kept clean for outputs that are written to stdout
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
@@ -2,7 +2,7 @@ package docker
 
 import (
 	"encoding/json"
-	"github.com/sirupsen/logrus"
+	"fmt"
 )
 
 type config struct {
@@ -24,11 +24,11 @@ type historyEntry struct {
 	EmptyLayer bool   `json:"empty_layer"`
 }
 
-func newConfig(configBytes []byte) config {
+func newConfig(configBytes []byte) (config, error) {
 	var imageConfig config
 	err := json.Unmarshal(configBytes, &imageConfig)
 	if err != nil {
-		logrus.Panic(err)
+		return config{}, fmt.Errorf("unable to parse image config: %v", err)
 	}
 
 	layerIdx := 0
@@ -36,10 +36,13 @@ func newConfig(configBytes []byte) config {
 		if imageConfig.History[idx].EmptyLayer {
 			imageConfig.History[idx].ID = "<missing>"
 		} else {
+			if layerIdx >= len(imageConfig.RootFs.DiffIds) {
+				return config{}, fmt.Errorf("image config has more layers in its history than diff_ids")
+			}
 			imageConfig.History[idx].ID = imageConfig.RootFs.DiffIds[layerIdx]
 			layerIdx++
 		}
 	}
 
-	return imageConfig
+	return imageConfig, nil
 }
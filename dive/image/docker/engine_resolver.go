@@ -13,7 +13,10 @@ import (
 	"golang.org/x/net/context"
 )
 
-type engineResolver struct{}
+type engineResolver struct {
+	cache       *LayerCache
+	layerParsed LayerParsedFunc
+}
 
 func NewResolverFromEngine() *engineResolver {
 	return &engineResolver{}
@@ -21,19 +24,25 @@ func NewResolverFromEngine() *engineResolver {
 
 func (r *engineResolver) Fetch(id string) (*image.Image, error) {
 
-	reader, err := r.fetchArchive(id)
-	if err != nil {
-		return nil, err
-	}
-	defer reader.Close()
-
-	img, err := NewImageArchive(reader)
+	img, err := NewImageArchiveWithCache(func() (io.ReadCloser, error) {
+		return r.fetchArchive(id)
+	}, r.cache, r.layerParsed)
 	if err != nil {
 		return nil, err
 	}
 	return img.ToImage()
 }
 
+// SetLayerCache shares parsed layers with all other users of the given cache.
+func (r *engineResolver) SetLayerCache(cache *LayerCache) {
+	r.cache = cache
+}
+
+// SetLayerParsedFunc reports every layer of the fetched images to the given func while it is parsed.
+func (r *engineResolver) SetLayerParsedFunc(layerParsed LayerParsedFunc) {
+	r.layerParsed = layerParsed
+}
+
 func (r *engineResolver) Build(args []string) (*image.Image, error) {
 	id, err := buildImageFromCli(args)
 	if err != nil {
@@ -42,13 +51,48 @@ func (r *engineResolver) Build(args []string) (*image.Image, error) {
 	return r.Fetch(id)
 }
 
+// DiffIDs returns the diff_ids of the layers of the given image, which has to be available locally.
+func (r *engineResolver) DiffIDs(id string) ([]string, error) {
+	dockerClient, err := newDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	inspect, _, err := dockerClient.ImageInspectWithRaw(context.Background(), id)
+	if err != nil {
+		return nil, err
+	}
+	return inspect.RootFS.Layers, nil
+}
+
 func (r *engineResolver) fetchArchive(id string) (io.ReadCloser, error) {
-	var err error
-	var dockerClient *client.Client
+	dockerClient, err := newDockerClient()
+	if err != nil {
+		return nil, err
+	}
 
 	// pull the engineResolver if it does not exist
 	ctx := context.Background()
 
+	_, _, err = dockerClient.ImageInspectWithRaw(ctx, id)
+	if err != nil {
+		// don't use the API, the CLI has more informative output
+		fmt.Fprintln(os.Stderr, "Handler not available locally. Trying to pull '"+id+"'...")
+		err = runDockerCmd("pull", id)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	readCloser, err := dockerClient.ImageSave(ctx, []string{id})
+	if err != nil {
+		return nil, err
+	}
+
+	return readCloser, nil
+}
+
+// newDockerClient returns a client of the docker engine given by the environment (e.g. DOCKER_HOST).
+func newDockerClient() (*client.Client, error) {
 	host := os.Getenv("DOCKER_HOST")
 	var clientOpts []client.Opt
 
@@ -56,7 +100,7 @@ func (r *engineResolver) fetchArchive(id string) (io.ReadCloser, error) {
 	case "ssh":
 		helper, err := connhelper.GetConnectionHelper(host)
 		if err != nil {
-			fmt.Println("docker host", err)
+			fmt.Fprintln(os.Stderr, "docker host", err)
 		}
 		clientOpts = append(clientOpts, func(c *client.Client) error {
 			httpClient := &http.Client{
@@ -79,24 +123,5 @@ func (r *engineResolver) fetchArchive(id string) (io.ReadCloser, error) {
 	}
 
 	clientOpts = append(clientOpts, client.WithAPIVersionNegotiation())
-	dockerClient, err = client.NewClientWithOpts(clientOpts...)
-	if err != nil {
-		return nil, err
-	}
-	_, _, err = dockerClient.ImageInspectWithRaw(ctx, id)
-	if err != nil {
-		// don't use the API, the CLI has more informative output
-		fmt.Println("Handler not available locally. Trying to pull '" + id + "'...")
-		err = runDockerCmd("pull", id)
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	readCloser, err := dockerClient.ImageSave(ctx, []string{id})
-	if err != nil {
-		return nil, err
-	}
-
-	return readCloser, nil
+	return client.NewClientWithOpts(clientOpts...)
 }
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"os"
 	"path"
 	"strings"
 
@@ -15,16 +14,99 @@ import (
 )
 
 type ImageArchive struct {
-	manifest manifest
-	config   config
-	layerMap map[string]*filetree.FileTree
+	manifest    manifest
+	config      config
+	layerMap    map[string]*filetree.FileTree
+	layerParsed LayerParsedFunc
 }
 
+// ArchiveOpener opens an image archive for reading, it is called again when the archive has to be read a second time.
+type ArchiveOpener func() (io.ReadCloser, error)
+
+// LayerParsedFunc is called with the path (within the archive) of every layer right after it has been parsed, which
+// allows reporting the progress of reading a large archive.
+type LayerParsedFunc func(layerPath string)
+
 func NewImageArchive(tarFile io.ReadCloser) (*ImageArchive, error) {
 	img := &ImageArchive{
 		layerMap: make(map[string]*filetree.FileTree),
 	}
 
+	jsonFiles, _, err := img.readArchive(tarFile, nil)
+	if err != nil {
+		return img, err
+	}
+	return img, img.readMetadata(jsonFiles)
+}
+
+// NewImageArchiveWithCache reads the opened image archive, reusing the layers in the given cache (which may be nil)
+// instead of parsing them again. Skipped layers that turn out not to match the cached diff_id are parsed by reading the
+// archive a second time. All parsed layers are added to the cache. The given layerParsed func (which may be nil) is
+// called for every parsed layer.
+func NewImageArchiveWithCache(open ArchiveOpener, cache *LayerCache, layerParsed LayerParsedFunc) (*ImageArchive, error) {
+	img := &ImageArchive{
+		layerMap:    make(map[string]*filetree.FileTree),
+		layerParsed: layerParsed,
+	}
+
+	tarFile, err := open()
+	if err != nil {
+		return img, err
+	}
+	jsonFiles, skipped, err := img.readArchive(tarFile, func(name string) bool {
+		return !cache.knowsPath(name)
+	})
+	tarFile.Close()
+	if err != nil {
+		return img, err
+	}
+
+	if err := img.readMetadata(jsonFiles); err != nil {
+		return img, err
+	}
+	if cache == nil {
+		return img, nil
+	}
+
+	reparse := make(map[string]bool)
+	for idx, layerPath := range img.manifest.LayerTarPaths {
+		diffID := img.diffID(idx)
+		if !skipped[layerPath] {
+			img.storeLayer(cache, layerPath, diffID)
+		} else if tree := cache.lookup(layerPath, diffID); tree != nil {
+			img.layerMap[layerPath] = tree
+		} else {
+			reparse[layerPath] = true
+		}
+	}
+	if len(reparse) == 0 {
+		return img, nil
+	}
+
+	tarFile, err = open()
+	if err != nil {
+		return img, err
+	}
+	_, _, err = img.readArchive(tarFile, func(name string) bool {
+		return reparse[name]
+	})
+	tarFile.Close()
+	if err != nil {
+		return img, err
+	}
+	for idx, layerPath := range img.manifest.LayerTarPaths {
+		if reparse[layerPath] {
+			img.storeLayer(cache, layerPath, img.diffID(idx))
+		}
+	}
+
+	return img, nil
+}
+
+// readArchive reads the layers and json files of an archive in a single pass. Only the layers for which parse returns
+// true are parsed (all layers if parse is nil), the paths of the skipped layers are returned.
+func (img *ImageArchive) readArchive(tarFile io.Reader, parse func(name string) bool) (map[string][]byte, map[string]bool, error) {
+	skipped := make(map[string]bool)
 	tarReader := tar.NewReader(tarFile)
 
 	// store discovered json files in a map so we can read the image in one pass
@@ -39,8 +121,7 @@ func NewImageArchive(tarFile io.ReadCloser) (*ImageArchive, error) {
 		}
 
 		if err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+			return nil, nil, err
 		}
 
 		name := header.Name
@@ -48,16 +129,21 @@ func NewImageArchive(tarFile io.ReadCloser) (*ImageArchive, error) {
 		// some layer tars can be relative layer symlinks to other layer tars
 		if header.Typeflag == tar.TypeSymlink || header.Typeflag == tar.TypeReg {
 
-			if strings.HasSuffix(name, ".tar") {
+			if parse != nil && !parse(name) && (strings.HasSuffix(name, ".tar") || strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, "tgz")) {
+				// the layer content is skipped by the next read of the archive
+				currentLayer++
+				skipped[name] = true
+
+			} else if strings.HasSuffix(name, ".tar") {
 				currentLayer++
 				layerReader := tar.NewReader(tarReader)
 				tree, err := processLayerTar(name, layerReader)
 				if err != nil {
-					return img, err
+					return nil, nil, err
 				}
 
 				// add the layer to the image
-				img.layerMap[tree.Name] = tree
+				img.addLayer(tree)
 
 			} else if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, "tgz") {
 				currentLayer++
@@ -65,7 +151,7 @@ func NewImageArchive(tarFile io.ReadCloser) (*ImageArchive, error) {
 				// Add gzip reader
 				gz, err := gzip.NewReader(tarReader)
 				if err != nil {
-					return img, err
+					return nil, nil, err
 				}
 
 				// Add tar reader
@@ -74,37 +160,68 @@ func NewImageArchive(tarFile io.ReadCloser) (*ImageArchive, error) {
 				// Process layer
 				tree, err := processLayerTar(name, layerReader)
 				if err != nil {
-					return img, err
+					return nil, nil, err
 				}
 
 				// add the layer to the image
-				img.layerMap[tree.Name] = tree
+				img.addLayer(tree)
 
 			} else if strings.HasSuffix(name, ".json") || strings.HasPrefix(name, "sha256:") {
 				fileBuffer, err := ioutil.ReadAll(tarReader)
 				if err != nil {
-					return img, err
+					return nil, nil, err
 				}
 				jsonFiles[name] = fileBuffer
 			}
 		}
 	}
 
+	return jsonFiles, skipped, nil
+}
+
+// addLayer adds a parsed layer to the image.
+func (img *ImageArchive) addLayer(tree *filetree.FileTree) {
+	img.layerMap[tree.Name] = tree
+	if img.layerParsed != nil {
+		img.layerParsed(tree.Name)
+	}
+}
+
+// readMetadata reads the manifest and the config from the json files of the archive.
+func (img *ImageArchive) readMetadata(jsonFiles map[string][]byte) error {
 	manifestContent, exists := jsonFiles["manifest.json"]
 	if !exists {
-		return img, fmt.Errorf("could not find image manifest")
+		return fmt.Errorf("could not find image manifest")
 	}
 
-	img.manifest = newManifest(manifestContent)
+	var err error
+	img.manifest, err = newManifest(manifestContent)
+	if err != nil {
+		return err
+	}
 
 	configContent, exists := jsonFiles[img.manifest.ConfigPath]
 	if !exists {
-		return img, fmt.Errorf("could not find image config")
+		return fmt.Errorf("could not find image config")
 	}
 
-	img.config = newConfig(configContent)
+	img.config, err = newConfig(configContent)
+	return err
+}
 
-	return img, nil
+// diffID returns the diff_id of the layer at the given index of the manifest ("" if the config has none).
+func (img *ImageArchive) diffID(idx int) string {
+	if idx >= len(img.config.RootFs.DiffIds) {
+		return ""
+	}
+	return img.config.RootFs.DiffIds[idx]
+}
+
+// storeLayer adds the parsed layer at the given path to the cache, using the tree of the cache for it from then on.
+func (img *ImageArchive) storeLayer(cache *LayerCache, layerPath, diffID string) {
+	if tree, exists := img.layerMap[layerPath]; exists {
+		img.layerMap[layerPath] = cache.store(layerPath, diffID, tree)
+	}
 }
 
 func processLayerTar(name string, reader *tar.Reader) (*filetree.FileTree, error) {
@@ -151,7 +268,11 @@ func getFileList(tarReader *tar.Reader) ([]filetree.FileInfo, error) {
 		case tar.TypeXHeader:
 			return nil, fmt.Errorf("unexptected tar file (XHeader): type=%v name=%s", header.Typeflag, name)
 		default:
-			files = append(files, filetree.NewFileInfoFromTarHeader(tarReader, header, name))
+			info, err := filetree.NewFileInfoFromTarHeader(tarReader, header, name)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, info)
 		}
 	}
 	return files, nil
@@ -1,6 +1,8 @@
 package docker
 
 import (
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
 	"testing"
 )
 
@@ -41,3 +43,109 @@ func Test_Analysis(t *testing.T) {
 		}
 	}
 }
+
+func Test_AnalysisWithBaseLayers(t *testing.T) {
+	archive, err := TestLoadArchive("../../../.data/test-docker-image.tar")
+	if err != nil {
+		t.Fatalf("unable to fetch archive: %v", err)
+	}
+
+	img, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	table := map[string]struct {
+		baseLayers      int
+		userSizeBytes   uint64
+		wastedUserBytes uint64
+		wastedPercent   float64
+	}{
+		"base-only":       {1, 66237, 32025, 0.4834911001404049},
+		"many-layers":     {5, 47022, 19215, 0.40863850963378845},
+		"all-base-layers": {14, 0, 0, 0},
+	}
+
+	for name, test := range table {
+		result, err := img.AnalyzeWithBaseLayers(test.baseLayers)
+		if err != nil {
+			t.Fatalf("%s.%s: unable to analyze: %v", t.Name(), name, err)
+		}
+
+		if result.BaseLayers != test.baseLayers {
+			t.Errorf("%s.%s: expected baseLayers=%v, got %v", t.Name(), name, test.baseLayers, result.BaseLayers)
+		}
+
+		if result.UserSizeByes != test.userSizeBytes {
+			t.Errorf("%s.%s: expected userSizeBytes=%v, got %v", t.Name(), name, test.userSizeBytes, result.UserSizeByes)
+		}
+
+		if result.WastedUserBytes != test.wastedUserBytes {
+			t.Errorf("%s.%s: expected wastedUserBytes=%v, got %v", t.Name(), name, test.wastedUserBytes, result.WastedUserBytes)
+		}
+
+		if result.WastedUserPercent != test.wastedPercent {
+			t.Errorf("%s.%s: expected wastedPercent=%v, got %v", t.Name(), name, test.wastedPercent, result.WastedUserPercent)
+		}
+	}
+
+	if _, err := img.AnalyzeWithBaseLayers(15); err == nil {
+		t.Errorf("%s: expected an error for an out of range base layer count", t.Name())
+	}
+}
+
+func Test_AnalysisWithBaseLayers_SharedPath(t *testing.T) {
+	base, user := filetree.NewFileTree(), filetree.NewFileTree()
+	if _, _, err := base.AddPath("/etc/app.conf", filetree.FileInfo{Path: "/etc/app.conf", TypeFlag: 1, Size: 100}); err != nil {
+		t.Fatalf("unable to add path: %v", err)
+	}
+	if _, _, err := user.AddPath("/etc/app.conf", filetree.FileInfo{Path: "/etc/app.conf", TypeFlag: 1, Size: 40}); err != nil {
+		t.Fatalf("unable to add path: %v", err)
+	}
+	img := &image.Image{
+		Trees:  []*filetree.FileTree{base, user},
+		Layers: []*image.Layer{{Index: 0, Size: 100}, {Index: 1, Size: 40}},
+	}
+
+	result, err := img.AnalyzeWithBaseLayers(1)
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+
+	// the copy within the base layer is wasted, but not by the user
+	if result.WastedBytes != 140 {
+		t.Errorf("expected wastedBytes=140, got %v", result.WastedBytes)
+	}
+	if result.WastedUserBytes != 40 {
+		t.Errorf("expected wastedUserBytes=40, got %v", result.WastedUserBytes)
+	}
+}
+
+func Test_BaseLayerCount(t *testing.T) {
+	archive, err := TestLoadArchive("../../../.data/test-docker-image.tar")
+	if err != nil {
+		t.Fatalf("unable to fetch archive: %v", err)
+	}
+
+	img, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	base := &image.Image{
+		Trees:  img.Trees[:3],
+		Layers: img.Layers[:3],
+	}
+
+	if actual := image.BaseLayerCount(img, base); actual != 3 {
+		t.Errorf("%s: expected 3 shared layers, got %d", t.Name(), actual)
+	}
+
+	if actual := image.BaseLayerCount(img, img); actual != len(img.Layers) {
+		t.Errorf("%s: expected %d shared layers, got %d", t.Name(), len(img.Layers), actual)
+	}
+
+	if actual := image.BaseLayerCount(base, &image.Image{}); actual != 0 {
+		t.Errorf("%s: expected no shared layers, got %d", t.Name(), actual)
+	}
+}
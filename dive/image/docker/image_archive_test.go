@@ -0,0 +1,43 @@
+package docker
+
+import (
+	"archive/tar"
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func Test_NewImageArchive_MalformedMetadata(t *testing.T) {
+	table := map[string]map[string]string{
+		"manifest":       {"manifest.json": "{"},
+		"empty manifest": {"manifest.json": "[]"},
+		"config": {
+			"manifest.json": `[{"Config": "config.json", "Layers": []}]`,
+			"config.json":   "{",
+		},
+		"history": {
+			"manifest.json": `[{"Config": "config.json", "Layers": []}]`,
+			"config.json":   `{"history": [{"created_by": "ADD"}], "rootfs": {"diff_ids": []}}`,
+		},
+	}
+
+	for name, files := range table {
+		var buffer bytes.Buffer
+		writer := tar.NewWriter(&buffer)
+		for fileName, content := range files {
+			if err := writer.WriteHeader(&tar.Header{Name: fileName, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+				t.Fatalf("%s: unable to write archive: %v", name, err)
+			}
+			if _, err := writer.Write([]byte(content)); err != nil {
+				t.Fatalf("%s: unable to write archive: %v", name, err)
+			}
+		}
+		if err := writer.Close(); err != nil {
+			t.Fatalf("%s: unable to write archive: %v", name, err)
+		}
+
+		if _, err := NewImageArchive(ioutil.NopCloser(&buffer)); err == nil {
+			t.Errorf("%s: expected an error for the malformed archive", name)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package docker
+
+import (
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func Test_Compare(t *testing.T) {
+	archive, err := TestLoadArchive("../../../.data/test-docker-image.tar")
+	if err != nil {
+		t.Fatalf("unable to fetch archive: %v", err)
+	}
+
+	after, err := archive.ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+
+	before := &image.Image{
+		Trees:  after.Trees[:5],
+		Layers: after.Layers[:5],
+	}
+
+	diff, pathErrors, err := image.Compare(before, after)
+	if err != nil {
+		t.Fatalf("unable to compare images: %v", err)
+	}
+	if len(pathErrors) > 0 {
+		t.Errorf("expected no path errors, got %+v", pathErrors)
+	}
+
+	if diff.SharedLayers != 5 {
+		t.Errorf("expected 5 shared layers, got %d", diff.SharedLayers)
+	}
+
+	expectedChanges := image.FileChanges{Added: 6, Removed: 1, Modified: 0}
+	if actual := diff.FileChanges(); actual != expectedChanges {
+		t.Errorf("expected file changes %+v, got %+v", expectedChanges, actual)
+	}
+
+	expectedDeltas := []image.DirectoryDelta{
+		{Path: "/", BeforeSize: 1167171, AfterSize: 1188573},
+		{Path: "/root", BeforeSize: 6405, AfterSize: 21402},
+		{Path: "/root/.data", BeforeSize: 0, AfterSize: 8592},
+		{Path: "/root/example", BeforeSize: 6405, AfterSize: 0},
+		{Path: "/tmp", BeforeSize: 0, AfterSize: 6405},
+	}
+	actualDeltas := diff.DirectoryDeltas(2)
+	if len(expectedDeltas) != len(actualDeltas) {
+		t.Fatalf("expected %d directory deltas, got %d: %+v", len(expectedDeltas), len(actualDeltas), actualDeltas)
+	}
+	for idx, expected := range expectedDeltas {
+		if expected != actualDeltas[idx] {
+			t.Errorf("expected directory delta %+v, got %+v", expected, actualDeltas[idx])
+		}
+	}
+
+	node, err := diff.Tree.GetNode("/root/example/somefile1.txt")
+	if err != nil {
+		t.Fatalf("expected removed path to remain in the marked tree: %v", err)
+	}
+	if node.Data.DiffType != filetree.Removed {
+		t.Errorf("expected /root/example/somefile1.txt to be removed, got %v", node.Data.DiffType)
+	}
+}
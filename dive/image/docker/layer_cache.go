@@ -0,0 +1,125 @@
+package docker
+
+import (
+	"sync"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// LayerCache retains parsed layer trees by diff_id, such that several images that share layers (e.g. the same base
+// image) only parse each layer once. Archives are read in a single pass and the diff_id of a layer is only known once
+// the manifest and config have been read, so a layer is skipped when its path within the archive was seen with an
+// already parsed diff_id before. The skipped layer is checked against its diff_id afterwards and parsed in a second
+// pass over the archive on a mismatch.
+//
+// A tree is only retained while an image that has not been evaluated yet references its diff_id (see Reserve and
+// Release), trees that no such image references are dropped on the next Release.
+type LayerCache struct {
+	lock    sync.Mutex
+	trees   map[string]*filetree.FileTree // by diff_id
+	paths   map[string]string             // the diff_id of each layer path seen in an archive
+	pending map[string]int                // the number of reservations of each diff_id
+	hits    int
+}
+
+func NewLayerCache() *LayerCache {
+	return &LayerCache{
+		trees:   make(map[string]*filetree.FileTree),
+		paths:   make(map[string]string),
+		pending: make(map[string]int),
+	}
+}
+
+// Hits returns the number of layers that were reused instead of parsed.
+func (cache *LayerCache) Hits() int {
+	if cache == nil {
+		return 0
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	return cache.hits
+}
+
+// Reserve retains the trees of the given diff_ids (the layers of an image that is yet to be fetched) until they are
+// released again.
+func (cache *LayerCache) Reserve(diffIDs []string) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	for _, diffID := range diffIDs {
+		cache.pending[diffID]++
+	}
+}
+
+// Release gives up the reservation of the given diff_ids (nil if there was none) and drops every tree that is no
+// longer reserved.
+func (cache *LayerCache) Release(diffIDs []string) {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	for _, diffID := range diffIDs {
+		if cache.pending[diffID] > 1 {
+			cache.pending[diffID]--
+		} else {
+			delete(cache.pending, diffID)
+		}
+	}
+	for diffID := range cache.trees {
+		if cache.pending[diffID] == 0 {
+			delete(cache.trees, diffID)
+		}
+	}
+	for layerPath, diffID := range cache.paths {
+		if _, exists := cache.trees[diffID]; !exists {
+			delete(cache.paths, layerPath)
+		}
+	}
+}
+
+// knowsPath indicates whether the given archive path was seen with a diff_id that is still cached, such that reading
+// the layer can be skipped.
+func (cache *LayerCache) knowsPath(layerPath string) bool {
+	if cache == nil {
+		return false
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	diffID, exists := cache.paths[layerPath]
+	if !exists {
+		return false
+	}
+	_, exists = cache.trees[diffID]
+	return exists
+}
+
+// lookup returns the tree of the given diff_id if the layer at the given archive path was cached for the same diff_id
+// (nil on a miss or mismatch, the layer has to be parsed then).
+func (cache *LayerCache) lookup(layerPath, diffID string) *filetree.FileTree {
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	if cache.paths[layerPath] != diffID {
+		return nil
+	}
+	tree := cache.trees[diffID]
+	if tree != nil {
+		cache.hits++
+	}
+	return tree
+}
+
+// store records the tree of a layer, returning the tree that should be used for it: a tree that was already parsed
+// for the same diff_id (from a different archive path) takes precedence over the given tree.
+func (cache *LayerCache) store(layerPath, diffID string, tree *filetree.FileTree) *filetree.FileTree {
+	if cache == nil || diffID == "" {
+		return tree
+	}
+	cache.lock.Lock()
+	defer cache.lock.Unlock()
+	cache.paths[layerPath] = diffID
+	if existing, exists := cache.trees[diffID]; exists {
+		if existing != tree {
+			cache.hits++
+		}
+		return existing
+	}
+	cache.trees[diffID] = tree
+	return tree
+}
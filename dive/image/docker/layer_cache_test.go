@@ -0,0 +1,115 @@
+package docker
+
+import (
+	"io"
+	"os"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+const testArchive = "../../../.data/test-docker-image.tar"
+
+func loadCachedArchive(t *testing.T, cache *LayerCache) *ImageArchive {
+	archive, err := NewImageArchiveWithCache(func() (io.ReadCloser, error) {
+		return os.Open(testArchive)
+	}, cache, nil)
+	if err != nil {
+		t.Fatalf("unable to read archive: %v", err)
+	}
+	return archive
+}
+
+func Test_LayerCache(t *testing.T) {
+	cache := NewLayerCache()
+
+	diffIDs, err := NewResolverFromArchive().DiffIDs(testArchive)
+	if err != nil {
+		t.Fatalf("unable to read diff_ids: %v", err)
+	}
+	// both images are pending
+	cache.Reserve(diffIDs)
+	cache.Reserve(diffIDs)
+
+	first, err := loadCachedArchive(t, cache).ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	if cache.Hits() != 0 {
+		t.Errorf("expected no cache hits for the first image, got %d", cache.Hits())
+	}
+	cache.Release(diffIDs)
+
+	second, err := loadCachedArchive(t, cache).ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	if cache.Hits() != len(second.Trees) {
+		t.Errorf("expected %d cache hits, got %d", len(second.Trees), cache.Hits())
+	}
+
+	for idx := range first.Trees {
+		if first.Trees[idx] != second.Trees[idx] {
+			t.Errorf("expected layer %d to be reused", idx)
+		}
+	}
+
+	firstAnalysis, err := first.Analyze()
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+	secondAnalysis, err := second.Analyze()
+	if err != nil {
+		t.Fatalf("unable to analyze: %v", err)
+	}
+	if firstAnalysis.WastedBytes != secondAnalysis.WastedBytes || firstAnalysis.SizeBytes != secondAnalysis.SizeBytes {
+		t.Errorf("expected the same analysis for both images, got %+v and %+v", firstAnalysis, secondAnalysis)
+	}
+
+	cache.Release(diffIDs)
+	if len(cache.trees) != 0 || len(cache.paths) != 0 {
+		t.Errorf("expected all trees to be released, got %d trees and %d paths", len(cache.trees), len(cache.paths))
+	}
+}
+
+func Test_LayerCache_ReleasesUnreservedTrees(t *testing.T) {
+	cache := NewLayerCache()
+
+	loadCachedArchive(t, cache)
+	if len(cache.trees) == 0 {
+		t.Fatalf("expected the parsed trees to be cached")
+	}
+
+	cache.Release(nil)
+	if len(cache.trees) != 0 {
+		t.Errorf("expected the unreserved trees to be released, got %d", len(cache.trees))
+	}
+}
+
+func Test_LayerCache_ReparsesMismatchedLayer(t *testing.T) {
+	cache := NewLayerCache()
+
+	archive := loadCachedArchive(t, cache)
+	layerPath := archive.manifest.LayerTarPaths[0]
+	parsed := archive.layerMap[layerPath]
+
+	// the path is known for a different diff_id (e.g. from another archive), so the layer is skipped but not reused
+	stale := filetree.NewFileTree()
+	cache.paths[layerPath] = "sha256:stale"
+	cache.trees["sha256:stale"] = stale
+	delete(cache.trees, archive.config.RootFs.DiffIds[0])
+
+	img, err := loadCachedArchive(t, cache).ToImage()
+	if err != nil {
+		t.Fatalf("unable to convert to image: %v", err)
+	}
+	if img.Trees[0] == stale || img.Trees[0] == parsed {
+		t.Errorf("expected the mismatched layer to be parsed again")
+	}
+	if img.Trees[0].FileSize != parsed.FileSize {
+		t.Errorf("expected the parsed layer to have %d bytes, got %d", parsed.FileSize, img.Trees[0].FileSize)
+	}
+	if cache.Hits() != len(img.Trees)-1 {
+		t.Errorf("expected %d cache hits, got %d", len(img.Trees)-1, cache.Hits())
+	}
+}
@@ -2,7 +2,7 @@ package docker
 
 import (
 	"encoding/json"
-	"github.com/sirupsen/logrus"
+	"fmt"
 )
 
 type manifest struct {
@@ -11,11 +11,14 @@ type manifest struct {
 	LayerTarPaths []string `json:"Layers"`
 }
 
-func newManifest(manifestBytes []byte) manifest {
-	var manifest []manifest
-	err := json.Unmarshal(manifestBytes, &manifest)
+func newManifest(manifestBytes []byte) (manifest, error) {
+	var manifests []manifest
+	err := json.Unmarshal(manifestBytes, &manifests)
 	if err != nil {
-		logrus.Panic(err)
+		return manifest{}, fmt.Errorf("unable to parse image manifest: %v", err)
 	}
-	return manifest[0]
+	if len(manifests) == 0 {
+		return manifest{}, fmt.Errorf("image manifest has no images")
+	}
+	return manifests[0], nil
 }
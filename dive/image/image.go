@@ -1,6 +1,8 @@
 package image
 
 import (
+	"fmt"
+
 	"github.com/wagoodman/dive/dive/filetree"
 )
 
@@ -9,31 +11,83 @@ type Image struct {
 	Layers []*Layer
 }
 
+// Analyze evaluates the image, considering only the bottom-most layer as the base image.
 func (img *Image) Analyze() (*AnalysisResult, error) {
+	return img.AnalyzeWithBaseLayers(1)
+}
+
+// AnalyzeWithBaseLayers evaluates the image, considering the given number of bottom-most layers as the base image.
+// Bytes wasted only within the base image layers are not attributed to the user layers.
+func (img *Image) AnalyzeWithBaseLayers(baseLayers int) (*AnalysisResult, error) {
+	if baseLayers < 0 || baseLayers > len(img.Layers) {
+		return nil, fmt.Errorf("invalid base layer count: %d (image has %d layers)", baseLayers, len(img.Layers))
+	}
+	return analyze(img.Trees, img.Layers, baseLayers, nil), nil
+}
 
-	efficiency, inefficiencies := filetree.Efficiency(img.Trees)
+// WithIgnoredPaths re-evaluates the analysis without considering the paths for which the ignore function returns true
+// as inefficient (e.g. known and accepted duplication in the base image).
+func (result *AnalysisResult) WithIgnoredPaths(ignore func(path string) bool) *AnalysisResult {
+	return analyze(result.RefTrees, result.Layers, result.BaseLayers, ignore)
+}
+
+func analyze(trees []*filetree.FileTree, layers []*Layer, baseLayers int, ignore func(path string) bool) *AnalysisResult {
+	efficiency, inefficiencies := filetree.EfficiencyWithIgnore(trees, ignore)
 	var sizeBytes, userSizeBytes uint64
 
-	for i, v := range img.Layers {
+	for i, v := range layers {
 		sizeBytes += v.Size
-		if i != 0 {
+		if i >= baseLayers {
 			userSizeBytes += v.Size
 		}
 	}
 
-	var wastedBytes uint64
+	userTrees := make(map[*filetree.FileTree]bool)
+	for i, tree := range trees {
+		if i >= baseLayers {
+			userTrees[tree] = true
+		}
+	}
+
+	var wastedBytes, wastedUserBytes uint64
 	for _, file := range inefficiencies {
 		wastedBytes += uint64(file.CumulativeSize)
+
+		// only the copies that were introduced by a user layer are wasted by the user
+		for idx, node := range file.Nodes {
+			if userTrees[node.Tree] {
+				wastedUserBytes += uint64(file.Sizes[idx])
+			}
+		}
+	}
+
+	var wastedUserPercent float64
+	if userSizeBytes > 0 {
+		wastedUserPercent = float64(wastedUserBytes) / float64(userSizeBytes)
 	}
 
 	return &AnalysisResult{
-		Layers:            img.Layers,
-		RefTrees:          img.Trees,
+		Layers:            layers,
+		RefTrees:          trees,
 		Efficiency:        efficiency,
+		BaseLayers:        baseLayers,
 		UserSizeByes:      userSizeBytes,
 		SizeBytes:         sizeBytes,
 		WastedBytes:       wastedBytes,
-		WastedUserPercent: float64(wastedBytes) / float64(userSizeBytes),
+		WastedUserBytes:   wastedUserBytes,
+		WastedUserPercent: wastedUserPercent,
 		Inefficiencies:    inefficiencies,
-	}, nil
+	}
+}
+
+// BaseLayerCount returns the number of bottom-most layers the image shares with the given base image (matched by digest).
+func BaseLayerCount(img, base *Image) int {
+	var count int
+	for idx, layer := range img.Layers {
+		if idx >= len(base.Layers) || layer.Digest == "" || layer.Digest != base.Layers[idx].Digest {
+			break
+		}
+		count++
+	}
+	return count
 }
@@ -21,7 +21,8 @@ func runPodmanCmd(cmdStr string, args ...string) error {
 	cmd := exec.Command("podman", allArgs...)
 	cmd.Env = os.Environ()
 
-	cmd.Stdout = os.Stdout
+	// the output of the engine is progress, stdout is kept clean for outputs that are written to stdout
+	cmd.Stdout = os.Stderr
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 
@@ -4,15 +4,29 @@ import (
 	"fmt"
 	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/dive/image/docker"
+	"io"
 	"io/ioutil"
 )
 
-type resolver struct{}
+type resolver struct {
+	cache       *docker.LayerCache
+	layerParsed docker.LayerParsedFunc
+}
 
 func NewResolverFromEngine() *resolver {
 	return &resolver{}
 }
 
+// SetLayerCache shares parsed layers with all other users of the given cache.
+func (r *resolver) SetLayerCache(cache *docker.LayerCache) {
+	r.cache = cache
+}
+
+// SetLayerParsedFunc reports every layer of the fetched images to the given func while it is parsed.
+func (r *resolver) SetLayerParsedFunc(layerParsed docker.LayerParsedFunc) {
+	r.layerParsed = layerParsed
+}
+
 func (r *resolver) Build(args []string) (*image.Image, error) {
 	id, err := buildImageFromCli(args)
 	if err != nil {
@@ -33,12 +47,13 @@ func (r *resolver) Fetch(id string) (*image.Image, error) {
 }
 
 func (r *resolver) resolveFromDockerArchive(id string) (*image.Image, error) {
-	err, reader := streamPodmanCmd("image", "save", id)
-	if err != nil {
-		return nil, err
-	}
-
-	img, err := docker.NewImageArchive(ioutil.NopCloser(reader))
+	img, err := docker.NewImageArchiveWithCache(func() (io.ReadCloser, error) {
+		err, reader := streamPodmanCmd("image", "save", id)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.NopCloser(reader), nil
+	}, r.cache, r.layerParsed)
 	if err != nil {
 		return nil, err
 	}
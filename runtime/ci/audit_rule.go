@@ -0,0 +1,132 @@
+package ci
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// AuditCiRule is a CI rule that fails when any file in the final image has the given permission issue, unless the
+// file is allowlisted.
+type AuditCiRule struct {
+	key             string
+	issue           filetree.PermissionIssue
+	allow           []string
+	executablePaths []string
+	stack           *stackedTreeCache
+}
+
+func (rule *AuditCiRule) Key() string {
+	return rule.key
+}
+
+func (rule *AuditCiRule) configKeys() map[string][]string {
+	if rule.issue == filetree.UnexpectedExecutableIssue {
+		return map[string][]string{rule.key: append([]string{"paths"}, auditRuleKeys...)}
+	}
+	return map[string][]string{rule.key: auditRuleKeys}
+}
+
+func (rule *AuditCiRule) Configuration() string {
+	return "enabled"
+}
+
+func (rule *AuditCiRule) Validate() error {
+	for _, pattern := range append(append([]string{}, rule.allow...), rule.executablePaths...) {
+		if err := filetree.ValidateGlob(pattern); err != nil {
+			return fmt.Errorf("invalid path pattern ('%v'): %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (rule *AuditCiRule) Evaluate(analysis *image.AnalysisResult) (RuleStatus, string) {
+	tree, err := rule.stack.get(analysis)
+	if err != nil {
+		return RuleFailed, fmt.Sprintf("unable to stack the image layers: %v", err)
+	}
+
+	if rule.issue == filetree.UnknownOwnerIssue && filetree.KnownUids(tree) == nil {
+		return RulePassed, "no /etc/passwd found, owners were not checked"
+	}
+
+	audit := filetree.NewPermissionAudit(tree, rule.executablePaths)
+	var found []string
+	_ = tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		if !audit.Issues(node).Has(rule.issue) {
+			return nil
+		}
+		for _, pattern := range rule.allow {
+			if filetree.MatchGlob(pattern, node.Path()) {
+				return nil
+			}
+		}
+		if rule.issue == filetree.UnknownOwnerIssue {
+			found = append(found, fmt.Sprintf("%s (uid %d)", node.Path(), node.Data.FileInfo.Uid))
+		} else {
+			found = append(found, node.Path())
+		}
+		return nil
+	}, nil)
+
+	if len(found) > 0 {
+		return RuleFailed, fmt.Sprintf("%s files found (%d): %s", rule.issue, len(found), summarizePaths(found))
+	}
+	return RulePassed, ""
+}
+
+// loadAuditCiRules returns the permission audit rules that are configured (these are never enabled by default). Each
+// rule is either enabled with a boolean, or configured with an "allow" list of globs (and "paths" for the expected
+// executable locations).
+func loadAuditCiRules(config *viper.Viper, stack *stackedTreeCache, all bool) []CiRule {
+	var rules = make([]CiRule, 0)
+
+	for _, candidate := range []struct {
+		key   string
+		issue filetree.PermissionIssue
+	}{
+		{"setuidFiles", filetree.SpecialPermissionIssue},
+		{"worldWritableFiles", filetree.WorldWritableIssue},
+		{"unknownOwners", filetree.UnknownOwnerIssue},
+		{"unexpectedExecutables", filetree.UnexpectedExecutableIssue},
+	} {
+		configKey := fmt.Sprintf("rules.%s", candidate.key)
+		if !all && !isAuditRuleEnabled(config.Get(configKey)) {
+			continue
+		}
+
+		rule := &AuditCiRule{
+			key:   candidate.key,
+			issue: candidate.issue,
+			allow: config.GetStringSlice(configKey + ".allow"),
+			stack: stack,
+		}
+		if candidate.issue == filetree.UnexpectedExecutableIssue {
+			rule.executablePaths = config.GetStringSlice(configKey + ".paths")
+			if len(rule.executablePaths) == 0 {
+				rule.executablePaths = filetree.DefaultExecutablePaths
+			}
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+func isAuditRuleEnabled(value interface{}) bool {
+	switch value := value.(type) {
+	case nil:
+		return false
+	case bool:
+		return value
+	case string:
+		enabled, _ := strconv.ParseBool(value)
+		return enabled
+	default:
+		// configured with an allowlist
+		return true
+	}
+}
@@ -0,0 +1,172 @@
+package ci
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/export"
+)
+
+// BaselineCiRule is a CI rule that is evaluated against the changes relative to a baseline export (see --baseline)
+// instead of against the image in isolation.
+type BaselineCiRule struct {
+	key             string
+	thresholds      ruleThresholds
+	configValidator func(string) error
+	evaluator       func(*export.Regression, string) (RuleStatus, string)
+	regression      *export.Regression
+}
+
+func newBaselineCiRule(key string, thresholds ruleThresholds, validator func(string) error, evaluator func(*export.Regression, string) (RuleStatus, string)) *BaselineCiRule {
+	return &BaselineCiRule{
+		key:             key,
+		thresholds:      thresholds,
+		configValidator: validator,
+		evaluator:       evaluator,
+	}
+}
+
+func (rule *BaselineCiRule) Key() string {
+	return rule.key
+}
+
+func (rule *BaselineCiRule) configKeys() map[string][]string {
+	return map[string][]string{rule.key: thresholdRuleKeys}
+}
+
+func (rule *BaselineCiRule) Configuration() string {
+	return rule.thresholds.fail
+}
+
+func (rule *BaselineCiRule) WarnConfiguration() string {
+	return rule.thresholds.warn
+}
+
+func (rule *BaselineCiRule) Validate() error {
+	if err := rule.thresholds.validate(rule.configValidator); err != nil {
+		return err
+	}
+	if rule.regression == nil {
+		return fmt.Errorf("no baseline given (set 'baseline' in the CI config or use --baseline)")
+	}
+	return nil
+}
+
+func (rule *BaselineCiRule) Evaluate(_ *image.AnalysisResult) (RuleStatus, string) {
+	return rule.thresholds.evaluate(func(value string) (RuleStatus, string) {
+		return rule.evaluator(rule.regression, value)
+	})
+}
+
+// parseSizeIncrease parses either an absolute size (e.g. "10MB") or a percentage of the baseline size (e.g. "5%").
+func parseSizeIncrease(value string) (bytes uint64, percent float64, isPercent bool, err error) {
+	if strings.HasSuffix(value, "%") {
+		percent, err = strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+		if err == nil && percent < 0 {
+			err = fmt.Errorf("percentage must not be negative")
+		}
+		return 0, percent, true, err
+	}
+	bytes, err = humanize.ParseBytes(value)
+	return bytes, 0, false, err
+}
+
+// loadBaselineCiRules returns the baseline rules that are configured (these are never enabled by default), or all of
+// them.
+func loadBaselineCiRules(config *viper.Viper, all bool) []CiRule {
+	var rules = make([]CiRule, 0)
+
+	var ruleKey = "maxSizeIncrease"
+	if thresholds := loadRuleThresholds(config, ruleKey); all || thresholds.isEnabled() {
+		rules = append(rules, newBaselineCiRule(
+			ruleKey,
+			thresholds,
+			func(value string) error {
+				_, _, _, err := parseSizeIncrease(value)
+				if err != nil {
+					return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+				}
+				return nil
+			},
+			func(regression *export.Regression, value string) (RuleStatus, string) {
+				maxBytes, maxPercent, isPercent, err := parseSizeIncrease(value)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+				}
+				if isPercent {
+					if regression.BaselineSizeBytes == 0 {
+						return RulePassed, ""
+					}
+					percent := float64(regression.SizeDeltaBytes) / float64(regression.BaselineSizeBytes) * 100
+					if percent > maxPercent {
+						return RuleFailed, fmt.Sprintf("image grew too much relative to the baseline (size-increase=%2.4f%% > threshold=%v%%)", percent, maxPercent)
+					}
+					return RulePassed, ""
+				}
+				if regression.SizeDeltaBytes > int64(maxBytes) {
+					return RuleFailed, fmt.Sprintf("image grew too much relative to the baseline (size-increase=%v > threshold=%v)", regression.SizeDeltaBytes, maxBytes)
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	ruleKey = "maxWastedBytesIncrease"
+	if thresholds := loadRuleThresholds(config, ruleKey); all || thresholds.isEnabled() {
+		rules = append(rules, newBaselineCiRule(
+			ruleKey,
+			thresholds,
+			func(value string) error {
+				_, err := humanize.ParseBytes(value)
+				if err != nil {
+					return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+				}
+				return nil
+			},
+			func(regression *export.Regression, value string) (RuleStatus, string) {
+				maxWastedBytesIncrease, err := humanize.ParseBytes(value)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+				}
+				if regression.InefficientBytesDelta > int64(maxWastedBytesIncrease) {
+					return RuleFailed, fmt.Sprintf("wasted bytes grew too much relative to the baseline (wasted-bytes-increase=%v > threshold=%v)", regression.InefficientBytesDelta, maxWastedBytesIncrease)
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	ruleKey = "maxNewLayers"
+	if thresholds := loadRuleThresholds(config, ruleKey); all || thresholds.isEnabled() {
+		rules = append(rules, newBaselineCiRule(
+			ruleKey,
+			thresholds,
+			func(value string) error {
+				maxNewLayers, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+				}
+				if maxNewLayers < 0 {
+					return fmt.Errorf("maxNewLayers config value must not be negative, given '%s'", value)
+				}
+				return nil
+			},
+			func(regression *export.Regression, value string) (RuleStatus, string) {
+				maxNewLayers, err := strconv.Atoi(value)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+				}
+				if len(regression.AddedLayers) > maxNewLayers {
+					return RuleFailed, fmt.Sprintf("too many layers added relative to the baseline (new-layers=%d > threshold=%d, first new layer: '%s')", len(regression.AddedLayers), maxNewLayers, strings.TrimSpace(regression.AddedLayers[0].Command))
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	return rules
+}
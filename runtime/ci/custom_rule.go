@@ -0,0 +1,201 @@
+package ci
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ci/expression"
+)
+
+// customRuleConfig is a single entry of the "customRules" list in the CI config.
+type customRuleConfig struct {
+	Name       string `mapstructure:"name"`
+	Expression string `mapstructure:"expression"`
+	Message    string `mapstructure:"message"`
+	Severity   string `mapstructure:"severity"`
+}
+
+// CustomCiRule is a CI rule that is defined in the CI config as an expression over the analysis (see
+// expressionModel), the rule passes when the expression is true.
+type CustomCiRule struct {
+	config  customRuleConfig
+	expr    *expression.Expression
+	message *expression.Template
+	err     error // a configuration error, reported on validation
+	model   *expressionModelCache
+}
+
+// expressionModelCache shares the (expensive to build) model among all custom rules of an evaluation.
+type expressionModelCache struct {
+	stack    *stackedTreeCache
+	analysis *image.AnalysisResult
+	model    map[string]interface{}
+	err      error
+}
+
+func (rule *CustomCiRule) Key() string {
+	return rule.config.Name
+}
+
+func (rule *CustomCiRule) Configuration() string {
+	return rule.config.Expression
+}
+
+// Severity returns the severity that is given with the rule definition ("" when there is none).
+func (rule *CustomCiRule) Severity() string {
+	return rule.config.Severity
+}
+
+func (rule *CustomCiRule) Validate() error {
+	return rule.err
+}
+
+func (rule *CustomCiRule) Evaluate(analysis *image.AnalysisResult) (RuleStatus, string) {
+	model, err := rule.model.get(analysis)
+	if err != nil {
+		return RuleMisconfigured, fmt.Sprintf("unable to build the expression model: %v", err)
+	}
+
+	// the expression can only be type checked against the model, such errors are configuration errors as well
+	pass, err := rule.expr.EvaluateBool(model)
+	if err != nil {
+		return RuleMisconfigured, fmt.Sprintf("unable to evaluate expression ('%s'): %v", rule.expr, err)
+	}
+	if pass {
+		return RulePassed, ""
+	}
+
+	if rule.message == nil {
+		return RuleFailed, fmt.Sprintf("expression is false: %s", rule.expr)
+	}
+	message, err := rule.message.Render(model)
+	if err != nil {
+		return RuleMisconfigured, err.Error()
+	}
+	return RuleFailed, message
+}
+
+// loadCustomCiRules returns the rules given in the "customRules" list, the names of the rules must not clash with
+// each other or any of the given (built-in) rule keys.
+func loadCustomCiRules(config *viper.Viper, existingKeys map[string]bool, stack *stackedTreeCache) []CiRule {
+	var rules = make([]CiRule, 0)
+	if !config.IsSet("customRules") {
+		return rules
+	}
+
+	var entries []customRuleConfig
+	if err := config.UnmarshalKey("customRules", &entries); err != nil {
+		return append(rules, &CustomCiRule{
+			config: customRuleConfig{Name: "customRules"},
+			err:    fmt.Errorf("invalid custom rules: %v", err),
+		})
+	}
+
+	model := &expressionModelCache{stack: stack}
+	for idx, entry := range entries {
+		rule := &CustomCiRule{config: entry, model: model}
+		switch {
+		case entry.Name == "":
+			rule.config.Name = fmt.Sprintf("customRules[%d]", idx)
+			rule.err = fmt.Errorf("custom rule without a name")
+		case existingKeys[entry.Name]:
+			rule.config.Name = fmt.Sprintf("customRules[%d]", idx)
+			rule.err = fmt.Errorf("custom rule name is already in use: '%s'", entry.Name)
+		case entry.Expression == "":
+			rule.err = fmt.Errorf("custom rule without an expression")
+		default:
+			rule.expr, rule.err = expression.Parse(entry.Expression)
+			if rule.err != nil {
+				rule.err = fmt.Errorf("invalid expression ('%s'): %v", entry.Expression, rule.err)
+			} else if entry.Message != "" {
+				rule.message, rule.err = expression.ParseTemplate(entry.Message)
+			}
+		}
+		if rule.err == nil {
+			rule.err = validateSeverity(entry.Severity)
+		}
+		existingKeys[rule.config.Name] = true
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+func (cache *expressionModelCache) get(analysis *image.AnalysisResult) (map[string]interface{}, error) {
+	if cache.analysis != analysis {
+		cache.analysis = analysis
+		cache.model, cache.err = expressionModel(analysis, cache.stack)
+	}
+	return cache.model, cache.err
+}
+
+// expressionModel describes the analysis to the custom rule expressions:
+//
+//	image:          size, userSize, wastedBytes, userWastedBytes, userWastedPercent, efficiency, layerCount, baseLayers
+//	layers:         index, id, digest, size, command, isBase
+//	files:          path, size, mode, uid, gid, isDir, isLink, linkTarget (all entries of the final filesystem)
+//	inefficiencies: path, count, size (the files that are duplicated or removed across layers)
+func expressionModel(analysis *image.AnalysisResult, stack *stackedTreeCache) (map[string]interface{}, error) {
+	layers := make([]interface{}, 0, len(analysis.Layers))
+	for _, layer := range analysis.Layers {
+		layers = append(layers, map[string]interface{}{
+			"index":   float64(layer.Index),
+			"id":      layer.Id,
+			"digest":  layer.Digest,
+			"size":    float64(layer.Size),
+			"command": layer.Command,
+			"isBase":  layer.Index < analysis.BaseLayers,
+		})
+	}
+
+	tree, err := stack.get(analysis)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]interface{}, 0)
+	err = tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		info := node.Data.FileInfo
+		files = append(files, map[string]interface{}{
+			"path":       node.Path(),
+			"size":       float64(info.Size),
+			"mode":       float64(info.Mode.Perm()),
+			"uid":        float64(info.Uid),
+			"gid":        float64(info.Gid),
+			"isDir":      info.IsDir,
+			"isLink":     info.Linkname != "",
+			"linkTarget": info.Linkname,
+		})
+		return nil
+	}, func(node *filetree.FileNode) bool {
+		return !node.IsWhiteout()
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	inefficiencies := make([]interface{}, 0, len(analysis.Inefficiencies))
+	for _, data := range analysis.Inefficiencies {
+		inefficiencies = append(inefficiencies, map[string]interface{}{
+			"path":  data.Path,
+			"count": float64(len(data.Nodes)),
+			"size":  float64(data.CumulativeSize),
+		})
+	}
+
+	return map[string]interface{}{
+		"image": map[string]interface{}{
+			"size":              float64(analysis.SizeBytes),
+			"userSize":          float64(analysis.UserSizeByes),
+			"wastedBytes":       float64(analysis.WastedBytes),
+			"userWastedBytes":   float64(analysis.WastedUserBytes),
+			"userWastedPercent": analysis.WastedUserPercent,
+			"efficiency":        analysis.Efficiency,
+			"layerCount":        float64(len(analysis.Layers)),
+			"baseLayers":        float64(analysis.BaseLayers),
+		},
+		"layers":         layers,
+		"files":          files,
+		"inefficiencies": inefficiencies,
+	}, nil
+}
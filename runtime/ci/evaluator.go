@@ -3,11 +3,13 @@ package ci
 import (
 	"fmt"
 	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/export"
 	"github.com/wagoodman/dive/utils"
-	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
@@ -16,13 +18,26 @@ import (
 
 type CiEvaluator struct {
 	Rules            []CiRule
+	Severities       map[string]string // per rule severity overrides (e.g. "warn" downgrades failures to warnings)
 	Results          map[string]RuleResult
 	Tally            ResultTally
 	Pass             bool
 	Misconfigured    bool
 	InefficientFiles []ReferenceFile
+	IgnoreEntries    []IgnoreEntry
+	Suppressed       Suppressed
+	ignoreErr        error
+	stack            *stackedTreeCache // the final filesystem, shared by all rules that need it
+	configFile       string            // the file the config was read from ("" if unknown)
 }
 
+// ExitCodeFailed and ExitCodeMisconfigured are the exit codes of a CI run in which a rule failed, or in which the
+// rules could not be evaluated because of a misconfiguration.
+const (
+	ExitCodeFailed        = 1
+	ExitCodeMisconfigured = 2
+)
+
 type ResultTally struct {
 	Pass  int
 	Fail  int
@@ -32,18 +47,50 @@ type ResultTally struct {
 }
 
 func NewCiEvaluator(config *viper.Viper) *CiEvaluator {
+	stack := &stackedTreeCache{}
+	rules := loadCiRules(config, stack, false)
+	severities := make(map[string]string)
+	for _, rule := range rules {
+		if severity := loadRuleSeverity(config, rule.Key()); severity != "" {
+			severities[rule.Key()] = severity
+		} else if customRule, ok := rule.(*CustomCiRule); ok && customRule.Severity() != "" {
+			severities[rule.Key()] = customRule.Severity()
+		}
+	}
+
+	ignoreEntries, ignoreErr := loadIgnoreEntries(config)
+
 	return &CiEvaluator{
-		Rules:   loadCiRules(config),
-		Results: make(map[string]RuleResult),
-		Pass:    true,
+		Rules:         rules,
+		Severities:    severities,
+		Results:       make(map[string]RuleResult),
+		Pass:          true,
+		IgnoreEntries: ignoreEntries,
+		ignoreErr:     ignoreErr,
+		stack:         stack,
+		configFile:    config.ConfigFileUsed(),
+	}
+}
+
+// SetRegression provides the changes relative to the baseline export to all baseline rules.
+func (ci *CiEvaluator) SetRegression(regression *export.Regression) {
+	for _, rule := range ci.Rules {
+		if baselineRule, ok := rule.(*BaselineCiRule); ok {
+			baselineRule.regression = regression
+		}
 	}
 }
 
 func (ci *CiEvaluator) isRuleEnabled(rule CiRule) bool {
+	if warnRule, ok := rule.(interface{ WarnConfiguration() string }); ok && warnRule.WarnConfiguration() != "" {
+		return true
+	}
 	return rule.Configuration() != "disabled"
 }
 
-func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
+// Validate checks the configuration of all rules (and the ignore list) without evaluating them, false is returned if
+// anything is misconfigured.
+func (ci *CiEvaluator) Validate() bool {
 	canEvaluate := true
 	for _, rule := range ci.Rules {
 		if !ci.isRuleEnabled(rule) {
@@ -55,6 +102,9 @@ func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
 		}
 
 		err := rule.Validate()
+		if err == nil {
+			err = validateSeverity(ci.Severities[rule.Key()])
+		}
 		if err != nil {
 			ci.Results[rule.Key()] = RuleResult{
 				status:  RuleMisconfigured,
@@ -70,12 +120,29 @@ func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
 
 	}
 
+	if ci.ignoreErr != nil {
+		ci.Results["ignore"] = RuleResult{
+			status:  RuleMisconfigured,
+			message: ci.ignoreErr.Error(),
+		}
+		canEvaluate = false
+	}
+
 	if !canEvaluate {
 		ci.Pass = false
 		ci.Misconfigured = true
+	}
+	return canEvaluate
+}
+
+func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
+	if !ci.Validate() {
 		return ci.Pass
 	}
 
+	analysis = ci.suppress(analysis)
+	defer ci.stack.release()
+
 	// capture inefficient files
 	for idx := 0; idx < len(analysis.Inefficiencies); idx++ {
 		fileData := analysis.Inefficiencies[len(analysis.Inefficiencies)-1-idx]
@@ -98,6 +165,9 @@ func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
 		}
 
 		status, message := rule.Evaluate(analysis)
+		if status == RuleFailed && ci.Severities[rule.Key()] == severityWarn {
+			status = RuleWarning
+		}
 
 		if value, exists := ci.Results[rule.Key()]; exists && value.status != RuleConfigured && value.status != RuleMisconfigured {
 			panic(fmt.Errorf("CI rule result recorded twice: %s", rule.Key()))
@@ -106,6 +176,11 @@ func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
 		if status == RuleFailed {
 			ci.Pass = false
 		}
+		if status == RuleMisconfigured {
+			// some configuration errors (e.g. a custom rule expression of the wrong type) are only found on evaluation
+			ci.Pass = false
+			ci.Misconfigured = true
+		}
 
 		ci.Results[rule.Key()] = RuleResult{
 			status:  status,
@@ -119,7 +194,7 @@ func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
 		switch result.status {
 		case RulePassed:
 			ci.Tally.Pass++
-		case RuleFailed:
+		case RuleFailed, RuleMisconfigured:
 			ci.Tally.Fail++
 		case RuleWarning:
 			ci.Tally.Warn++
@@ -133,6 +208,37 @@ func (ci *CiEvaluator) Evaluate(analysis *image.AnalysisResult) bool {
 	return ci.Pass
 }
 
+// suppress excludes all inefficiencies that match an (unexpired) ignore entry from the analysis.
+func (ci *CiEvaluator) suppress(analysis *image.AnalysisResult) *image.AnalysisResult {
+	ci.Suppressed = Suppressed{ExpiredEntries: make([]IgnoreEntry, 0)}
+	var patterns []string
+	now := time.Now()
+	for _, entry := range ci.IgnoreEntries {
+		if entry.isExpired(now) {
+			ci.Suppressed.ExpiredEntries = append(ci.Suppressed.ExpiredEntries, entry)
+			continue
+		}
+		patterns = append(patterns, entry.Path)
+	}
+	if len(patterns) == 0 {
+		return analysis
+	}
+
+	ignore := func(path string) bool {
+		for _, pattern := range patterns {
+			if filetree.MatchGlob(pattern, path) {
+				return true
+			}
+		}
+		return false
+	}
+
+	suppressed := analysis.WithIgnoredPaths(ignore)
+	ci.Suppressed.Files = len(analysis.Inefficiencies) - len(suppressed.Inefficiencies)
+	ci.Suppressed.WastedBytes = analysis.WastedBytes - suppressed.WastedBytes
+	return suppressed
+}
+
 func (ci *CiEvaluator) Report() string {
 	var sb strings.Builder
 	fmt.Fprintln(&sb, utils.TitleFormat("Inefficient Files:"))
@@ -148,21 +254,26 @@ func (ci *CiEvaluator) Report() string {
 		}
 	}
 
+	if len(ci.IgnoreEntries) > 0 {
+		fmt.Fprintf(&sb, "Suppressed: %d files (%s wasted) by %d ignore entries\n", ci.Suppressed.Files, humanize.Bytes(ci.Suppressed.WastedBytes), len(ci.IgnoreEntries)-len(ci.Suppressed.ExpiredEntries))
+		for _, entry := range ci.Suppressed.ExpiredEntries {
+			fmt.Fprintf(&sb, "  %s: ignore entry for '%s' expired on %s", aurora.Blue("EXPIRED"), entry.Path, entry.Expires)
+			if entry.Reason != "" {
+				fmt.Fprintf(&sb, " (%s)", entry.Reason)
+			}
+			fmt.Fprintln(&sb)
+		}
+	}
+
 	fmt.Fprintln(&sb, utils.TitleFormat("Results:"))
 
 	status := "PASS"
 
-	rules := make([]string, 0, len(ci.Results))
-	for name := range ci.Results {
-		rules = append(rules, name)
-	}
-	sort.Strings(rules)
-
 	if ci.Tally.Fail > 0 {
 		status = "FAIL"
 	}
 
-	for _, rule := range rules {
+	for _, rule := range ci.SortedRules() {
 		result := ci.Results[rule]
 		name := strings.TrimPrefix(rule, "rules.")
 		if result.message != "" {
@@ -177,10 +288,10 @@ func (ci *CiEvaluator) Report() string {
 
 	} else {
 		summary := fmt.Sprintf("Result:%s [Total:%d] [Passed:%d] [Failed:%d] [Warn:%d] [Skipped:%d]", status, ci.Tally.Total, ci.Tally.Pass, ci.Tally.Fail, ci.Tally.Warn, ci.Tally.Skip)
-		if ci.Pass {
-			fmt.Fprintln(&sb, aurora.Green(summary))
-		} else if ci.Pass && ci.Tally.Warn > 0 {
+		if ci.Pass && ci.Tally.Warn > 0 {
 			fmt.Fprintln(&sb, aurora.Blue(summary))
+		} else if ci.Pass {
+			fmt.Fprintln(&sb, aurora.Green(summary))
 		} else {
 			fmt.Fprintln(&sb, aurora.Red(summary))
 		}
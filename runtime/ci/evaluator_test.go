@@ -2,6 +2,7 @@ package ci
 
 import (
 	"github.com/wagoodman/dive/dive/image/docker"
+	"github.com/wagoodman/dive/runtime/export"
 	"strings"
 	"testing"
 
@@ -56,3 +57,633 @@ func Test_Evaluator(t *testing.T) {
 	}
 
 }
+
+func Test_EvaluatorBaselineRules(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	regression := &export.Regression{
+		BaselineSizeBytes:     1000,
+		SizeDeltaBytes:        100,
+		InefficientBytesDelta: 50,
+		AddedLayers:           []export.Layer{{Command: "RUN apt-get install -y python3"}},
+	}
+
+	table := map[string]struct {
+		sizeIncrease   string
+		wastedIncrease string
+		newLayers      string
+		regression     *export.Regression
+		expectedPass   bool
+		expectedResult map[string]RuleStatus
+	}{
+		"bytesPass":     {"100B", "50B", "1", regression, true, map[string]RuleStatus{"maxSizeIncrease": RulePassed, "maxWastedBytesIncrease": RulePassed, "maxNewLayers": RulePassed}},
+		"bytesFail":     {"99B", "49B", "0", regression, false, map[string]RuleStatus{"maxSizeIncrease": RuleFailed, "maxWastedBytesIncrease": RuleFailed, "maxNewLayers": RuleFailed}},
+		"percentPass":   {"10%", "disabled", "disabled", regression, true, map[string]RuleStatus{"maxSizeIncrease": RulePassed}},
+		"percentFail":   {"9.5%", "disabled", "disabled", regression, false, map[string]RuleStatus{"maxSizeIncrease": RuleFailed}},
+		"misconfigured": {"-1%", "1BB", "-1", regression, false, map[string]RuleStatus{"maxSizeIncrease": RuleMisconfigured, "maxWastedBytesIncrease": RuleMisconfigured, "maxNewLayers": RuleMisconfigured}},
+		"noBaseline":    {"10%", "disabled", "disabled", nil, false, map[string]RuleStatus{"maxSizeIncrease": RuleMisconfigured}},
+	}
+
+	for name, test := range table {
+		ciConfig := viper.New()
+		ciConfig.SetDefault("rules.lowestEfficiency", "disabled")
+		ciConfig.SetDefault("rules.highestWastedBytes", "disabled")
+		ciConfig.SetDefault("rules.highestUserWastedPercent", "disabled")
+		ciConfig.SetDefault("rules.maxSizeIncrease", test.sizeIncrease)
+		ciConfig.SetDefault("rules.maxWastedBytesIncrease", test.wastedIncrease)
+		ciConfig.SetDefault("rules.maxNewLayers", test.newLayers)
+
+		evaluator := NewCiEvaluator(ciConfig)
+		if test.regression != nil {
+			evaluator.SetRegression(test.regression)
+		}
+
+		pass := evaluator.Evaluate(result)
+
+		if test.expectedPass != pass {
+			t.Errorf("%s: expected pass=%v, got %v", name, test.expectedPass, pass)
+		}
+
+		for rule, expectedStatus := range test.expectedResult {
+			actualResult, exists := evaluator.Results[rule]
+			if !exists {
+				t.Errorf("%s: missing result for rule %v", name, rule)
+				continue
+			}
+			if expectedStatus != actualResult.status {
+				t.Errorf("%s: %v: expected %v, got %v: %v", name, rule, expectedStatus, actualResult.status, actualResult)
+			}
+		}
+
+		// disabled baseline rules are not loaded at all
+		if len(evaluator.Results) != 3+len(test.expectedResult) {
+			t.Errorf("%s: expected %v results, got %v", name, 3+len(test.expectedResult), len(evaluator.Results))
+		}
+	}
+}
+
+func Test_EvaluatorSizeRules(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string]struct {
+		imageSize       string
+		layerSize       string
+		layerCount      string
+		fileSize        string
+		expectedPass    bool
+		expectedResult  map[string]RuleStatus
+		expectedMessage map[string]string
+	}{
+		"allPass": {"2MB", "2MB", "14", "2MB", true,
+			map[string]RuleStatus{"highestImageSize": RulePassed, "highestLayerSize": RulePassed, "highestLayerCount": RulePassed, "highestFileSize": RulePassed},
+			nil,
+		},
+		"allFail": {"1MB", "1MB", "13", "1MB", false,
+			map[string]RuleStatus{"highestImageSize": RuleFailed, "highestLayerSize": RuleFailed, "highestLayerCount": RuleFailed, "highestFileSize": RuleFailed},
+			map[string]string{
+				"highestLayerSize": "layer 0 is too large (layer-size=1154361 > threshold=1000000): '#(nop) ADD file:ce026b62356eec3ad1214f92be2c9dc063fe205bd5e600be3492c4dfb17148bd in /'",
+				"highestFileSize":  "file is too large (file-size=1075464 > threshold=1000000): '/bin/['",
+			},
+		},
+		"misconfigured": {"1BB", "-1BB", "many", "1BB", false,
+			map[string]RuleStatus{"highestImageSize": RuleMisconfigured, "highestLayerSize": RuleMisconfigured, "highestLayerCount": RuleMisconfigured, "highestFileSize": RuleMisconfigured},
+			nil,
+		},
+		"partial": {"disabled", "disabled", "20", "disabled", true,
+			map[string]RuleStatus{"highestLayerCount": RulePassed},
+			nil,
+		},
+	}
+
+	for name, test := range table {
+		ciConfig := viper.New()
+		ciConfig.SetDefault("rules.lowestEfficiency", "disabled")
+		ciConfig.SetDefault("rules.highestWastedBytes", "disabled")
+		ciConfig.SetDefault("rules.highestUserWastedPercent", "disabled")
+		ciConfig.SetDefault("rules.highestImageSize", test.imageSize)
+		ciConfig.SetDefault("rules.highestLayerSize", test.layerSize)
+		ciConfig.SetDefault("rules.highestLayerCount", test.layerCount)
+		ciConfig.SetDefault("rules.highestFileSize", test.fileSize)
+
+		evaluator := NewCiEvaluator(ciConfig)
+
+		pass := evaluator.Evaluate(result)
+
+		if test.expectedPass != pass {
+			t.Errorf("%s: expected pass=%v, got %v", name, test.expectedPass, pass)
+		}
+
+		if len(evaluator.Results) != 3+len(test.expectedResult) {
+			t.Errorf("%s: expected %v results, got %v", name, 3+len(test.expectedResult), len(evaluator.Results))
+		}
+
+		for rule, expectedStatus := range test.expectedResult {
+			actualResult := evaluator.Results[rule]
+			if expectedStatus != actualResult.status {
+				t.Errorf("%s: %v: expected %v, got %v: %v", name, rule, expectedStatus, actualResult.status, actualResult)
+			}
+		}
+
+		for rule, expectedMessage := range test.expectedMessage {
+			if actualMessage := evaluator.Results[rule].message; expectedMessage != actualMessage {
+				t.Errorf("%s: %v: expected message '%v', got '%v'", name, rule, expectedMessage, actualMessage)
+			}
+		}
+	}
+}
+
+func Test_EvaluatorPathRules(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string]struct {
+		forbidden       []string
+		anyLayer        bool
+		required        []string
+		expectedPass    bool
+		expectedResult  map[string]RuleStatus
+		expectedMessage map[string]string
+	}{
+		"pass": {[]string{"**/.git", "/root/example/**"}, false, []string{"/etc/passwd", "/root/.data/*"}, true,
+			map[string]RuleStatus{"forbiddenPaths": RulePassed, "requiredPaths": RulePassed},
+			nil,
+		},
+		"fail": {[]string{"/root/*.txt"}, false, []string{"/etc/ssl/certs/ca-certificates.crt", "**/*.pem"}, false,
+			map[string]RuleStatus{"forbiddenPaths": RuleFailed, "requiredPaths": RuleFailed},
+			map[string]string{
+				"forbiddenPaths": "forbidden paths found (2): /root/.saved.txt, /root/saved.txt",
+				"requiredPaths":  "required paths missing (2): /etc/ssl/certs/ca-certificates.crt, **/*.pem",
+			},
+		},
+		"anyLayer": {[]string{"/root/example/*.txt"}, true, nil, false,
+			map[string]RuleStatus{"forbiddenPaths": RuleFailed},
+			map[string]string{
+				"forbiddenPaths": "forbidden paths found (4): /root/example/somefile1.txt (layer 3), /root/example/somefile1.txt (layer 4), /root/example/somefile2.txt (layer 5), /root/example/somefile3.txt (layer 6)",
+			},
+		},
+		"misconfigured": {[]string{"/root/["}, false, nil, false,
+			map[string]RuleStatus{"forbiddenPaths": RuleMisconfigured},
+			nil,
+		},
+	}
+
+	for name, test := range table {
+		ciConfig := viper.New()
+		ciConfig.SetDefault("rules.lowestEfficiency", "disabled")
+		ciConfig.SetDefault("rules.highestWastedBytes", "disabled")
+		ciConfig.SetDefault("rules.highestUserWastedPercent", "disabled")
+		ciConfig.SetDefault("rules.forbiddenPaths", test.forbidden)
+		ciConfig.SetDefault("rules.forbiddenPathsAnyLayer", test.anyLayer)
+		ciConfig.SetDefault("rules.requiredPaths", test.required)
+
+		evaluator := NewCiEvaluator(ciConfig)
+
+		pass := evaluator.Evaluate(result)
+
+		if test.expectedPass != pass {
+			t.Errorf("%s: expected pass=%v, got %v", name, test.expectedPass, pass)
+		}
+
+		if len(evaluator.Results) != 3+len(test.expectedResult) {
+			t.Errorf("%s: expected %v results, got %v", name, 3+len(test.expectedResult), len(evaluator.Results))
+		}
+
+		for rule, expectedStatus := range test.expectedResult {
+			actualResult := evaluator.Results[rule]
+			if expectedStatus != actualResult.status {
+				t.Errorf("%s: %v: expected %v, got %v: %v", name, rule, expectedStatus, actualResult.status, actualResult)
+			}
+		}
+
+		for rule, expectedMessage := range test.expectedMessage {
+			if actualMessage := evaluator.Results[rule].message; expectedMessage != actualMessage {
+				t.Errorf("%s: %v: expected message '%v', got '%v'", name, rule, expectedMessage, actualMessage)
+			}
+		}
+	}
+}
+
+func Test_EvaluatorStackedTree(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	stack := &stackedTreeCache{}
+	tree, err := stack.get(result)
+	if err != nil {
+		t.Fatalf("unable to stack the image: %v", err)
+	}
+	if again, _ := stack.get(result); again != tree {
+		t.Errorf("expected the stacked tree to be shared")
+	}
+
+	ciConfig := viper.New()
+	ciConfig.SetDefault("rules.lowestEfficiency", "disabled")
+	ciConfig.SetDefault("rules.highestWastedBytes", "disabled")
+	ciConfig.SetDefault("rules.highestUserWastedPercent", "disabled")
+	ciConfig.SetDefault("rules.requiredPaths", []string{"/etc/passwd"})
+	ciConfig.SetDefault("rules.highestFileSize", "10MB")
+
+	evaluator := NewCiEvaluator(ciConfig)
+	evaluator.Evaluate(result)
+
+	for _, rule := range []string{"requiredPaths", "highestFileSize"} {
+		if status := evaluator.Results[rule].status; status != RulePassed {
+			t.Errorf("%v: expected %v, got %v", rule, RulePassed, status)
+		}
+	}
+	if evaluator.stack.tree != nil {
+		t.Errorf("expected the stacked tree to be released after the evaluation")
+	}
+}
+
+func Test_EvaluatorAuditRules(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string]struct {
+		config          map[string]interface{}
+		expectedPass    bool
+		expectedResult  map[string]RuleStatus
+		expectedMessage map[string]string
+	}{
+		"fail": {
+			config: map[string]interface{}{
+				"rules.setuidFiles":           true,
+				"rules.worldWritableFiles":    "true",
+				"rules.unknownOwners":         true,
+				"rules.unexpectedExecutables": true,
+			},
+			expectedPass:   false,
+			expectedResult: map[string]RuleStatus{"setuidFiles": RulePassed, "worldWritableFiles": RulePassed, "unknownOwners": RulePassed, "unexpectedExecutables": RuleFailed},
+			expectedMessage: map[string]string{
+				"unexpectedExecutables": "unexpected executable files found (3): /root/.data/tag.sh, /root/.data/test.sh, /root/saved.txt",
+			},
+		},
+		"allowlisted": {
+			config: map[string]interface{}{
+				"rules.unexpectedExecutables": map[string]interface{}{"allow": []string{"/root/.data/*.sh"}, "paths": []string{"/bin/**", "/root/saved.txt"}},
+			},
+			expectedPass:   true,
+			expectedResult: map[string]RuleStatus{"unexpectedExecutables": RulePassed},
+		},
+		"disabled": {
+			config: map[string]interface{}{
+				"rules.setuidFiles":           false,
+				"rules.unexpectedExecutables": "disabled",
+			},
+			expectedPass:   true,
+			expectedResult: map[string]RuleStatus{},
+		},
+		"misconfigured": {
+			config: map[string]interface{}{
+				"rules.setuidFiles": map[string]interface{}{"allow": []string{"/bin/["}},
+			},
+			expectedPass:   false,
+			expectedResult: map[string]RuleStatus{"setuidFiles": RuleMisconfigured},
+		},
+	}
+
+	for name, test := range table {
+		ciConfig := viper.New()
+		ciConfig.SetDefault("rules.lowestEfficiency", "disabled")
+		ciConfig.SetDefault("rules.highestWastedBytes", "disabled")
+		ciConfig.SetDefault("rules.highestUserWastedPercent", "disabled")
+		for key, value := range test.config {
+			ciConfig.Set(key, value)
+		}
+
+		evaluator := NewCiEvaluator(ciConfig)
+
+		pass := evaluator.Evaluate(result)
+
+		if test.expectedPass != pass {
+			t.Errorf("%s: expected pass=%v, got %v", name, test.expectedPass, pass)
+		}
+
+		if len(evaluator.Results) != 3+len(test.expectedResult) {
+			t.Errorf("%s: expected %v results, got %v", name, 3+len(test.expectedResult), len(evaluator.Results))
+		}
+
+		for rule, expectedStatus := range test.expectedResult {
+			actualResult := evaluator.Results[rule]
+			if expectedStatus != actualResult.status {
+				t.Errorf("%s: %v: expected %v, got %v: %v", name, rule, expectedStatus, actualResult.status, actualResult)
+			}
+		}
+
+		for rule, expectedMessage := range test.expectedMessage {
+			if actualMessage := evaluator.Results[rule].message; expectedMessage != actualMessage {
+				t.Errorf("%s: %v: expected message '%v', got '%v'", name, rule, expectedMessage, actualMessage)
+			}
+		}
+	}
+}
+
+func Test_EvaluatorWarnings(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string]struct {
+		config         map[string]interface{}
+		expectedPass   bool
+		expectedTally  ResultTally
+		expectedResult map[string]RuleStatus
+	}{
+		"warnThreshold": {
+			config: map[string]interface{}{
+				"rules.lowestEfficiency":         map[string]interface{}{"warn": "0.99", "fail": "0.9"},
+				"rules.highestWastedBytes":       map[string]interface{}{"warn": "1kB"},
+				"rules.highestUserWastedPercent": "0.5",
+			},
+			expectedPass:   true,
+			expectedTally:  ResultTally{Pass: 1, Warn: 2, Total: 3},
+			expectedResult: map[string]RuleStatus{"lowestEfficiency": RuleWarning, "highestWastedBytes": RuleWarning, "highestUserWastedPercent": RulePassed},
+		},
+		"failThresholdFirst": {
+			config: map[string]interface{}{
+				"rules.lowestEfficiency":         map[string]interface{}{"warn": "0.999", "fail": "0.99"},
+				"rules.highestWastedBytes":       "disabled",
+				"rules.highestUserWastedPercent": "disabled",
+			},
+			expectedPass:   false,
+			expectedTally:  ResultTally{Fail: 1, Skip: 2, Total: 3},
+			expectedResult: map[string]RuleStatus{"lowestEfficiency": RuleFailed, "highestWastedBytes": RuleDisabled, "highestUserWastedPercent": RuleDisabled},
+		},
+		"severityOverride": {
+			config: map[string]interface{}{
+				"rules.lowestEfficiency":         "0.9",
+				"rules.highestWastedBytes":       map[string]interface{}{"fail": "1B", "severity": "warn"},
+				"rules.highestUserWastedPercent": "disabled",
+				"rules.forbiddenPaths":           map[string]interface{}{"paths": []string{"/root/*.txt"}, "severity": "warn"},
+			},
+			expectedPass:   true,
+			expectedTally:  ResultTally{Pass: 1, Warn: 2, Skip: 1, Total: 4},
+			expectedResult: map[string]RuleStatus{"lowestEfficiency": RulePassed, "highestWastedBytes": RuleWarning, "highestUserWastedPercent": RuleDisabled, "forbiddenPaths": RuleWarning},
+		},
+		"severityOnly": {
+			config: map[string]interface{}{
+				"rules.lowestEfficiency":         "disabled",
+				"rules.highestWastedBytes":       "disabled",
+				"rules.highestUserWastedPercent": map[string]interface{}{"severity": "warn"},
+			},
+			// the default threshold (0.1) is kept
+			expectedPass:   true,
+			expectedTally:  ResultTally{Warn: 1, Skip: 2, Total: 3},
+			expectedResult: map[string]RuleStatus{"lowestEfficiency": RuleDisabled, "highestWastedBytes": RuleDisabled, "highestUserWastedPercent": RuleWarning},
+		},
+		"invalidSeverity": {
+			config: map[string]interface{}{
+				"rules.lowestEfficiency":         map[string]interface{}{"fail": "0.9", "severity": "critical"},
+				"rules.highestWastedBytes":       "disabled",
+				"rules.highestUserWastedPercent": "disabled",
+			},
+			expectedPass:   false,
+			expectedResult: map[string]RuleStatus{"lowestEfficiency": RuleMisconfigured, "highestWastedBytes": RuleConfigured, "highestUserWastedPercent": RuleConfigured},
+		},
+		"invalidWarnThreshold": {
+			config: map[string]interface{}{
+				"rules.lowestEfficiency":         map[string]interface{}{"warn": "1.5"},
+				"rules.highestWastedBytes":       "disabled",
+				"rules.highestUserWastedPercent": "disabled",
+			},
+			expectedPass:   false,
+			expectedResult: map[string]RuleStatus{"lowestEfficiency": RuleMisconfigured, "highestWastedBytes": RuleConfigured, "highestUserWastedPercent": RuleConfigured},
+		},
+	}
+
+	for name, test := range table {
+		ciConfig := viper.New()
+		for key, value := range test.config {
+			ciConfig.Set(key, value)
+		}
+
+		evaluator := NewCiEvaluator(ciConfig)
+
+		pass := evaluator.Evaluate(result)
+
+		if test.expectedPass != pass {
+			t.Errorf("%s: expected pass=%v, got %v", name, test.expectedPass, pass)
+		}
+
+		if !evaluator.Misconfigured && test.expectedTally != evaluator.Tally {
+			t.Errorf("%s: expected tally %+v, got %+v", name, test.expectedTally, evaluator.Tally)
+		}
+
+		for rule, expectedStatus := range test.expectedResult {
+			actualResult := evaluator.Results[rule]
+			if expectedStatus != actualResult.status {
+				t.Errorf("%s: %v: expected %v, got %v: %v", name, rule, expectedStatus, actualResult.status, actualResult)
+			}
+		}
+	}
+}
+
+func Test_EvaluatorIgnoreEntries(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string]struct {
+		ignore             []map[string]interface{}
+		expectedPass       bool
+		expectedFiles      int
+		expectedSuppressed int
+		expectedExpired    int
+	}{
+		"noEntries": {
+			expectedPass:  false,
+			expectedFiles: 3,
+		},
+		"ignoreFile": {
+			ignore:             []map[string]interface{}{{"path": "/root/saved.txt", "reason": "accepted"}},
+			expectedPass:       false,
+			expectedFiles:      2,
+			expectedSuppressed: 1,
+		},
+		"ignoreAll": {
+			ignore:             []map[string]interface{}{{"path": "/**", "expires": "2999-01-01"}},
+			expectedPass:       true,
+			expectedFiles:      0,
+			expectedSuppressed: 3,
+		},
+		"expiredEntry": {
+			ignore:          []map[string]interface{}{{"path": "/**", "expires": "2000-01-01"}},
+			expectedPass:    false,
+			expectedFiles:   3,
+			expectedExpired: 1,
+		},
+	}
+
+	for name, test := range table {
+		ciConfig := viper.New()
+		ciConfig.Set("rules.lowestEfficiency", "0.99")
+		ciConfig.Set("rules.highestWastedBytes", "1B")
+		ciConfig.Set("rules.highestUserWastedPercent", "disabled")
+		if test.ignore != nil {
+			ciConfig.Set("ignore", test.ignore)
+		}
+
+		evaluator := NewCiEvaluator(ciConfig)
+
+		pass := evaluator.Evaluate(result)
+
+		if test.expectedPass != pass {
+			t.Errorf("%s: expected pass=%v, got %v: %+v", name, test.expectedPass, pass, evaluator.Results)
+		}
+		if len(evaluator.InefficientFiles) != test.expectedFiles {
+			t.Errorf("%s: expected %d inefficient files, got %d", name, test.expectedFiles, len(evaluator.InefficientFiles))
+		}
+		if evaluator.Suppressed.Files != test.expectedSuppressed {
+			t.Errorf("%s: expected %d suppressed files, got %d", name, test.expectedSuppressed, evaluator.Suppressed.Files)
+		}
+		if (evaluator.Suppressed.WastedBytes > 0) != (test.expectedSuppressed > 0) {
+			t.Errorf("%s: unexpected suppressed bytes: %d", name, evaluator.Suppressed.WastedBytes)
+		}
+		if len(evaluator.Suppressed.ExpiredEntries) != test.expectedExpired {
+			t.Errorf("%s: expected %d expired entries, got %d", name, test.expectedExpired, len(evaluator.Suppressed.ExpiredEntries))
+		}
+	}
+}
+
+func Test_EvaluatorInvalidIgnoreEntries(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string][]map[string]interface{}{
+		"missingPath": {{"reason": "no path"}},
+		"invalidGlob": {{"path": "/root/["}},
+		"invalidDate": {{"path": "/root/**", "expires": "next week"}},
+	}
+
+	for name, ignore := range table {
+		ciConfig := viper.New()
+		ciConfig.Set("rules.lowestEfficiency", "0.9")
+		ciConfig.Set("ignore", ignore)
+
+		evaluator := NewCiEvaluator(ciConfig)
+
+		if evaluator.Evaluate(result) {
+			t.Errorf("%s: expected evaluation to fail", name)
+		}
+		if !evaluator.Misconfigured {
+			t.Errorf("%s: expected a misconfiguration", name)
+		}
+		if evaluator.Results["ignore"].status != RuleMisconfigured {
+			t.Errorf("%s: expected a misconfigured ignore list, got %v", name, evaluator.Results["ignore"])
+		}
+	}
+}
+
+func Test_EvaluatorCustomRules(t *testing.T) {
+
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	table := map[string]struct {
+		rules           []map[string]interface{}
+		expectedPass    bool
+		expectedResult  map[string]RuleStatus
+		expectedMessage map[string]string
+	}{
+		"passingRule": {
+			rules: []map[string]interface{}{
+				{"name": "noLargeLayers", "expression": "layers.filter(l, l.size > 500MB).size() == 0"},
+			},
+			expectedPass:   true,
+			expectedResult: map[string]RuleStatus{"noLargeLayers": RulePassed},
+		},
+		"failingRule": {
+			rules: []map[string]interface{}{
+				{
+					"name":       "noTextFiles",
+					"expression": `!files.exists(f, f.path.matches("^/root/.*\\.txt$"))`,
+					"message":    `{{ files.filter(f, f.path.matches("^/root/.*\\.txt$")).size() }} text files in /root`,
+				},
+			},
+			expectedPass:    false,
+			expectedResult:  map[string]RuleStatus{"noTextFiles": RuleFailed},
+			expectedMessage: map[string]string{"noTextFiles": "3 text files in /root"},
+		},
+		"defaultMessage": {
+			rules: []map[string]interface{}{
+				{"name": "fewLayers", "expression": "image.layerCount < 5"},
+			},
+			expectedPass:    false,
+			expectedResult:  map[string]RuleStatus{"fewLayers": RuleFailed},
+			expectedMessage: map[string]string{"fewLayers": "expression is false: image.layerCount < 5"},
+		},
+		"warnSeverity": {
+			rules: []map[string]interface{}{
+				{"name": "noInefficiencies", "expression": "inefficiencies.size() == 0", "severity": "warn"},
+			},
+			expectedPass:   true,
+			expectedResult: map[string]RuleStatus{"noInefficiencies": RuleWarning},
+		},
+		"evaluationError": {
+			rules: []map[string]interface{}{
+				{"name": "notBool", "expression": "image.size"},
+			},
+			expectedPass:    false,
+			expectedResult:  map[string]RuleStatus{"notBool": RuleMisconfigured},
+			expectedMessage: map[string]string{"notBool": "unable to evaluate expression ('image.size'): expression must result in a bool, got number"},
+		},
+		"invalidExpression": {
+			rules: []map[string]interface{}{
+				{"name": "broken", "expression": "layers.size( > 1"},
+			},
+			expectedPass:   false,
+			expectedResult: map[string]RuleStatus{"broken": RuleMisconfigured},
+		},
+		"nameClash": {
+			rules: []map[string]interface{}{
+				{"name": "lowestEfficiency", "expression": "true"},
+			},
+			expectedPass:   false,
+			expectedResult: map[string]RuleStatus{"customRules[0]": RuleMisconfigured},
+		},
+		"invalidSeverity": {
+			rules: []map[string]interface{}{
+				{"name": "strict", "expression": "true", "severity": "critical"},
+			},
+			expectedPass:   false,
+			expectedResult: map[string]RuleStatus{"strict": RuleMisconfigured},
+		},
+	}
+
+	for name, test := range table {
+		ciConfig := viper.New()
+		ciConfig.Set("rules.lowestEfficiency", "disabled")
+		ciConfig.Set("rules.highestWastedBytes", "disabled")
+		ciConfig.Set("rules.highestUserWastedPercent", "disabled")
+		ciConfig.Set("customRules", test.rules)
+
+		evaluator := NewCiEvaluator(ciConfig)
+
+		pass := evaluator.Evaluate(result)
+
+		if test.expectedPass != pass {
+			t.Errorf("%s: expected pass=%v, got %v: %+v", name, test.expectedPass, pass, evaluator.Results)
+		}
+
+		for rule, expectedStatus := range test.expectedResult {
+			actualResult := evaluator.Results[rule]
+			if expectedStatus != actualResult.status {
+				t.Errorf("%s: %v: expected %v, got %v: %v", name, rule, expectedStatus, actualResult.status, actualResult)
+			}
+		}
+
+		for rule, expectedMessage := range test.expectedMessage {
+			if actualMessage := evaluator.Results[rule].message; expectedMessage != actualMessage {
+				t.Errorf("%s: %v: expected message %q, got %q", name, rule, expectedMessage, actualMessage)
+			}
+		}
+
+		var expectedMisconfigured bool
+		for _, status := range test.expectedResult {
+			expectedMisconfigured = expectedMisconfigured || status == RuleMisconfigured
+		}
+		if evaluator.Misconfigured != expectedMisconfigured {
+			t.Errorf("%s: expected misconfigured=%v, got %v", name, expectedMisconfigured, evaluator.Misconfigured)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package expression
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// node is a single element of a parsed expression.
+type node interface {
+	eval(scope *scope) (interface{}, error)
+}
+
+// scope holds the variables that are visible to (a part of) an expression.
+type scope struct {
+	variables map[string]interface{}
+	parent    *scope
+	regexps   map[string]*regexp.Regexp // compiled "matches" patterns, shared by all scopes of an evaluation
+}
+
+func (s *scope) lookup(name string) (interface{}, bool) {
+	for current := s; current != nil; current = current.parent {
+		if value, exists := current.variables[name]; exists {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (s *scope) with(name string, value interface{}) *scope {
+	return &scope{variables: map[string]interface{}{name: value}, parent: s, regexps: s.regexps}
+}
+
+func (s *scope) regexp(pattern string) (*regexp.Regexp, error) {
+	if compiled, exists := s.regexps[pattern]; exists {
+		return compiled, nil
+	}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression ('%s'): %v", pattern, err)
+	}
+	s.regexps[pattern] = compiled
+	return compiled, nil
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(*scope) (interface{}, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+}
+
+func (n *identNode) eval(scope *scope) (interface{}, error) {
+	value, exists := scope.lookup(n.name)
+	if !exists {
+		return nil, fmt.Errorf("undeclared reference to '%s'", n.name)
+	}
+	return value, nil
+}
+
+type fieldNode struct {
+	target node
+	field  string
+}
+
+func (n *fieldNode) eval(scope *scope) (interface{}, error) {
+	target, err := n.target.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	object, ok := target.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("cannot select field '%s' from %s", n.field, typeName(target))
+	}
+	value, exists := object[n.field]
+	if !exists {
+		return nil, fmt.Errorf("no such field '%s'", n.field)
+	}
+	return value, nil
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(scope *scope) (interface{}, error) {
+	operand, err := evalBool(n.operand, scope, "!")
+	if err != nil {
+		return nil, err
+	}
+	return !operand, nil
+}
+
+type binaryNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+func (n *binaryNode) eval(scope *scope) (interface{}, error) {
+	// logical operators short-circuit
+	switch n.operator {
+	case "&&", "||":
+		left, err := evalBool(n.left, scope, n.operator)
+		if err != nil {
+			return nil, err
+		}
+		if left == (n.operator == "||") {
+			return left, nil
+		}
+		return evalBool(n.right, scope, n.operator)
+	}
+
+	left, err := n.left.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	default:
+		return compare(n.operator, left, right)
+	}
+}
+
+// macroNode evaluates the body for each element of a list, with the element bound to the variable.
+type macroNode struct {
+	target   node
+	macro    string
+	variable string
+	body     node
+}
+
+func (n *macroNode) eval(scope *scope) (interface{}, error) {
+	target, err := n.target.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := target.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("'%s' requires a list, got %s", n.macro, typeName(target))
+	}
+
+	var results = make([]interface{}, 0)
+	for _, element := range list {
+		match, err := evalBool(n.body, scope.with(n.variable, element), n.macro)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case n.macro == "all" && !match:
+			return false, nil
+		case n.macro == "exists" && match:
+			return true, nil
+		case match:
+			results = append(results, element)
+		}
+	}
+
+	switch n.macro {
+	case "all":
+		return true, nil
+	case "exists":
+		return false, nil
+	default:
+		return results, nil
+	}
+}
+
+// callNode invokes a method on a target value.
+type callNode struct {
+	target node
+	method string
+	args   []node
+}
+
+func (n *callNode) eval(scope *scope) (interface{}, error) {
+	target, err := n.target.eval(scope)
+	if err != nil {
+		return nil, err
+	}
+	var args []interface{}
+	for _, arg := range n.args {
+		value, err := arg.eval(scope)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, value)
+	}
+
+	switch n.method {
+	case "size":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("'size' takes no arguments")
+		}
+		return size(target)
+	case "matches":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("'matches' takes a single argument")
+		}
+		value, valueOk := target.(string)
+		argument, argumentOk := args[0].(string)
+		if !valueOk || !argumentOk {
+			return nil, fmt.Errorf("'matches' requires strings, got %s and %s", typeName(target), typeName(args[0]))
+		}
+		pattern, err := scope.regexp(argument)
+		if err != nil {
+			return nil, err
+		}
+		return pattern.MatchString(value), nil
+	default:
+		return nil, fmt.Errorf("unknown function '%s'", n.method)
+	}
+}
+
+func evalBool(n node, scope *scope, operator string) (bool, error) {
+	value, err := n.eval(scope)
+	if err != nil {
+		return false, err
+	}
+	boolean, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("'%s' requires a bool, got %s", operator, typeName(value))
+	}
+	return boolean, nil
+}
+
+func equal(left, right interface{}) bool {
+	return reflect.DeepEqual(left, right)
+}
+
+func compare(operator string, left, right interface{}) (interface{}, error) {
+	var order int
+	switch leftValue := left.(type) {
+	case float64:
+		rightValue, ok := right.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+		}
+		switch {
+		case leftValue < rightValue:
+			order = -1
+		case leftValue > rightValue:
+			order = 1
+		}
+	case string:
+		rightValue, ok := right.(string)
+		if !ok {
+			return nil, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+		}
+		order = strings.Compare(leftValue, rightValue)
+	default:
+		return nil, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+	}
+
+	switch operator {
+	case "<":
+		return order < 0, nil
+	case "<=":
+		return order <= 0, nil
+	case ">":
+		return order > 0, nil
+	default:
+		return order >= 0, nil
+	}
+}
+
+func size(value interface{}) (interface{}, error) {
+	switch typed := value.(type) {
+	case string:
+		return float64(len(typed)), nil
+	case []interface{}:
+		return float64(len(typed)), nil
+	default:
+		return nil, fmt.Errorf("'size' requires a string or list, got %s", typeName(value))
+	}
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,64 @@
+// Package expression implements the small expression language (the syntax is a subset of CEL) that is used to write
+// custom CI rules, e.g. "layers.filter(l, l.size > 500MB).size() == 0". Only what is needed to write predicates over
+// the analysis is supported, which keeps it far smaller than a full CEL implementation (and its dependencies).
+//
+// Values are bools, numbers (a number may carry a size unit, e.g. 500MB, and is then given in bytes), strings, lists,
+// and maps (whose fields are selected with "."). Supported are the operators ! < <= > >= == != && and ||, the list
+// macros all, exists, and filter (e.g. "files.exists(f, f.size > 1GB)"), size (of a list or string), and matches (a
+// regular expression).
+package expression
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Expression is a parsed expression that can be evaluated against different variables.
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses the given expression.
+func Parse(source string) (*Expression, error) {
+	root, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (expr *Expression) String() string {
+	return expr.source
+}
+
+// Evaluate evaluates the expression with the given variables. Variable values are expected to be bools, float64s,
+// strings, []interface{}, or map[string]interface{}.
+func (expr *Expression) Evaluate(variables map[string]interface{}) (interface{}, error) {
+	return expr.root.eval(&scope{variables: variables, regexps: make(map[string]*regexp.Regexp)})
+}
+
+// EvaluateBool evaluates the expression, which must result in a bool.
+func (expr *Expression) EvaluateBool(variables map[string]interface{}) (bool, error) {
+	value, err := expr.Evaluate(variables)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression must result in a bool, got %s", typeName(value))
+	}
+	return result, nil
+}
+
+// Format renders a value as text, numbers without a fraction are shown as integers.
+func Format(value interface{}) string {
+	switch typed := value.(type) {
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", typed)
+	}
+}
@@ -0,0 +1,135 @@
+package expression
+
+import (
+	"strings"
+	"testing"
+)
+
+func testVariables() map[string]interface{} {
+	return map[string]interface{}{
+		"image": map[string]interface{}{"size": float64(800 * 1000 * 1000), "efficiency": 0.95},
+		"layers": []interface{}{
+			map[string]interface{}{"index": float64(0), "size": float64(600 * 1000 * 1000), "command": "#(nop) ADD file:abc in /"},
+			map[string]interface{}{"index": float64(1), "size": float64(200 * 1000 * 1000), "command": "pip install -r requirements.txt"},
+		},
+		"files": []interface{}{
+			map[string]interface{}{"path": "/app/main.py", "size": float64(1200)},
+			map[string]interface{}{"path": "/app/__pycache__/main.cpython-37.pyc", "size": float64(900)},
+		},
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	table := map[string]struct {
+		expression string
+		expected   interface{}
+	}{
+		"literal":          {"42", float64(42)},
+		"sizeUnit":         {"1.5kB", float64(1500)},
+		"string":           {`'a\'b'`, "a'b"},
+		"parenthesis":      {"!(true && false) || false", true},
+		"comparison":       {"image.size > 500MB && image.efficiency >= 0.9", true},
+		"shortCircuit":     {"false && missing.field", false},
+		"not":              {"!(1 == 2)", true},
+		"filter":           {"layers.filter(l, l.size > 500MB).size()", float64(1)},
+		"all":              {"layers.all(l, l.size < 1GB)", true},
+		"exists":           {`files.exists(f, f.path.matches("\\.pyc$"))`, true},
+		"nestedScopes":     {`layers.exists(l, files.exists(f, f.size > l.index))`, true},
+		"stringSize":       {`files.exists(f, f.path.size() > 20)`, true},
+		"listEquality":     {"layers.filter(l, false) == files.filter(f, false)", true},
+		"stringComparison": {`"abc" < "abd"`, true},
+	}
+
+	for name, test := range table {
+		expr, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("%s: unable to parse: %v", name, err)
+			continue
+		}
+		actual, err := expr.Evaluate(testVariables())
+		if err != nil {
+			t.Errorf("%s: unable to evaluate: %v", name, err)
+			continue
+		}
+		if !equal(test.expected, actual) {
+			t.Errorf("%s: expected %v, got %v", name, test.expected, actual)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	table := map[string]struct {
+		expression string
+		expected   string
+	}{
+		"unterminatedString": {`"abc`, "unterminated string"},
+		"invalidEscape":      {`"\."`, "invalid escape sequence"},
+		"invalidSize":        {"5XB", "invalid size"},
+		"unexpectedEnd":      {"1 <", "unexpected end of expression"},
+		"missingParenthesis": {"(1 < 2", "expected ')'"},
+		"trailingTokens":     {"1 2", "unexpected '2'"},
+		"macroVariable":      {"layers.filter(1, true)", "must be a variable name"},
+		"unknownCharacter":   {"1 # 2", "unexpected character"},
+		"arithmetic":         {"image.size + 1", "unexpected character '+'"},
+	}
+
+	for name, test := range table {
+		_, err := Parse(test.expression)
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected error containing %q, got %q", name, test.expected, err)
+		}
+	}
+}
+
+func TestEvaluateErrors(t *testing.T) {
+	table := map[string]struct {
+		expression string
+		expected   string
+	}{
+		"undeclared":      {"missing > 1", "undeclared reference to 'missing'"},
+		"noSuchField":     {"image.missing", "no such field 'missing'"},
+		"typeMismatch":    {`image.size < "MB"`, "cannot compare number and string"},
+		"notBool":         {"layers.filter(l, l.size)", "'filter' requires a bool, got number"},
+		"invalidRegex":    {`files.exists(f, f.path.matches("["))`, "invalid regular expression"},
+		"unknownFunction": {"layers.first()", "unknown function 'first'"},
+	}
+
+	for name, test := range table {
+		expr, err := Parse(test.expression)
+		if err != nil {
+			t.Errorf("%s: unable to parse: %v", name, err)
+			continue
+		}
+		_, err = expr.Evaluate(testVariables())
+		if err == nil {
+			t.Errorf("%s: expected an error", name)
+			continue
+		}
+		if !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%s: expected error containing %q, got %q", name, test.expected, err)
+		}
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	template, err := ParseTemplate("{{ layers.filter(l, l.size > 500MB).size() }} of {{layers.size()}} layers exceed 500MB ({{ image.efficiency }})")
+	if err != nil {
+		t.Fatalf("unable to parse template: %v", err)
+	}
+	actual, err := template.Render(testVariables())
+	if err != nil {
+		t.Fatalf("unable to render template: %v", err)
+	}
+	expected := "1 of 2 layers exceed 500MB (0.95)"
+	if actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+
+	if _, err := ParseTemplate("{{ layers.size() "); err == nil {
+		t.Errorf("expected an error for an unterminated template expression")
+	}
+}
@@ -0,0 +1,156 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dustin/go-humanize"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+)
+
+type token struct {
+	kind   tokenKind
+	text   string  // the identifier, operator, or (unquoted) string value
+	number float64 // the value of a number token
+	pos    int     // the offset of the token in the source
+}
+
+// operators lists all operators, longest first such that the lexer prefers e.g. "<=" over "<".
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", ".", ","}
+
+// tokenize splits the source into tokens, the last token is always tokenEOF.
+func tokenize(source string) ([]token, error) {
+	var tokens []token
+	pos := 0
+	for pos < len(source) {
+		char := rune(source[pos])
+		switch {
+		case unicode.IsSpace(char):
+			pos++
+		case isDigit(char):
+			tok, next, err := lexNumber(source, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			pos = next
+		case char == '"' || char == '\'':
+			value, next, err := lexString(source, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: value, pos: pos})
+			pos = next
+		case isIdentStart(char):
+			end := pos
+			for end < len(source) && isIdentPart(rune(source[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			operator := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(source[pos:], candidate) {
+					operator = candidate
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character '%c' at offset %d", char, pos)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: operator, pos: pos})
+			pos += len(operator)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: pos}), nil
+}
+
+// lexNumber reads a number, optionally directly followed by a size unit (e.g. 500MB), which is converted to bytes.
+func lexNumber(source string, start int) (token, int, error) {
+	pos := start
+	for pos < len(source) && isDigit(rune(source[pos])) {
+		pos++
+	}
+	if pos+1 < len(source) && source[pos] == '.' && isDigit(rune(source[pos+1])) {
+		pos++
+		for pos < len(source) && isDigit(rune(source[pos])) {
+			pos++
+		}
+	}
+	number := source[start:pos]
+
+	unitEnd := pos
+	for unitEnd < len(source) && unicode.IsLetter(rune(source[unitEnd])) {
+		unitEnd++
+	}
+	if unitEnd > pos {
+		size, err := humanize.ParseBytes(source[start:unitEnd])
+		if err != nil {
+			return token{}, 0, fmt.Errorf("invalid size '%s' at offset %d", source[start:unitEnd], start)
+		}
+		return token{kind: tokenNumber, number: float64(size), pos: start}, unitEnd, nil
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return token{}, 0, fmt.Errorf("invalid number '%s' at offset %d", number, start)
+	}
+	return token{kind: tokenNumber, number: value, pos: start}, pos, nil
+}
+
+// lexString reads a quoted string starting at the opening quote.
+func lexString(source string, start int) (string, int, error) {
+	quote := source[start]
+	var sb strings.Builder
+	pos := start + 1
+	for pos < len(source) {
+		char := source[pos]
+		switch {
+		case char == quote:
+			return sb.String(), pos + 1, nil
+		case char == '\\':
+			if pos+1 >= len(source) {
+				return "", 0, fmt.Errorf("unterminated string at offset %d", start)
+			}
+			switch escaped := source[pos+1]; escaped {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			case '\\', '"', '\'':
+				sb.WriteByte(escaped)
+			default:
+				return "", 0, fmt.Errorf("invalid escape sequence '\\%c' at offset %d (use '\\\\' for a backslash)", escaped, pos)
+			}
+			pos += 2
+		default:
+			sb.WriteByte(char)
+			pos++
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated string at offset %d", start)
+}
+
+func isDigit(char rune) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isIdentStart(char rune) bool {
+	return char == '_' || (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z')
+}
+
+func isIdentPart(char rune) bool {
+	return isIdentStart(char) || isDigit(char)
+}
@@ -0,0 +1,233 @@
+package expression
+
+import (
+	"fmt"
+)
+
+// macros are the list methods that take a variable name and an expression that is evaluated for each element.
+var macros = map[string]bool{
+	"all":    true,
+	"exists": true,
+	"filter": true,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(source string) (node, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+	p := parser{tokens: tokens}
+	result, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+	return result, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the given operator (or keyword).
+func (p *parser) accept(text string) bool {
+	tok := p.peek()
+	if (tok.kind == tokenOperator || tok.kind == tokenIdent) && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		tok := p.peek()
+		if tok.kind == tokenEOF {
+			return fmt.Errorf("expected '%s' at end of expression", text)
+		}
+		return fmt.Errorf("expected '%s' at offset %d, got '%s'", text, tok.pos, tok.display())
+	}
+	return nil
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of expression")
+	}
+	return fmt.Errorf("unexpected '%s' at offset %d", tok.display(), tok.pos)
+}
+
+func (tok token) display() string {
+	switch tok.kind {
+	case tokenNumber:
+		return fmt.Sprintf("%v", tok.number)
+	case tokenString:
+		return fmt.Sprintf("%q", tok.text)
+	default:
+		return tok.text
+	}
+}
+
+// parseExpression parses an expression (starting at the lowest precedence).
+func (p *parser) parseExpression() (node, error) {
+	return p.parseBinary(0)
+}
+
+// precedences lists the binary operators from the lowest to the highest precedence.
+var precedences = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!=", "<", "<=", ">", ">="},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedences) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		operator := ""
+		for _, candidate := range precedences[level] {
+			if p.accept(candidate) {
+				operator = candidate
+				break
+			}
+		}
+		if operator == "" {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{operator: operator, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if p.accept("!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parsePostfix()
+}
+
+func (p *parser) parsePostfix() (node, error) {
+	result, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept(".") {
+		tok := p.next()
+		if tok.kind != tokenIdent {
+			return nil, p.unexpected(tok)
+		}
+		if !p.accept("(") {
+			result = &fieldNode{target: result, field: tok.text}
+			continue
+		}
+		if macros[tok.text] {
+			result, err = p.parseMacro(result, tok.text)
+		} else {
+			var args []node
+			args, err = p.parseArguments()
+			result = &callNode{target: result, method: tok.text, args: args}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// parseMacro parses the arguments of a macro (e.g. "filter(l, l.size > 1MB)"), the opening parenthesis is consumed.
+func (p *parser) parseMacro(target node, name string) (node, error) {
+	tok := p.next()
+	if tok.kind != tokenIdent {
+		return nil, fmt.Errorf("the first argument of '%s' must be a variable name (offset %d)", name, tok.pos)
+	}
+	if err := p.expect(","); err != nil {
+		return nil, err
+	}
+	body, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return &macroNode{target: target, macro: name, variable: tok.text, body: body}, nil
+}
+
+// parseArguments parses a comma separated list of expressions up to the closing parenthesis (the opening parenthesis
+// is consumed).
+func (p *parser) parseArguments() ([]node, error) {
+	var args []node
+	if p.accept(")") {
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+		if p.accept(")") {
+			return args, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		return &literalNode{value: tok.number}, nil
+	case tokenString:
+		return &literalNode{value: tok.text}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		}
+		return &identNode{name: tok.text}, nil
+	case tokenOperator:
+		if tok.text == "(" {
+			inner, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			return inner, nil
+		}
+	}
+	return nil, p.unexpected(tok)
+}
@@ -0,0 +1,57 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Template is text with embedded expressions in double braces, e.g. "{{ layers.size() }} layers".
+type Template struct {
+	parts []templatePart
+}
+
+type templatePart struct {
+	text string
+	expr *Expression // nil for literal text
+}
+
+// ParseTemplate parses the given template text.
+func ParseTemplate(text string) (*Template, error) {
+	var template Template
+	rest := text
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			template.parts = append(template.parts, templatePart{text: rest})
+			return &template, nil
+		}
+		end := strings.Index(rest[start:], "}}")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated '{{' in template")
+		}
+		source := strings.TrimSpace(rest[start+2 : start+end])
+		expr, err := Parse(source)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template expression ('%s'): %v", source, err)
+		}
+		template.parts = append(template.parts, templatePart{text: rest[:start]}, templatePart{expr: expr})
+		rest = rest[start+end+2:]
+	}
+}
+
+// Render evaluates all embedded expressions with the given variables.
+func (template *Template) Render(variables map[string]interface{}) (string, error) {
+	var sb strings.Builder
+	for _, part := range template.parts {
+		if part.expr == nil {
+			sb.WriteString(part.text)
+			continue
+		}
+		value, err := part.expr.Evaluate(variables)
+		if err != nil {
+			return "", fmt.Errorf("unable to render '%s': %v", part.expr, err)
+		}
+		sb.WriteString(Format(value))
+	}
+	return sb.String(), nil
+}
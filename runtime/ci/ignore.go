@@ -0,0 +1,63 @@
+package ci
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// ignoreDateFormat is the format of the expiry date of an ignore entry.
+const ignoreDateFormat = "2006-01-02"
+
+// IgnoreEntry acknowledges the inefficient files that match the path glob, such that they are not considered by the
+// CI rules. An entry with an expiry date no longer applies after that date.
+type IgnoreEntry struct {
+	Path    string `mapstructure:"path" json:"path"`
+	Reason  string `mapstructure:"reason" json:"reason,omitempty"`
+	Expires string `mapstructure:"expires" json:"expires,omitempty"`
+}
+
+// Suppressed summarizes the inefficiencies that were excluded by the ignore entries.
+type Suppressed struct {
+	Files          int           `json:"files"`
+	WastedBytes    uint64        `json:"wastedBytes"`
+	ExpiredEntries []IgnoreEntry `json:"expiredEntries"`
+}
+
+func loadIgnoreEntries(config *viper.Viper) ([]IgnoreEntry, error) {
+	var entries []IgnoreEntry
+	if !config.IsSet("ignore") {
+		return entries, nil
+	}
+	if err := config.UnmarshalKey("ignore", &entries); err != nil {
+		return nil, fmt.Errorf("invalid ignore list: %v", err)
+	}
+	for _, entry := range entries {
+		if entry.Path == "" {
+			return nil, fmt.Errorf("ignore entry without a path")
+		}
+		if err := filetree.ValidateGlob(entry.Path); err != nil {
+			return nil, fmt.Errorf("invalid ignore path ('%v'): %v", entry.Path, err)
+		}
+		if entry.Expires != "" {
+			if _, err := time.Parse(ignoreDateFormat, entry.Expires); err != nil {
+				return nil, fmt.Errorf("invalid ignore expiry date ('%v'), expected YYYY-MM-DD: %v", entry.Expires, err)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// isExpired indicates if the entry no longer applies at the given time (the entry still applies on the expiry date).
+func (entry IgnoreEntry) isExpired(now time.Time) bool {
+	if entry.Expires == "" {
+		return false
+	}
+	expires, err := time.Parse(ignoreDateFormat, entry.Expires)
+	if err != nil {
+		return false
+	}
+	return now.After(expires.AddDate(0, 0, 1))
+}
@@ -0,0 +1,255 @@
+package ci
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/utils"
+)
+
+var (
+	thresholdRuleKeys = []string{"fail", "warn", "severity"}
+	pathRuleKeys      = []string{"paths", "severity"}
+	auditRuleKeys     = []string{"allow", "severity"}
+)
+
+// configurableRule is implemented by the built-in rules to describe their keys in the CI config.
+type configurableRule interface {
+	configKeys() map[string][]string
+}
+
+// knownRuleKeys lists every built-in rule that can be configured under "rules", with the keys the rule accepts when it
+// is given as a map.
+func knownRuleKeys() map[string][]string {
+	keys := make(map[string][]string)
+	for _, rule := range loadCiRules(viper.New(), &stackedTreeCache{}, true) {
+		if configurable, ok := rule.(configurableRule); ok {
+			for key, subKeys := range configurable.configKeys() {
+				keys[key] = subKeys
+			}
+		}
+	}
+	return keys
+}
+
+// knownTopLevelKeys lists the keys of the CI config besides "rules", with the keys of their list entries.
+var knownTopLevelKeys = map[string][]string{
+	"baseline":      nil,
+	"ignore":        {"path", "reason", "expires"},
+	"customRules":   {"name", "expression", "message", "severity"},
+	"ignore-errors": nil,
+}
+
+// ConfigIssue is a key of the CI config that is not understood by any rule.
+type ConfigIssue struct {
+	Key        string
+	Suggestion string // the closest known key ("" if there is none)
+}
+
+func (issue ConfigIssue) String() string {
+	if issue.Suggestion == "" {
+		return fmt.Sprintf("unknown key '%s'", issue.Key)
+	}
+	return fmt.Sprintf("unknown key '%s' (did you mean '%s'?)", issue.Key, issue.Suggestion)
+}
+
+// Linter validates a CI config without evaluating it against an image.
+type Linter struct {
+	Evaluator   *CiEvaluator
+	UnknownKeys []ConfigIssue
+}
+
+// Lint validates all rules of the given CI config and looks for keys that would be silently ignored (e.g. a misspelled
+// rule name).
+func Lint(config *viper.Viper) *Linter {
+	linter := &Linter{
+		Evaluator:   NewCiEvaluator(config),
+		UnknownKeys: unknownKeys(config),
+	}
+	linter.Evaluator.Validate()
+	return linter
+}
+
+// Valid indicates that all rules are configured correctly and that there are no unknown keys.
+func (linter *Linter) Valid() bool {
+	return !linter.Evaluator.Misconfigured && len(linter.UnknownKeys) == 0
+}
+
+func (linter *Linter) Report() string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Rules:"))
+
+	configurations := make(map[string]string)
+	for _, rule := range linter.Evaluator.Rules {
+		configurations[rule.Key()] = rule.Configuration()
+		if warnRule, ok := rule.(interface{ WarnConfiguration() string }); ok && warnRule.WarnConfiguration() != "" {
+			configurations[rule.Key()] = fmt.Sprintf("%s (warn: %s)", rule.Configuration(), warnRule.WarnConfiguration())
+		}
+	}
+
+	for _, name := range linter.Evaluator.SortedRules() {
+		result := linter.Evaluator.Results[name]
+		switch {
+		case result.status == RuleMisconfigured:
+			fmt.Fprintf(&sb, "  %s: %s: %s\n", result.status.String(), name, result.message)
+		case result.message == "rule disabled":
+			fmt.Fprintf(&sb, "  %s: %s: rule disabled\n", RuleStatus(RuleDisabled).String(), name)
+		default:
+			fmt.Fprintf(&sb, "  %s: %s: %s\n", result.status.String(), name, configurations[name])
+		}
+	}
+
+	if len(linter.UnknownKeys) > 0 {
+		fmt.Fprintln(&sb, utils.TitleFormat("Unknown keys:"))
+		for _, issue := range linter.UnknownKeys {
+			fmt.Fprintf(&sb, "  %s: %s\n", aurora.Red("UNKNOWN"), issue)
+		}
+	}
+
+	if linter.Valid() {
+		fmt.Fprint(&sb, aurora.Green("CI config is valid"))
+	} else {
+		fmt.Fprint(&sb, aurora.Red("CI Misconfigured"))
+	}
+	return sb.String()
+}
+
+// unknownKeys returns all keys of the config that are not used by any rule. Note that viper reports all keys in lower
+// case, so the keys are compared case-insensitively.
+func unknownKeys(config *viper.Viper) []ConfigIssue {
+	known := make(map[string]string)
+	for rule, subKeys := range knownRuleKeys() {
+		known[strings.ToLower("rules."+rule)] = "rules." + rule
+		for _, subKey := range subKeys {
+			known[strings.ToLower("rules."+rule+"."+subKey)] = "rules." + rule + "." + subKey
+		}
+	}
+	for key := range knownTopLevelKeys {
+		known[strings.ToLower(key)] = key
+	}
+
+	var issues []ConfigIssue
+	for _, key := range config.AllKeys() {
+		if _, exists := known[key]; !exists {
+			issues = append(issues, ConfigIssue{Key: key, Suggestion: closestKey(key, known)})
+		}
+	}
+
+	// the entries of lists are not part of the keys reported by viper
+	for key, entryKeys := range knownTopLevelKeys {
+		entries, ok := config.Get(key).([]interface{})
+		if !ok || len(entryKeys) == 0 {
+			continue
+		}
+		allowed := make(map[string]string)
+		for _, entryKey := range entryKeys {
+			allowed[strings.ToLower(entryKey)] = entryKey
+		}
+		for idx, entry := range entries {
+			for _, entryKey := range mapKeys(entry) {
+				if _, exists := allowed[strings.ToLower(entryKey)]; !exists {
+					issues = append(issues, ConfigIssue{
+						Key:        fmt.Sprintf("%s[%d].%s", key, idx, entryKey),
+						Suggestion: closestKey(strings.ToLower(entryKey), allowed),
+					})
+				}
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		return issues[i].Key < issues[j].Key
+	})
+	return issues
+}
+
+// mapKeys returns the keys of a map read from the config (yaml maps may have non-string keys).
+func mapKeys(value interface{}) []string {
+	var keys []string
+	switch typed := value.(type) {
+	case map[string]interface{}:
+		for key := range typed {
+			keys = append(keys, key)
+		}
+	case map[interface{}]interface{}:
+		for key := range typed {
+			keys = append(keys, fmt.Sprintf("%v", key))
+		}
+	}
+	return keys
+}
+
+// closestKey returns the known key (by its lower case form) that is within a small edit distance of the given key.
+func closestKey(key string, known map[string]string) string {
+	best, bestDistance := "", 3
+	for lowerKey, knownKey := range known {
+		if distance := editDistance(key, lowerKey); distance < bestDistance || (distance == bestDistance && best != "" && knownKey < best) {
+			best, bestDistance = knownKey, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between both strings.
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minimum(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+func minimum(values ...int) int {
+	result := values[0]
+	for _, value := range values[1:] {
+		if value < result {
+			result = value
+		}
+	}
+	return result
+}
+
+// EffectiveConfig returns all settings of the CI config (merged from the flags and the config file), with the known
+// keys in their documented case (viper reports all keys in lower case).
+func EffectiveConfig(config *viper.Viper) map[string]interface{} {
+	known := make(map[string]string)
+	for rule, subKeys := range knownRuleKeys() {
+		known[strings.ToLower(rule)] = rule
+		for _, subKey := range subKeys {
+			known[strings.ToLower(subKey)] = subKey
+		}
+	}
+	for key := range knownTopLevelKeys {
+		known[strings.ToLower(key)] = key
+	}
+	return restoreKeyCase(config.AllSettings(), known)
+}
+
+func restoreKeyCase(settings map[string]interface{}, known map[string]string) map[string]interface{} {
+	result := make(map[string]interface{})
+	for key, value := range settings {
+		if knownKey, exists := known[key]; exists {
+			key = knownKey
+		}
+		if nested, ok := value.(map[string]interface{}); ok {
+			value = restoreKeyCase(nested, known)
+		}
+		result[key] = value
+	}
+	return result
+}
@@ -0,0 +1,149 @@
+package ci
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func Test_Lint(t *testing.T) {
+	table := map[string]struct {
+		config              string
+		expectedValid       bool
+		expectedMisconfig   bool
+		expectedUnknownKeys []ConfigIssue
+	}{
+		"valid": {
+			config: `
+rules:
+  lowestEfficiency:
+    warn: 0.95
+    fail: 0.9
+  highestWastedBytes: 20MB
+  highestUserWastedPercent: disabled
+  forbiddenPaths:
+    paths: ["**/*.pem"]
+    severity: warn
+  unexpectedExecutables:
+    paths: ["/usr/**"]
+ignore:
+  - path: /root/**
+    expires: 2999-01-01
+customRules:
+  - name: fewLayers
+    expression: image.layerCount < 50
+`,
+			expectedValid: true,
+		},
+		"misspelledRule": {
+			config: `
+rules:
+  lowestEfficency: 0.9
+  highestWastedBytes: disabled
+  highestUserWastedPercent: disabled
+`,
+			expectedUnknownKeys: []ConfigIssue{{Key: "rules.lowestefficency", Suggestion: "rules.lowestEfficiency"}},
+		},
+		"unknownEntryKey": {
+			config: `
+rules:
+  highestWastedBytes: disabled
+  highestUserWastedPercent: disabled
+customRules:
+  - name: fewLayers
+    expresion: image.layerCount < 50
+`,
+			// the custom rule without an expression is misconfigured as well
+			expectedMisconfig:   true,
+			expectedUnknownKeys: []ConfigIssue{{Key: "customRules[0].expresion", Suggestion: "expression"}},
+		},
+		"unrelatedKey": {
+			config: `
+rules:
+  highestWastedBytes: disabled
+  highestUserWastedPercent: disabled
+notifications: slack
+`,
+			expectedUnknownKeys: []ConfigIssue{{Key: "notifications"}},
+		},
+		"misconfiguredRule": {
+			config: `
+rules:
+  lowestEfficiency: 1.5
+  highestWastedBytes: disabled
+  highestUserWastedPercent: disabled
+`,
+			expectedMisconfig: true,
+		},
+	}
+
+	for name, test := range table {
+		config := viper.New()
+		config.SetConfigType("yaml")
+		if err := config.ReadConfig(bytes.NewBufferString(test.config)); err != nil {
+			t.Fatalf("%s: unable to read config: %v", name, err)
+		}
+		if !config.IsSet("rules.lowestEfficiency") {
+			config.Set("rules.lowestEfficiency", "0.9")
+		}
+
+		linter := Lint(config)
+
+		if linter.Valid() != test.expectedValid {
+			t.Errorf("%s: expected valid=%v, got %v:\n%s", name, test.expectedValid, linter.Valid(), linter.Report())
+		}
+		if linter.Evaluator.Misconfigured != test.expectedMisconfig {
+			t.Errorf("%s: expected misconfigured=%v, got %v:\n%s", name, test.expectedMisconfig, linter.Evaluator.Misconfigured, linter.Report())
+		}
+		if len(test.expectedUnknownKeys) > 0 || len(linter.UnknownKeys) > 0 {
+			if !reflect.DeepEqual(test.expectedUnknownKeys, linter.UnknownKeys) {
+				t.Errorf("%s: expected unknown keys %+v, got %+v", name, test.expectedUnknownKeys, linter.UnknownKeys)
+			}
+		}
+	}
+}
+
+func Test_EffectiveConfig(t *testing.T) {
+	config := viper.New()
+	config.Set("rules.lowestEfficiency", "0.9")
+	config.Set("rules.forbiddenPaths.paths", []string{"/root/**"})
+	config.Set("baseline", "previous.json")
+
+	expected := map[string]interface{}{
+		"rules": map[string]interface{}{
+			"lowestEfficiency": "0.9",
+			"forbiddenPaths":   map[string]interface{}{"paths": []string{"/root/**"}},
+		},
+		"baseline": "previous.json",
+	}
+	if actual := EffectiveConfig(config); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected %+v, got %+v", expected, actual)
+	}
+}
+
+func Test_KnownRuleKeys(t *testing.T) {
+	keys := knownRuleKeys()
+
+	expected := map[string][]string{
+		"lowestEfficiency":       thresholdRuleKeys,
+		"highestFileSize":        thresholdRuleKeys,
+		"forbiddenPaths":         pathRuleKeys,
+		"forbiddenPathsAnyLayer": nil,
+		"setuidFiles":            auditRuleKeys,
+		"unexpectedExecutables":  append([]string{"paths"}, auditRuleKeys...),
+		"maxNewLayers":           thresholdRuleKeys,
+	}
+	for rule, subKeys := range expected {
+		actual, exists := keys[rule]
+		if !exists {
+			t.Errorf("expected a known rule '%s'", rule)
+		} else if !reflect.DeepEqual(subKeys, actual) {
+			t.Errorf("%s: expected keys %v, got %v", rule, subKeys, actual)
+		}
+	}
+	if len(keys) != 17 {
+		t.Errorf("expected 17 known rules, got %d", len(keys))
+	}
+}
@@ -0,0 +1,187 @@
+package ci
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// maxReportedPaths limits the number of offending paths that are named in a rule result message.
+const maxReportedPaths = 5
+
+// PathCiRule is a CI rule that is configured with a list of glob patterns (see filetree.MatchGlob) instead of a single
+// threshold value.
+type PathCiRule struct {
+	key       string
+	patterns  []string
+	options   []string // the keys of further options of the rule (e.g. "forbiddenPathsAnyLayer")
+	evaluator func(*image.AnalysisResult, []string) (RuleStatus, string)
+}
+
+func newPathCiRule(key string, patterns []string, evaluator func(*image.AnalysisResult, []string) (RuleStatus, string)) *PathCiRule {
+	return &PathCiRule{
+		key:       key,
+		patterns:  patterns,
+		evaluator: evaluator,
+	}
+}
+
+func (rule *PathCiRule) Key() string {
+	return rule.key
+}
+
+func (rule *PathCiRule) configKeys() map[string][]string {
+	keys := map[string][]string{rule.key: pathRuleKeys}
+	for _, option := range rule.options {
+		keys[option] = nil
+	}
+	return keys
+}
+
+func (rule *PathCiRule) Configuration() string {
+	return strings.Join(rule.patterns, ", ")
+}
+
+func (rule *PathCiRule) Validate() error {
+	for _, pattern := range rule.patterns {
+		if err := filetree.ValidateGlob(pattern); err != nil {
+			return fmt.Errorf("invalid path pattern ('%v'): %v", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (rule *PathCiRule) Evaluate(analysis *image.AnalysisResult) (RuleStatus, string) {
+	return rule.evaluator(analysis, rule.patterns)
+}
+
+// loadPathCiRules returns the forbidden and required path rules that are configured (these are never enabled by
+// default), or all of them.
+func loadPathCiRules(config *viper.Viper, stack *stackedTreeCache, all bool) []CiRule {
+	var rules = make([]CiRule, 0)
+
+	if patterns := loadRulePatterns(config, "forbiddenPaths"); all || len(patterns) > 0 {
+		anyLayer := config.GetBool("rules.forbiddenPathsAnyLayer")
+		rule := newPathCiRule(
+			"forbiddenPaths",
+			patterns,
+			func(analysis *image.AnalysisResult, patterns []string) (RuleStatus, string) {
+				var found []string
+				if anyLayer {
+					// note: the layer trees still contain files that are removed by a later layer
+					for idx, tree := range analysis.RefTrees {
+						for _, match := range matchTree(tree, patterns) {
+							found = append(found, fmt.Sprintf("%s (layer %d)", match, idx))
+						}
+					}
+				} else {
+					tree, err := stack.get(analysis)
+					if err != nil {
+						return RuleFailed, fmt.Sprintf("unable to stack the image layers: %v", err)
+					}
+					found = matchTree(tree, patterns)
+				}
+				if len(found) > 0 {
+					return RuleFailed, fmt.Sprintf("forbidden paths found (%d): %s", len(found), summarizePaths(found))
+				}
+				return RulePassed, ""
+			},
+		)
+		rule.options = []string{"forbiddenPathsAnyLayer"}
+		rules = append(rules, rule)
+	}
+
+	if patterns := loadRulePatterns(config, "requiredPaths"); all || len(patterns) > 0 {
+		rules = append(rules, newPathCiRule(
+			"requiredPaths",
+			patterns,
+			func(analysis *image.AnalysisResult, patterns []string) (RuleStatus, string) {
+				tree, err := stack.get(analysis)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("unable to stack the image layers: %v", err)
+				}
+				var missing []string
+				for _, pattern := range patterns {
+					if len(matchTree(tree, []string{pattern})) == 0 {
+						missing = append(missing, pattern)
+					}
+				}
+				if len(missing) > 0 {
+					return RuleFailed, fmt.Sprintf("required paths missing (%d): %s", len(missing), summarizePaths(missing))
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	return rules
+}
+
+// loadRulePatterns returns the globs of a path rule, given either as a list or as the "paths" of a map (which may also
+// carry a severity override).
+func loadRulePatterns(config *viper.Viper, key string) []string {
+	configKey := fmt.Sprintf("rules.%s", key)
+	if config.IsSet(configKey + ".paths") {
+		return config.GetStringSlice(configKey + ".paths")
+	}
+	return config.GetStringSlice(configKey)
+}
+
+// stackedTreeCache squashes all layers of the analyzed image into the final filesystem, which is done only once for
+// all rules of an evaluation (see CiEvaluator.Evaluate).
+type stackedTreeCache struct {
+	analysis *image.AnalysisResult
+	tree     *filetree.FileTree
+	err      error
+}
+
+func (cache *stackedTreeCache) get(analysis *image.AnalysisResult) (*filetree.FileTree, error) {
+	if cache.analysis != analysis {
+		cache.analysis = analysis
+		cache.tree, cache.err = stackAnalysis(analysis)
+	}
+	return cache.tree, cache.err
+}
+
+// release drops the stacked tree once the evaluation is done.
+func (cache *stackedTreeCache) release() {
+	*cache = stackedTreeCache{}
+}
+
+// stackAnalysis squashes all layers of the analyzed image into the final filesystem.
+func stackAnalysis(analysis *image.AnalysisResult) (*filetree.FileTree, error) {
+	if len(analysis.RefTrees) == 0 {
+		return filetree.NewFileTree(), nil
+	}
+	tree, _, err := filetree.StackTreeRange(analysis.RefTrees, 0, len(analysis.RefTrees)-1)
+	return tree, err
+}
+
+// matchTree returns all paths in the tree (files and directories, but not whiteouts) that match any of the patterns.
+func matchTree(tree *filetree.FileTree, patterns []string) []string {
+	var matches []string
+	_ = tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		nodePath := node.Path()
+		for _, pattern := range patterns {
+			if filetree.MatchGlob(pattern, nodePath) {
+				matches = append(matches, nodePath)
+				break
+			}
+		}
+		return nil
+	}, func(node *filetree.FileNode) bool {
+		return !node.IsWhiteout()
+	})
+	return matches
+}
+
+// summarizePaths renders the first few of the given paths.
+func summarizePaths(paths []string) string {
+	if len(paths) <= maxReportedPaths {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s, ... (and %d more)", strings.Join(paths[:maxReportedPaths], ", "), len(paths)-maxReportedPaths)
+}
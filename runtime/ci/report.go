@@ -0,0 +1,415 @@
+package ci
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// ReportFormats are the supported machine-readable CI report formats (besides the default text report).
+var ReportFormats = []string{"junit", "sarif", "github", "json"}
+
+// inefficientFileRule is the name used for findings about inefficient files (these are not a rule of their own).
+const inefficientFileRule = "inefficientFile"
+
+// FormatReport renders the evaluation results in the given machine-readable format.
+func (ci *CiEvaluator) FormatReport(format, imageName string) ([]byte, error) {
+	switch format {
+	case "junit":
+		return ci.junitReport(imageName)
+	case "sarif":
+		return ci.sarifReport(imageName)
+	case "github":
+		return ci.githubReport(), nil
+	case "json":
+		return ci.jsonReport(imageName)
+	default:
+		return nil, fmt.Errorf("unsupported CI report format: '%s' (supported: %s)", format, strings.Join(ReportFormats, ", "))
+	}
+}
+
+// ImageEvaluation is the evaluation of a single image in a CI run over several images.
+type ImageEvaluation struct {
+	Image     string
+	Evaluator *CiEvaluator
+}
+
+// FormatReports renders the evaluation results of several images as a single report in the given machine-readable
+// format.
+func FormatReports(format string, evaluations []ImageEvaluation) ([]byte, error) {
+	switch format {
+	case "junit":
+		var suites []junitTestSuite
+		for _, evaluation := range evaluations {
+			suites = append(suites, evaluation.Evaluator.junitSuite(evaluation.Image))
+		}
+		return marshalJunitSuites(suites)
+	case "sarif":
+		runs := make([]sarifRun, 0)
+		for _, evaluation := range evaluations {
+			runs = append(runs, evaluation.Evaluator.sarifRun(evaluation.Image))
+		}
+		return marshalSarifRuns(runs)
+	case "github":
+		var report strings.Builder
+		for _, evaluation := range evaluations {
+			report.WriteString(evaluation.Evaluator.githubCommands(fmt.Sprintf(" (%s)", evaluation.Image)))
+		}
+		return []byte(report.String()), nil
+	case "json":
+		report := jsonMultiReport{Pass: true, Images: make([]jsonReport, 0)}
+		for _, evaluation := range evaluations {
+			report.Pass = report.Pass && evaluation.Evaluator.Pass
+			report.Images = append(report.Images, evaluation.Evaluator.newJSONReport(evaluation.Image))
+		}
+		return json.MarshalIndent(&report, "", "  ")
+	default:
+		return nil, fmt.Errorf("unsupported CI report format: '%s' (supported: %s)", format, strings.Join(ReportFormats, ", "))
+	}
+}
+
+// Name returns the plain (uncolored) name of the status.
+func (status RuleStatus) Name() string {
+	switch status {
+	case RulePassed:
+		return "pass"
+	case RuleFailed:
+		return "fail"
+	case RuleWarning:
+		return "warn"
+	case RuleDisabled:
+		return "skip"
+	case RuleMisconfigured:
+		return "misconfigured"
+	case RuleConfigured:
+		return "configured"
+	default:
+		return "unknown"
+	}
+}
+
+// SortedRules returns the names of all evaluated rules in a stable order.
+func (ci *CiEvaluator) SortedRules() []string {
+	rules := make([]string, 0, len(ci.Results))
+	for name := range ci.Results {
+		rules = append(rules, name)
+	}
+	sort.Strings(rules)
+	return rules
+}
+
+func inefficientFileMessage(file ReferenceFile) string {
+	return fmt.Sprintf("%s wastes %s (%d copies across layers)", file.Path, humanize.Bytes(file.SizeBytes), file.References)
+}
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+	SystemOut string          `xml:"system-out,omitempty"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+}
+
+func (ci *CiEvaluator) junitReport(imageName string) ([]byte, error) {
+	return marshalJunitSuites([]junitTestSuite{ci.junitSuite(imageName)})
+}
+
+func (ci *CiEvaluator) junitSuite(imageName string) junitTestSuite {
+	rules := junitTestSuite{Name: fmt.Sprintf("dive rules (%s)", imageName)}
+	for _, name := range ci.SortedRules() {
+		result := ci.Results[name]
+		testCase := junitTestCase{Name: name, ClassName: "dive.rules"}
+		switch result.status {
+		case RuleFailed:
+			testCase.Failure = &junitMessage{Message: result.message, Type: "fail"}
+			rules.Failures++
+		case RuleMisconfigured:
+			testCase.Error = &junitMessage{Message: result.message, Type: "misconfigured"}
+			rules.Errors++
+		case RuleDisabled:
+			testCase.Skipped = &junitMessage{Message: result.message}
+			rules.Skipped++
+		case RuleWarning:
+			testCase.SystemOut = "warning: " + result.message
+		}
+		rules.TestCases = append(rules.TestCases, testCase)
+	}
+	rules.Tests = len(rules.TestCases)
+
+	var inefficientFiles strings.Builder
+	for _, file := range ci.InefficientFiles {
+		inefficientFiles.WriteString(inefficientFileMessage(file) + "\n")
+	}
+	rules.SystemOut = inefficientFiles.String()
+	return rules
+}
+
+func marshalJunitSuites(suites []junitTestSuite) ([]byte, error) {
+	report := junitTestSuites{
+		Name:   "dive",
+		Suites: suites,
+	}
+	for _, suite := range suites {
+		report.Tests += suite.Tests
+		report.Failures += suite.Failures
+		report.Errors += suite.Errors
+		report.Skipped += suite.Skipped
+	}
+
+	payload, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), payload...), nil
+}
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Kind      string          `json:"kind"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+func (ci *CiEvaluator) sarifReport(imageName string) ([]byte, error) {
+	return marshalSarifRuns([]sarifRun{ci.sarifRun(imageName)})
+}
+
+func (ci *CiEvaluator) sarifRun(imageName string) sarifRun {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "dive",
+			InformationURI: "https://github.com/wagoodman/dive",
+			Rules:          make([]sarifRule, 0),
+		}},
+		Results: make([]sarifResult, 0),
+	}
+
+	for _, name := range ci.SortedRules() {
+		result := ci.Results[name]
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: name, ShortDescription: sarifMessage{Text: name}})
+
+		sarifResult := sarifResult{RuleID: name, Message: sarifMessage{Text: result.message}, Locations: ci.sarifRuleLocations()}
+		switch result.status {
+		case RulePassed:
+			sarifResult.Kind, sarifResult.Level = "pass", "none"
+		case RuleFailed, RuleMisconfigured:
+			sarifResult.Kind, sarifResult.Level = "fail", "error"
+		case RuleWarning:
+			sarifResult.Kind, sarifResult.Level = "fail", "warning"
+		case RuleDisabled:
+			sarifResult.Kind, sarifResult.Level = "notApplicable", "none"
+		case RuleConfigured:
+			// the rule was not evaluated, as another rule is misconfigured
+			sarifResult.Kind, sarifResult.Level = "notApplicable", "none"
+			sarifResult.Message.Text = fmt.Sprintf("%s: not evaluated (%s)", name, imageName)
+		default:
+			sarifResult.Kind, sarifResult.Level = "open", "none"
+		}
+		if sarifResult.Message.Text == "" {
+			sarifResult.Message.Text = fmt.Sprintf("%s: %s (%s)", name, result.status.Name(), imageName)
+		}
+		run.Results = append(run.Results, sarifResult)
+	}
+
+	if len(ci.InefficientFiles) > 0 {
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, sarifRule{ID: inefficientFileRule, ShortDescription: sarifMessage{Text: "file is duplicated or removed across layers"}})
+	}
+	for _, file := range ci.InefficientFiles {
+		run.Results = append(run.Results, sarifResult{
+			RuleID:  inefficientFileRule,
+			Kind:    "review",
+			Level:   "note",
+			Message: sarifMessage{Text: inefficientFileMessage(file)},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: sarifArtifactLocation{URI: strings.TrimPrefix(file.Path, "/"), URIBaseID: "IMAGE_ROOT"},
+			}}},
+		})
+	}
+
+	return run
+}
+
+// sarifRuleLocations returns the location of the rule results (code scanning requires one for every result): the CI
+// config file, or the root of the image if the config was not read from a file.
+func (ci *CiEvaluator) sarifRuleLocations() []sarifLocation {
+	artifact := sarifArtifactLocation{URI: filepath.ToSlash(ci.configFile)}
+	if ci.configFile == "" {
+		artifact = sarifArtifactLocation{URI: ".", URIBaseID: "IMAGE_ROOT"}
+	}
+	return []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: artifact}}}
+}
+
+func marshalSarifRuns(runs []sarifRun) ([]byte, error) {
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    runs,
+	}, "", "  ")
+}
+
+// githubReport renders GitHub Actions workflow commands, which are shown as annotations on the workflow run.
+func (ci *CiEvaluator) githubReport() []byte {
+	return []byte(ci.githubCommands(""))
+}
+
+// githubCommands renders the workflow commands, the suffix is appended to the title of each annotation.
+func (ci *CiEvaluator) githubCommands(titleSuffix string) string {
+	var report strings.Builder
+	for _, name := range ci.SortedRules() {
+		result := ci.Results[name]
+		var command string
+		switch result.status {
+		case RuleFailed, RuleMisconfigured:
+			command = "error"
+		case RuleWarning:
+			command = "warning"
+		default:
+			continue
+		}
+		report.WriteString(fmt.Sprintf("::%s title=dive %s%s::%s\n", command, name, titleSuffix, escapeGithubData(result.message)))
+	}
+	for _, file := range ci.InefficientFiles {
+		report.WriteString(fmt.Sprintf("::notice title=dive %s%s::%s\n", inefficientFileRule, titleSuffix, escapeGithubData(inefficientFileMessage(file))))
+	}
+	return report.String()
+}
+
+// escapeGithubData escapes the message of a workflow command.
+func escapeGithubData(value string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(value)
+}
+
+type jsonReport struct {
+	Image            string           `json:"image"`
+	Pass             bool             `json:"pass"`
+	Misconfigured    bool             `json:"misconfigured"`
+	Tally            jsonReportTally  `json:"tally"`
+	Rules            []jsonReportRule `json:"rules"`
+	InefficientFiles []ReferenceFile  `json:"inefficientFiles"`
+	Suppressed       *Suppressed      `json:"suppressed,omitempty"`
+}
+
+type jsonMultiReport struct {
+	Pass   bool         `json:"pass"`
+	Images []jsonReport `json:"images"`
+}
+
+type jsonReportTally struct {
+	Pass  int `json:"pass"`
+	Fail  int `json:"fail"`
+	Skip  int `json:"skip"`
+	Warn  int `json:"warn"`
+	Total int `json:"total"`
+}
+
+type jsonReportRule struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func (ci *CiEvaluator) jsonReport(imageName string) ([]byte, error) {
+	report := ci.newJSONReport(imageName)
+	return json.MarshalIndent(&report, "", "  ")
+}
+
+func (ci *CiEvaluator) newJSONReport(imageName string) jsonReport {
+	report := jsonReport{
+		Image:         imageName,
+		Pass:          ci.Pass,
+		Misconfigured: ci.Misconfigured,
+		Tally: jsonReportTally{
+			Pass:  ci.Tally.Pass,
+			Fail:  ci.Tally.Fail,
+			Skip:  ci.Tally.Skip,
+			Warn:  ci.Tally.Warn,
+			Total: ci.Tally.Total,
+		},
+		Rules:            make([]jsonReportRule, 0),
+		InefficientFiles: make([]ReferenceFile, 0),
+	}
+	for _, name := range ci.SortedRules() {
+		result := ci.Results[name]
+		report.Rules = append(report.Rules, jsonReportRule{Name: name, Status: result.status.Name(), Message: result.message})
+	}
+	report.InefficientFiles = append(report.InefficientFiles, ci.InefficientFiles...)
+	if len(ci.IgnoreEntries) > 0 {
+		report.Suppressed = &ci.Suppressed
+	}
+	return report
+}
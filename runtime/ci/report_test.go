@@ -0,0 +1,189 @@
+package ci
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func evaluateTestImage(t *testing.T) *CiEvaluator {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	ciConfig := viper.New()
+	ciConfig.Set("rules.lowestEfficiency", "0.9")
+	ciConfig.Set("rules.highestWastedBytes", "1kB")
+	ciConfig.Set("rules.highestUserWastedPercent", map[string]interface{}{"warn": "0.1", "fail": "disabled"})
+	ciConfig.Set("rules.highestImageSize", "disabled")
+	ciConfig.Set("rules.highestLayerCount", "1")
+
+	evaluator := NewCiEvaluator(ciConfig)
+	evaluator.Evaluate(result)
+	return evaluator
+}
+
+func Test_FormatReportJUnit(t *testing.T) {
+	evaluator := evaluateTestImage(t)
+
+	report, err := evaluator.FormatReport("junit", "dive-example")
+	if err != nil {
+		t.Fatalf("unable to render report: %v", err)
+	}
+
+	var suites junitTestSuites
+	if err := xml.Unmarshal(report, &suites); err != nil {
+		t.Fatalf("invalid junit report: %v\n%s", err, report)
+	}
+
+	if suites.Tests != 4 || suites.Failures != 2 || suites.Errors != 0 || suites.Skipped != 0 {
+		t.Errorf("unexpected junit counts: %+v", suites)
+	}
+	if len(suites.Suites) != 1 || len(suites.Suites[0].TestCases) != 4 {
+		t.Fatalf("unexpected junit test cases: %+v", suites.Suites)
+	}
+	failure := suites.Suites[0].TestCases[0].Failure
+	if failure == nil || failure.Message != "too many layers (layer-count=14 > threshold=1)" {
+		t.Errorf("unexpected highestLayerCount failure: %+v", failure)
+	}
+	if !strings.Contains(suites.Suites[0].SystemOut, "/root/saved.txt wastes 13 kB (2 copies across layers)") {
+		t.Errorf("expected the inefficient files in the output, got %q", suites.Suites[0].SystemOut)
+	}
+}
+
+func Test_FormatReportSarif(t *testing.T) {
+	evaluator := evaluateTestImage(t)
+
+	report, err := evaluator.FormatReport("sarif", "dive-example")
+	if err != nil {
+		t.Fatalf("unable to render report: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(report, &log); err != nil {
+		t.Fatalf("invalid sarif report: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("unexpected sarif log: %+v", log)
+	}
+
+	levels := make(map[string]string)
+	var locations []string
+	for _, result := range log.Runs[0].Results {
+		if result.RuleID == inefficientFileRule {
+			locations = append(locations, result.Locations[0].PhysicalLocation.ArtifactLocation.URI)
+			continue
+		}
+		levels[result.RuleID] = result.Level
+	}
+
+	expectedLevels := map[string]string{"highestLayerCount": "error", "highestUserWastedPercent": "warning", "highestWastedBytes": "error", "lowestEfficiency": "none"}
+	for rule, level := range expectedLevels {
+		if levels[rule] != level {
+			t.Errorf("%s: expected level '%s', got '%s'", rule, level, levels[rule])
+		}
+	}
+	if strings.Join(locations, ",") != "root/saved.txt,root/example/somefile1.txt,root/example/somefile3.txt" {
+		t.Errorf("unexpected inefficient file locations: %v", locations)
+	}
+}
+
+func Test_FormatReportSarifMisconfigured(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	ciConfig := viper.New()
+	ciConfig.SetConfigFile("ci/.dive-ci")
+	ciConfig.Set("rules.lowestEfficiency", "1.1")
+	ciConfig.Set("rules.highestWastedBytes", "disabled")
+	ciConfig.Set("rules.highestUserWastedPercent", "0.9")
+
+	evaluator := NewCiEvaluator(ciConfig)
+	evaluator.Evaluate(result)
+
+	report, err := evaluator.FormatReport("sarif", "dive-example")
+	if err != nil {
+		t.Fatalf("unable to render report: %v", err)
+	}
+
+	var log sarifLog
+	if err := json.Unmarshal(report, &log); err != nil {
+		t.Fatalf("invalid sarif report: %v", err)
+	}
+
+	results := make(map[string]sarifResult)
+	for _, result := range log.Runs[0].Results {
+		if len(result.Locations) != 1 {
+			t.Errorf("%s: expected a location, got %+v", result.RuleID, result.Locations)
+		} else if result.RuleID != inefficientFileRule && result.Locations[0].PhysicalLocation.ArtifactLocation.URI != "ci/.dive-ci" {
+			t.Errorf("%s: expected the CI config location, got %+v", result.RuleID, result.Locations[0])
+		}
+		results[result.RuleID] = result
+	}
+
+	if result := results["lowestEfficiency"]; result.Kind != "fail" || result.Level != "error" {
+		t.Errorf("expected the misconfigured rule to fail: %+v", result)
+	}
+	for _, rule := range []string{"highestWastedBytes", "highestUserWastedPercent"} {
+		if result := results[rule]; result.Kind != "notApplicable" || result.Level != "none" {
+			t.Errorf("%s: expected the rule to be not applicable: %+v", rule, result)
+		}
+	}
+	if message := results["highestUserWastedPercent"].Message.Text; message != "highestUserWastedPercent: not evaluated (dive-example)" {
+		t.Errorf("unexpected message of the unevaluated rule: %q", message)
+	}
+}
+
+func Test_FormatReportGithub(t *testing.T) {
+	evaluator := evaluateTestImage(t)
+
+	report, err := evaluator.FormatReport("github", "dive-example")
+	if err != nil {
+		t.Fatalf("unable to render report: %v", err)
+	}
+
+	expected := `::error title=dive highestLayerCount::too many layers (layer-count=14 > threshold=1)
+::warning title=dive highestUserWastedPercent::too many bytes wasted, relative to the user bytes added (%25-user-wasted-bytes=0.4834911001404049 > threshold=0.1)
+::error title=dive highestWastedBytes::too many bytes wasted (wasted-bytes=32025 > threshold=1000)
+::notice title=dive inefficientFile::/root/saved.txt wastes 13 kB (2 copies across layers)
+::notice title=dive inefficientFile::/root/example/somefile1.txt wastes 13 kB (2 copies across layers)
+::notice title=dive inefficientFile::/root/example/somefile3.txt wastes 6.4 kB (2 copies across layers)
+`
+	if string(report) != expected {
+		t.Errorf("unexpected github report:\n%s", report)
+	}
+}
+
+func Test_FormatReportJSON(t *testing.T) {
+	evaluator := evaluateTestImage(t)
+
+	report, err := evaluator.FormatReport("json", "dive-example")
+	if err != nil {
+		t.Fatalf("unable to render report: %v", err)
+	}
+
+	var actual jsonReport
+	if err := json.Unmarshal(report, &actual); err != nil {
+		t.Fatalf("invalid json report: %v", err)
+	}
+
+	if actual.Image != "dive-example" || actual.Pass || actual.Tally.Fail != 2 || actual.Tally.Warn != 1 || actual.Tally.Pass != 1 {
+		t.Errorf("unexpected json report: %+v", actual)
+	}
+	if len(actual.Rules) != 4 || actual.Rules[0].Name != "highestLayerCount" || actual.Rules[0].Status != "fail" {
+		t.Errorf("unexpected json rules: %+v", actual.Rules)
+	}
+	if len(actual.InefficientFiles) != 3 {
+		t.Errorf("expected 3 inefficient files, got %d", len(actual.InefficientFiles))
+	}
+}
+
+func Test_FormatReportUnsupported(t *testing.T) {
+	evaluator := evaluateTestImage(t)
+
+	if _, err := evaluator.FormatReport("html", "dive-example"); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
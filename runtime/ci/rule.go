@@ -21,6 +21,18 @@ const (
 	RuleConfigured
 )
 
+// DefaultRuleConfigs are the configurations of the threshold rules of "dive --ci" when they are not given (by a flag or
+// by the "rules" section of the CI config).
+var DefaultRuleConfigs = map[string]string{
+	"lowestEfficiency":         "0.9",
+	"highestWastedBytes":       "disabled",
+	"highestUserWastedPercent": "0.1",
+	"highestImageSize":         "disabled",
+	"highestLayerSize":         "disabled",
+	"highestLayerCount":        "disabled",
+	"highestFileSize":          "disabled",
+}
+
 type CiRule interface {
 	Key() string
 	Configuration() string
@@ -30,7 +42,7 @@ type CiRule interface {
 
 type GenericCiRule struct {
 	key             string
-	configValue     string
+	thresholds      ruleThresholds
 	configValidator func(string) error
 	evaluator       func(*image.AnalysisResult, string) (RuleStatus, string)
 }
@@ -42,10 +54,20 @@ type RuleResult struct {
 	message string
 }
 
-func newGenericCiRule(key string, configValue string, validator func(string) error, evaluator func(*image.AnalysisResult, string) (RuleStatus, string)) *GenericCiRule {
+// Status returns the outcome of the rule.
+func (result RuleResult) Status() RuleStatus {
+	return result.status
+}
+
+// Message explains the status of the rule (e.g. why the rule failed).
+func (result RuleResult) Message() string {
+	return result.message
+}
+
+func newGenericCiRule(key string, thresholds ruleThresholds, validator func(string) error, evaluator func(*image.AnalysisResult, string) (RuleStatus, string)) *GenericCiRule {
 	return &GenericCiRule{
 		key:             key,
-		configValue:     configValue,
+		thresholds:      thresholds,
 		configValidator: validator,
 		evaluator:       evaluator,
 	}
@@ -55,16 +77,27 @@ func (rule *GenericCiRule) Key() string {
 	return rule.key
 }
 
+func (rule *GenericCiRule) configKeys() map[string][]string {
+	return map[string][]string{rule.key: thresholdRuleKeys}
+}
+
 func (rule *GenericCiRule) Configuration() string {
-	return rule.configValue
+	return rule.thresholds.fail
+}
+
+// WarnConfiguration returns the threshold at which the rule results in a warning ("" when there is none).
+func (rule *GenericCiRule) WarnConfiguration() string {
+	return rule.thresholds.warn
 }
 
 func (rule *GenericCiRule) Validate() error {
-	return rule.configValidator(rule.configValue)
+	return rule.thresholds.validate(rule.configValidator)
 }
 
 func (rule *GenericCiRule) Evaluate(result *image.AnalysisResult) (RuleStatus, string) {
-	return rule.evaluator(result, rule.configValue)
+	return rule.thresholds.evaluate(func(value string) (RuleStatus, string) {
+		return rule.evaluator(result, value)
+	})
 }
 
 func (status RuleStatus) String() string {
@@ -86,12 +119,14 @@ func (status RuleStatus) String() string {
 	}
 }
 
-func loadCiRules(config *viper.Viper) []CiRule {
+// loadCiRules returns the default rules and the rules that are configured, or every built-in rule when all is given
+// (see knownRuleKeys).
+func loadCiRules(config *viper.Viper, stack *stackedTreeCache, all bool) []CiRule {
 	var rules = make([]CiRule, 0)
 	var ruleKey = "lowestEfficiency"
 	rules = append(rules, newGenericCiRule(
 		ruleKey,
-		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		loadRuleThresholds(config, ruleKey),
 		func(value string) error {
 			lowestEfficiency, err := strconv.ParseFloat(value, 64)
 			if err != nil {
@@ -117,7 +152,7 @@ func loadCiRules(config *viper.Viper) []CiRule {
 	ruleKey = "highestWastedBytes"
 	rules = append(rules, newGenericCiRule(
 		ruleKey,
-		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		loadRuleThresholds(config, ruleKey),
 		func(value string) error {
 			_, err := humanize.ParseBytes(value)
 			if err != nil {
@@ -140,7 +175,7 @@ func loadCiRules(config *viper.Viper) []CiRule {
 	ruleKey = "highestUserWastedPercent"
 	rules = append(rules, newGenericCiRule(
 		ruleKey,
-		config.GetString(fmt.Sprintf("rules.%s", ruleKey)),
+		loadRuleThresholds(config, ruleKey),
 		func(value string) error {
 			highestUserWastedPercent, err := strconv.ParseFloat(value, 64)
 			if err != nil {
@@ -164,5 +199,16 @@ func loadCiRules(config *viper.Viper) []CiRule {
 		},
 	))
 
+	rules = append(rules, loadSizeCiRules(config, stack, all)...)
+	rules = append(rules, loadPathCiRules(config, stack, all)...)
+	rules = append(rules, loadAuditCiRules(config, stack, all)...)
+	rules = append(rules, loadBaselineCiRules(config, all)...)
+
+	keys := make(map[string]bool)
+	for _, rule := range rules {
+		keys[rule.Key()] = true
+	}
+	rules = append(rules, loadCustomCiRules(config, keys, stack)...)
+
 	return rules
 }
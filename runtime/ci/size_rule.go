@@ -0,0 +1,130 @@
+package ci
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+// loadSizeCiRules returns the absolute size and layer count rules that are configured (these are never enabled by
+// default), or all of them.
+func loadSizeCiRules(config *viper.Viper, stack *stackedTreeCache, all bool) []CiRule {
+	var rules = make([]CiRule, 0)
+
+	validateBytes := func(value string) error {
+		_, err := humanize.ParseBytes(value)
+		if err != nil {
+			return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+		}
+		return nil
+	}
+
+	var ruleKey = "highestImageSize"
+	if thresholds := loadRuleThresholds(config, ruleKey); all || thresholds.isEnabled() {
+		rules = append(rules, newGenericCiRule(
+			ruleKey,
+			thresholds,
+			validateBytes,
+			func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+				highestImageSize, err := humanize.ParseBytes(value)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+				}
+				if analysis.SizeBytes > highestImageSize {
+					return RuleFailed, fmt.Sprintf("image is too large (image-size=%v > threshold=%v)", analysis.SizeBytes, highestImageSize)
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	ruleKey = "highestLayerSize"
+	if thresholds := loadRuleThresholds(config, ruleKey); all || thresholds.isEnabled() {
+		rules = append(rules, newGenericCiRule(
+			ruleKey,
+			thresholds,
+			validateBytes,
+			func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+				highestLayerSize, err := humanize.ParseBytes(value)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+				}
+				var largest *image.Layer
+				for _, layer := range analysis.Layers {
+					if largest == nil || layer.Size > largest.Size {
+						largest = layer
+					}
+				}
+				if largest != nil && largest.Size > highestLayerSize {
+					return RuleFailed, fmt.Sprintf("layer %d is too large (layer-size=%v > threshold=%v): '%s'", largest.Index, largest.Size, highestLayerSize, strings.TrimSpace(largest.Command))
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	ruleKey = "highestLayerCount"
+	if thresholds := loadRuleThresholds(config, ruleKey); all || thresholds.isEnabled() {
+		rules = append(rules, newGenericCiRule(
+			ruleKey,
+			thresholds,
+			func(value string) error {
+				highestLayerCount, err := strconv.Atoi(value)
+				if err != nil {
+					return fmt.Errorf("invalid config value ('%v'): %v", value, err)
+				}
+				if highestLayerCount < 0 {
+					return fmt.Errorf("highestLayerCount config value must not be negative, given '%s'", value)
+				}
+				return nil
+			},
+			func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+				highestLayerCount, err := strconv.Atoi(value)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+				}
+				if len(analysis.Layers) > highestLayerCount {
+					return RuleFailed, fmt.Sprintf("too many layers (layer-count=%d > threshold=%d)", len(analysis.Layers), highestLayerCount)
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	ruleKey = "highestFileSize"
+	if thresholds := loadRuleThresholds(config, ruleKey); all || thresholds.isEnabled() {
+		rules = append(rules, newGenericCiRule(
+			ruleKey,
+			thresholds,
+			validateBytes,
+			func(analysis *image.AnalysisResult, value string) (RuleStatus, string) {
+				highestFileSize, err := humanize.ParseBytes(value)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("invalid config value ('%v'): %v", value, err)
+				}
+				tree, err := stack.get(analysis)
+				if err != nil {
+					return RuleFailed, fmt.Sprintf("unable to stack the image layers: %v", err)
+				}
+				var largest *filetree.FileNode
+				_ = tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+					if !node.Data.FileInfo.IsDir && (largest == nil || node.Data.FileInfo.Size > largest.Data.FileInfo.Size) {
+						largest = node
+					}
+					return nil
+				}, nil)
+				if largest != nil && largest.Data.FileInfo.Size > int64(highestFileSize) {
+					return RuleFailed, fmt.Sprintf("file is too large (file-size=%v > threshold=%v): '%s'", largest.Data.FileInfo.Size, highestFileSize, largest.Path())
+				}
+				return RulePassed, ""
+			},
+		))
+	}
+
+	return rules
+}
@@ -0,0 +1,104 @@
+package ci
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	severityFail = "fail"
+	severityWarn = "warn"
+)
+
+// ruleThresholds are the configured values of a threshold rule. A rule is either configured with a single (fail)
+// value, or with a map that has a "fail" and/or a "warn" value (the fail value defaults to the flag or default value of
+// the rule):
+//
+//	lowestEfficiency:
+//	  warn: 0.95
+//	  fail: 0.9
+type ruleThresholds struct {
+	fail string
+	warn string
+}
+
+func loadRuleThresholds(config *viper.Viper, key string) ruleThresholds {
+	configKey := fmt.Sprintf("rules.%s", key)
+	if !config.IsSet(configKey+".fail") && !config.IsSet(configKey+".warn") && !config.IsSet(configKey+".severity") {
+		return ruleThresholds{fail: config.GetString(configKey)}
+	}
+
+	thresholds := ruleThresholds{
+		fail: config.GetString(configKey + ".fail"),
+		warn: config.GetString(configKey + ".warn"),
+	}
+	if thresholds.fail == "" {
+		// keep the threshold of the flag (which takes precedence over the map when given) or the default threshold
+		thresholds.fail = config.GetString(configKey)
+	}
+	if thresholds.fail == "" {
+		thresholds.fail = DefaultRuleConfigs[key]
+	}
+	if thresholds.fail == "" {
+		thresholds.fail = "disabled"
+	}
+	return thresholds
+}
+
+// isEnabled indicates if a rule that is not part of the default rule set has been configured.
+func (thresholds ruleThresholds) isEnabled() bool {
+	return isOptionalRuleEnabled(thresholds.fail) || thresholds.warn != ""
+}
+
+// validate checks every configured threshold with the given validator.
+func (thresholds ruleThresholds) validate(validator func(string) error) error {
+	if thresholds.fail != "disabled" {
+		if err := validator(thresholds.fail); err != nil {
+			return err
+		}
+	}
+	if thresholds.warn != "" {
+		if err := validator(thresholds.warn); err != nil {
+			return fmt.Errorf("warn: %v", err)
+		}
+	}
+	return nil
+}
+
+// evaluate checks the fail threshold first, then the warn threshold (a violation of which results in a warning).
+func (thresholds ruleThresholds) evaluate(evaluator func(string) (RuleStatus, string)) (RuleStatus, string) {
+	if thresholds.fail != "disabled" {
+		status, message := evaluator(thresholds.fail)
+		if status != RulePassed {
+			return status, message
+		}
+	}
+	if thresholds.warn != "" {
+		status, message := evaluator(thresholds.warn)
+		if status == RuleFailed {
+			return RuleWarning, message
+		}
+	}
+	return RulePassed, ""
+}
+
+// isOptionalRuleEnabled indicates if the value of a rule that is not part of the default rule set has been configured.
+func isOptionalRuleEnabled(value string) bool {
+	return value != "" && value != "disabled"
+}
+
+// loadRuleSeverity returns the severity override of the given rule ("" when there is none). With the "warn" severity
+// a failing rule results in a warning instead, which does not fail the CI run.
+func loadRuleSeverity(config *viper.Viper, key string) string {
+	return config.GetString(fmt.Sprintf("rules.%s.severity", key))
+}
+
+func validateSeverity(severity string) error {
+	switch severity {
+	case "", severityFail, severityWarn:
+		return nil
+	default:
+		return fmt.Errorf("invalid severity ('%v'), expected '%s' or '%s'", severity, severityWarn, severityFail)
+	}
+}
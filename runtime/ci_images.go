@@ -0,0 +1,229 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+	"github.com/wagoodman/dive/runtime/ci"
+	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/utils"
+)
+
+// layerCacheResolver is a resolver that can share parsed layers with other resolvers.
+type layerCacheResolver interface {
+	SetLayerCache(cache *docker.LayerCache)
+}
+
+// diffIDResolver is a resolver that can tell the layers of an image before fetching it.
+type diffIDResolver interface {
+	DiffIDs(id string) ([]string, error)
+}
+
+// ciImageResult is the outcome of evaluating a single image of a multi-image CI run.
+type ciImageResult struct {
+	name      string
+	evaluator *ci.CiEvaluator // nil if the image could not be evaluated
+}
+
+// runCiImages evaluates all images given in the options, each with its own (or the shared) CI config, and reports the
+// consolidated results. Layers shared between the images are only parsed once.
+func runCiImages(options Options, resolvers dive.ResolverProvider, events eventChannel, filesystem afero.Fs) {
+	defer close(events)
+
+	cache := docker.NewLayerCache()
+	resolverBySource := make(map[dive.ImageSource]image.Resolver)
+	resolverErrs := make(map[dive.ImageSource]error)
+	resolverFor := func(source dive.ImageSource) (image.Resolver, error) {
+		if resolver, exists := resolverBySource[source]; exists {
+			return resolver, resolverErrs[source]
+		}
+		resolver, err := resolvers(source)
+		if cacheResolver, ok := resolver.(layerCacheResolver); ok && err == nil {
+			cacheResolver.SetLayerCache(cache)
+		}
+		resolverBySource[source], resolverErrs[source] = resolver, err
+		return resolver, err
+	}
+
+	// the layers of every image that can be told up front are retained in the cache until the image is evaluated,
+	// all other parsed layers are released after each image
+	reserved := make([][]string, len(options.CiImages))
+	for idx, ciImage := range options.CiImages {
+		resolver, err := resolverFor(ciImage.Source)
+		if err != nil {
+			continue
+		}
+		if lister, ok := resolver.(diffIDResolver); ok {
+			if diffIDs, err := lister.DiffIDs(ciImage.Image); err == nil {
+				cache.Reserve(diffIDs)
+				reserved[idx] = diffIDs
+			}
+		}
+	}
+
+	var metrics *export.Metrics
+	if options.MetricsFile != "" {
+		metrics = export.NewMetrics(options.MetricsTopDirs)
+	}
+
+	var results []ciImageResult
+	for idx, ciImage := range options.CiImages {
+		name := ciImage.Source.String() + "://" + ciImage.Image
+		events.message(utils.TitleFormat("Image: ") + name)
+
+		resolver, err := resolverFor(ciImage.Source)
+		if err != nil {
+			events.exitWithErrorMessage("cannot determine image provider", err)
+			results = append(results, ciImageResult{name: name})
+			cache.Release(reserved[idx])
+			continue
+		}
+
+		evaluator := evaluateCiImage(ciImage, options, resolver, metrics, events, filesystem)
+		results = append(results, ciImageResult{name: name, evaluator: evaluator})
+		cache.Release(reserved[idx])
+	}
+
+	events.message(renderCiSummary(results, cache.Hits()))
+
+	pass, misconfigured := true, false
+	for _, result := range results {
+		pass = pass && result.evaluator != nil && result.evaluator.Pass
+		misconfigured = misconfigured || (result.evaluator != nil && result.evaluator.Misconfigured)
+	}
+
+	if metrics != nil && !writeMetrics(options, metrics, events, filesystem) {
+		return
+	}
+
+	if options.CiReportFormat != "" {
+		var evaluations []ci.ImageEvaluation
+		for _, result := range results {
+			if result.evaluator != nil {
+				evaluations = append(evaluations, ci.ImageEvaluation{Image: result.name, Evaluator: result.evaluator})
+			}
+		}
+		report, err := ci.FormatReports(options.CiReportFormat, evaluations)
+		if err != nil {
+			events.exitWithErrorMessage("cannot render CI report", err)
+			return
+		}
+		if !outputCiReport(options, report, events, filesystem) {
+			return
+		}
+	}
+
+	if misconfigured {
+		events.exitWithCode(ci.ExitCodeMisconfigured)
+	} else if !pass {
+		events.exitWithError(nil)
+	}
+}
+
+// evaluateCiImage fetches, analyzes, and evaluates a single image (adding it to the given metrics, if any), nil is
+// returned if the image could not be evaluated (which has been reported already).
+func evaluateCiImage(ciImage CiImage, options Options, resolver image.Resolver, metrics *export.Metrics, events eventChannel, filesystem afero.Fs) *ci.CiEvaluator {
+	config := ciImage.CiConfig
+	if config == nil {
+		config = options.CiConfig
+	}
+	if ciImage.CiConfigFile != "" {
+		events.message(fmt.Sprintf("  Using CI config: %s", ciImage.CiConfigFile))
+	}
+
+	events.fetchStarted(utils.TitleFormat("Fetching image...")+" (this can take a while for large images)", ciImage.Source, ciImage.Image)
+	reportParsedLayers(resolver, ciImage.Image, events)
+	img, err := resolver.Fetch(ciImage.Image)
+	if err != nil {
+		events.exitWithErrorMessage("cannot fetch image", err)
+		return nil
+	}
+	events.imageFetched(ciImage.Image, img)
+
+	analysis := analyzeImage(img, options, ciImage.Source, resolver, events)
+	if analysis == nil {
+		return nil
+	}
+	events.analysisComplete(ciImage.Image, analysis)
+
+	// the export is only built when needed, as it requires stacking all layers
+	var regression *export.Regression
+	baselineFile := config.GetString("baseline")
+	if metrics != nil || baselineFile != "" {
+		exp := newExport(analysis, events)
+		if exp == nil {
+			return nil
+		}
+		if metrics != nil {
+			metrics.Add(ciImage.Image, exp)
+		}
+		if baselineFile != "" && !compareToBaseline(exp, baselineFile, events, filesystem) {
+			return nil
+		}
+		regression = exp.Regression
+	}
+
+	evaluator, _ := evaluateCi(config, analysis, regression, baselineFile, events)
+	events.ciRuleResults(ciImage.Image, evaluator)
+	if options.CiReportFormat == "" || options.CiReportFile != "" {
+		events.message(evaluator.Report())
+	}
+	return evaluator
+}
+
+// renderCiSummary renders the outcome of every image of a multi-image CI run.
+func renderCiSummary(results []ciImageResult, reusedLayers int) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, utils.TitleFormat("Summary:"))
+
+	var passed, failed int
+	for _, result := range results {
+		var status string
+		switch {
+		case result.evaluator == nil:
+			status = aurora.Bold(aurora.Inverse(aurora.Red("ERROR"))).String()
+		case result.evaluator.Misconfigured:
+			status = ci.RuleStatus(ci.RuleMisconfigured).String()
+		case !result.evaluator.Pass:
+			status = ci.RuleStatus(ci.RuleFailed).String()
+		case result.evaluator.Tally.Warn > 0:
+			status = ci.RuleStatus(ci.RuleWarning).String()
+		default:
+			status = ci.RuleStatus(ci.RulePassed).String()
+		}
+		if result.evaluator != nil && result.evaluator.Pass {
+			passed++
+		} else {
+			failed++
+		}
+		fmt.Fprintf(&sb, "  %s: %s\n", status, result.name)
+	}
+	if reusedLayers > 0 {
+		fmt.Fprintf(&sb, "  (reused %d already parsed layers)\n", reusedLayers)
+	}
+
+	status := "PASS"
+	if failed > 0 {
+		status = "FAIL"
+	}
+	summary := fmt.Sprintf("Result:%s [Images:%d] [Passed:%d] [Failed:%d]", status, len(results), passed, failed)
+	if failed == 0 {
+		fmt.Fprint(&sb, aurora.Green(summary))
+	} else {
+		fmt.Fprint(&sb, aurora.Red(summary))
+	}
+	return sb.String()
+}
+
+// RunCiImages evaluates several images in a single CI run (see Options.CiImages).
+func RunCiImages(options Options) {
+	var events = make(eventChannel)
+	go runCiImages(options, dive.GetImageResolver, events, afero.NewOsFs())
+	os.Exit(consumeEvents(events, newEventPrinter(options.Output, options.writesToStdout())))
+}
@@ -0,0 +1,127 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lunixbochs/vtclean"
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func TestRunCiImages(t *testing.T) {
+	lenientConfig := viper.New()
+	lenientConfig.Set("rules.lowestEfficiency", "0.9")
+	lenientConfig.Set("rules.highestWastedBytes", "disabled")
+	lenientConfig.Set("rules.highestUserWastedPercent", "disabled")
+
+	strictConfig := viper.New()
+	strictConfig.Set("rules.lowestEfficiency", "0.999")
+	strictConfig.Set("rules.highestWastedBytes", "disabled")
+	strictConfig.Set("rules.highestUserWastedPercent", "disabled")
+
+	archiveResolver := func(source dive.ImageSource) (image.Resolver, error) {
+		return docker.NewResolverFromArchive(), nil
+	}
+	failedResolver := func(source dive.ImageSource) (image.Resolver, error) {
+		return nil, fmt.Errorf("no resolver")
+	}
+
+	table := map[string]struct {
+		images          []CiImage
+		resolvers       dive.ResolverProvider
+		reportFormat    string
+		metricsFile     string
+		expectedSummary []string
+		expectedMetrics []string
+		expectedExit    bool
+	}{
+		"all-pass": {
+			images: []CiImage{
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive},
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive},
+			},
+			resolvers:       archiveResolver,
+			expectedSummary: []string{"PASS: docker-archive://../.data/test-docker-image.tar", "(reused 14 already parsed layers)", "Result:PASS [Images:2] [Passed:2] [Failed:0]"},
+		},
+		"per-image-config": {
+			images: []CiImage{
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive},
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive, CiConfig: strictConfig},
+			},
+			resolvers:       archiveResolver,
+			expectedSummary: []string{"FAIL: docker-archive://../.data/test-docker-image.tar", "Result:FAIL [Images:2] [Passed:1] [Failed:1]"},
+			expectedExit:    true,
+		},
+		"unresolvable": {
+			images: []CiImage{
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive},
+			},
+			resolvers:       failedResolver,
+			expectedSummary: []string{"ERROR: docker-archive://../.data/test-docker-image.tar", "Result:FAIL [Images:1] [Passed:0] [Failed:1]"},
+			expectedExit:    true,
+		},
+		"junit-report": {
+			images: []CiImage{
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive},
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive, CiConfig: strictConfig},
+			},
+			resolvers:       archiveResolver,
+			reportFormat:    "junit",
+			expectedSummary: []string{`<testsuites name="dive" tests="6" failures="1" errors="0" skipped="4">`},
+			expectedExit:    true,
+		},
+		"metrics": {
+			images: []CiImage{
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive},
+				{Image: "../.data/test-docker-image.tar", Source: dive.SourceDockerArchive, CiConfig: strictConfig},
+			},
+			resolvers:       archiveResolver,
+			metricsFile:     "dive.prom",
+			expectedSummary: []string{"Writing metrics to 'dive.prom'...", "Result:FAIL [Images:2] [Passed:1] [Failed:1]"},
+			expectedMetrics: []string{
+				"# TYPE dive_image_layer_count gauge\ndive_image_layer_count{image=\"../.data/test-docker-image.tar\"} 14\n# HELP",
+			},
+			expectedExit: true,
+		},
+	}
+
+	for name, test := range table {
+		var ec = make(eventChannel)
+		var filesystem = afero.NewMemMapFs()
+		options := Options{Ci: true, CiConfig: lenientConfig, CiImages: test.images, CiReportFormat: test.reportFormat, MetricsFile: test.metricsFile}
+
+		go runCiImages(options, test.resolvers, ec, filesystem)
+
+		var stdout strings.Builder
+		var exitWithError bool
+		for event := range ec {
+			stdout.WriteString(vtclean.Clean(event.stdout, false) + "\n")
+			exitWithError = exitWithError || event.errorOnExit
+		}
+
+		for _, expected := range test.expectedSummary {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("%s.%s: expected output to contain '%s', got:\n%s", t.Name(), name, expected, stdout.String())
+			}
+		}
+		if test.metricsFile != "" {
+			metrics, err := afero.ReadFile(filesystem, test.metricsFile)
+			if err != nil {
+				t.Fatalf("%s.%s: unable to read metrics: %v", t.Name(), name, err)
+			}
+			for _, expected := range test.expectedMetrics {
+				if !strings.Contains(string(metrics), expected) {
+					t.Errorf("%s.%s: expected metrics to contain '%s', got:\n%s", t.Name(), name, expected, metrics)
+				}
+			}
+		}
+		if exitWithError != test.expectedExit {
+			t.Errorf("%s.%s: expected errorOnExit=%v, got %v", t.Name(), name, test.expectedExit, exitWithError)
+		}
+	}
+}
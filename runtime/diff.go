@@ -0,0 +1,254 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/runtime/ui"
+	"github.com/wagoodman/dive/utils"
+)
+
+type DiffOptions struct {
+	Before       string
+	BeforeSource dive.ImageSource
+	After        string
+	AfterSource  dive.ImageSource
+	IgnoreErrors bool
+	ExportFile   string
+	Summary      bool
+	Depth        int
+}
+
+func runDiff(enableUi bool, options DiffOptions, beforeResolver, afterResolver image.Resolver, events eventChannel, filesystem afero.Fs) {
+	defer close(events)
+
+	before := fetchDiffImage(options.Before, options.BeforeSource, beforeResolver, events)
+	if before == nil {
+		return
+	}
+
+	after := fetchDiffImage(options.After, options.AfterSource, afterResolver, events)
+	if after == nil {
+		return
+	}
+
+	events.message(utils.TitleFormat("Comparing images..."))
+	diff, pathErrors, err := image.Compare(before, after)
+	if err != nil {
+		events.exitWithErrorMessage("cannot compare images", err)
+		return
+	}
+	if len(pathErrors) > 0 {
+		for _, pathErr := range pathErrors {
+			events.message("  " + pathErr.String())
+		}
+		if !options.IgnoreErrors {
+			events.exitWithError(fmt.Errorf("file tree has path errors (use '--ignore-errors' to attempt to continue)"))
+			return
+		}
+	}
+
+	if options.ExportFile != "" {
+		events.message(utils.TitleFormat(fmt.Sprintf("Exporting image diff to %s...", outputName(options.ExportFile))))
+		bytes, err := export.NewDiffExport(options.Before, options.After, diff, options.Depth).Marshal()
+		if err != nil {
+			events.exitWithErrorMessage("cannot marshal export payload", err)
+			return
+		}
+
+		if err := writeOutput(options.ExportFile, bytes, events, filesystem); err != nil {
+			events.exitWithErrorMessage("cannot write to export file", err)
+		}
+		return
+	}
+
+	if options.Summary {
+		events.message(renderDiffSummary(options, diff))
+		return
+	}
+
+	if enableUi {
+		analysis, err := diffAnalysis(options, diff)
+		if err != nil {
+			events.exitWithErrorMessage("cannot analyze image", err)
+			return
+		}
+
+		treeStack := filetree.NewComparer(analysis.RefTrees)
+		errors := treeStack.BuildCache()
+		if errors != nil && !options.IgnoreErrors {
+			for _, err := range errors {
+				events.message("  " + err.Error())
+			}
+			events.exitWithError(fmt.Errorf("file tree has path errors (use '--ignore-errors' to attempt to continue)"))
+			return
+		}
+
+		// see the note in run() regarding the termbox startup race
+		time.Sleep(100 * time.Millisecond)
+
+		err = ui.Run(fmt.Sprintf("%s → %s", options.Before, options.After), analysis, treeStack)
+		if err != nil {
+			events.exitWithError(err)
+			return
+		}
+	}
+}
+
+// fetchDiffImage fetches one side of the comparison, reporting any failure (in which case nil is returned).
+func fetchDiffImage(name string, source dive.ImageSource, resolver image.Resolver, events eventChannel) *image.Image {
+	events.message(utils.TitleFormat("Image Source: ") + source.String() + "://" + name)
+	events.fetchStarted(utils.TitleFormat("Fetching image...")+" (this can take a while for large images)", source, name)
+	reportParsedLayers(resolver, name, events)
+	img, err := resolver.Fetch(name)
+	if err != nil {
+		events.exitWithErrorMessage(fmt.Sprintf("cannot fetch image '%s'", name), err)
+		return nil
+	}
+	return img
+}
+
+// diffAnalysis presents the image comparison as a two layer image: the complete filesystem of the before image,
+// followed by a single layer with all changes found in the after image. This way the usual layer-by-layer views show
+// the differences between both images. The image statistics describe these two layers: the size of the second layer
+// is the size of the changed files, and the wasted space is the space of the before image that is replaced or removed.
+func diffAnalysis(options DiffOptions, diff *image.Diff) (*image.AnalysisResult, error) {
+	changedFiles, changedSize, err := changedFilesTree(diff)
+	if err != nil {
+		return nil, err
+	}
+
+	var beforeSize uint64
+	for _, layer := range diff.Before.Layers {
+		beforeSize += layer.Size
+	}
+
+	layers := []*image.Layer{
+		{
+			Index:   0,
+			Command: fmt.Sprintf("%s (%d layers)", options.Before, len(diff.Before.Layers)),
+			Size:    beforeSize,
+			Tree:    diff.BeforeTree,
+			Names:   []string{options.Before},
+		},
+		{
+			Index:   1,
+			Command: fmt.Sprintf("%s (%d layers, %d shared)", options.After, len(diff.After.Layers), diff.SharedLayers),
+			Size:    changedSize,
+			Tree:    diff.ChangeTree,
+			Names:   []string{options.After},
+		},
+	}
+
+	// the statistics only consider the changed files (the change tree holds every file of the after image)
+	pseudoImage := image.Image{
+		Trees:  []*filetree.FileTree{diff.BeforeTree, changedFiles},
+		Layers: layers,
+	}
+	analysis, err := pseudoImage.Analyze()
+	if err != nil {
+		return nil, err
+	}
+	analysis.RefTrees = []*filetree.FileTree{diff.BeforeTree, diff.ChangeTree}
+	return analysis, nil
+}
+
+// changedFilesTree returns a copy of the change tree of the diff without the files that are unmodified relative to the
+// before image, along with the size of the remaining (added and modified) files.
+func changedFilesTree(diff *image.Diff) (*filetree.FileTree, uint64, error) {
+	tree := diff.ChangeTree.Copy()
+
+	var unmodified []string
+	var size uint64
+	visitor := func(node *filetree.FileNode) error {
+		if node.IsWhiteout() || node.Data.FileInfo.IsDir {
+			return nil
+		}
+		beforeNode, _ := diff.BeforeTree.GetNode(node.Path())
+		if beforeNode != nil && beforeNode.Data.FileInfo.Compare(node.Data.FileInfo) == filetree.Unmodified {
+			unmodified = append(unmodified, node.Path())
+			return nil
+		}
+		size += uint64(node.Data.FileInfo.Size)
+		return nil
+	}
+	if err := tree.VisitDepthChildFirst(visitor, nil); err != nil {
+		return nil, 0, err
+	}
+
+	for _, path := range unmodified {
+		if err := tree.RemovePath(path); err != nil {
+			return nil, 0, err
+		}
+	}
+	return tree, size, nil
+}
+
+// renderDiffSummary reports the number of changed files and the size changes of each directory.
+func renderDiffSummary(options DiffOptions, diff *image.Diff) string {
+	var beforeSize, afterSize int64
+	for _, layer := range diff.Before.Layers {
+		beforeSize += int64(layer.Size)
+	}
+	for _, layer := range diff.After.Layers {
+		afterSize += int64(layer.Size)
+	}
+	changes := diff.FileChanges()
+
+	var report strings.Builder
+	report.WriteString(utils.TitleFormat("Image Diff:") + "\n")
+	report.WriteString(fmt.Sprintf("  before: %s (%d layers, %s)\n", options.Before, len(diff.Before.Layers), humanize.Bytes(uint64(beforeSize))))
+	report.WriteString(fmt.Sprintf("  after: %s (%d layers, %s)\n", options.After, len(diff.After.Layers), humanize.Bytes(uint64(afterSize))))
+	report.WriteString(fmt.Sprintf("  sharedLayers: %d\n", diff.SharedLayers))
+	report.WriteString(fmt.Sprintf("  sizeDelta: %s\n", signedBytes(afterSize-beforeSize)))
+	report.WriteString(fmt.Sprintf("  files: %d added, %d removed, %d modified\n", changes.Added, changes.Removed, changes.Modified))
+
+	report.WriteString(utils.TitleFormat("Size Changes:") + "\n")
+	template := "%12s  %12s  %12s  %s\n"
+	report.WriteString(fmt.Sprintf(template, "Before", "After", "Delta", "Path"))
+	deltas := diff.DirectoryDeltas(options.Depth)
+	if len(deltas) == 0 {
+		report.WriteString("None\n")
+	}
+	for _, delta := range deltas {
+		report.WriteString(fmt.Sprintf(template,
+			humanize.Bytes(uint64(delta.BeforeSize)),
+			humanize.Bytes(uint64(delta.AfterSize)),
+			signedBytes(delta.Delta()),
+			delta.Path,
+		))
+	}
+	return report.String()
+}
+
+// signedBytes renders the given size difference in human readable form, always including the sign.
+func signedBytes(delta int64) string {
+	if delta < 0 {
+		return "-" + humanize.Bytes(uint64(-delta))
+	}
+	return "+" + humanize.Bytes(uint64(delta))
+}
+
+func RunDiff(options DiffOptions) {
+	beforeResolver, err := dive.GetImageResolver(options.BeforeSource)
+	if err != nil {
+		exitWithResolverError(err)
+	}
+
+	afterResolver, err := dive.GetImageResolver(options.AfterSource)
+	if err != nil {
+		exitWithResolverError(err)
+	}
+
+	var events = make(eventChannel)
+	go runDiff(true, options, beforeResolver, afterResolver, events, afero.NewOsFs())
+	os.Exit(consumeEvents(events, newEventPrinter(OutputText, options.ExportFile == StdoutPath)))
+}
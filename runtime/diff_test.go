@@ -0,0 +1,144 @@
+package runtime
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/lunixbochs/vtclean"
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+)
+
+func TestRunDiff(t *testing.T) {
+	table := map[string]struct {
+		beforeResolver image.Resolver
+		afterResolver  image.Resolver
+		options        DiffOptions
+		events         []testEvent
+	}{
+		"export-case": {
+			beforeResolver: &defaultResolver{},
+			afterResolver:  &defaultResolver{},
+			options: DiffOptions{
+				Before:       "before",
+				BeforeSource: dive.SourceDockerEngine,
+				After:        "after",
+				AfterSource:  dive.SourceDockerArchive,
+				ExportFile:   "diff.json",
+				Depth:        2,
+			},
+			events: []testEvent{
+				{stdout: "Image Source: docker://before", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Image Source: docker-archive://after", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Comparing images...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Exporting image diff to 'diff.json'...", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
+		"failed-fetch": {
+			beforeResolver: &defaultResolver{},
+			afterResolver:  &failedFetchResolver{},
+			options: DiffOptions{
+				Before:       "before",
+				BeforeSource: dive.SourceDockerEngine,
+				After:        "after",
+				AfterSource:  dive.SourceDockerEngine,
+				Summary:      true,
+			},
+			events: []testEvent{
+				{stdout: "Image Source: docker://before", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Image Source: docker://after", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "cannot fetch image 'after'", errorOnExit: true, errMessage: "some fetch failure"},
+			},
+		},
+	}
+
+	for name, test := range table {
+		var ec = make(eventChannel)
+		var events = make([]testEvent, 0)
+		var filesystem = afero.NewMemMapFs()
+
+		go runDiff(false, test.options, test.beforeResolver, test.afterResolver, ec, filesystem)
+
+		for event := range ec {
+			// the events without a text form are covered by TestRunTypedEvents
+			if event.payload != nil && event.stdout == "" {
+				continue
+			}
+			events = append(events, newTestEvent(event))
+		}
+
+		if len(test.events) != len(events) {
+			t.Fatalf("%s.%s: expected # events='%v', got '%v'", t.Name(), name, len(test.events), len(events))
+		}
+
+		for idx, actualEvent := range events {
+			actualEvent.stdout = vtclean.Clean(actualEvent.stdout, false)
+			actualEvent.stderr = vtclean.Clean(actualEvent.stderr, false)
+
+			if test.events[idx] != actualEvent {
+				t.Errorf("%s.%s: expected event %+v, got %+v", t.Name(), name, test.events[idx], actualEvent)
+			}
+		}
+
+		if test.options.ExportFile != "" {
+			contents, err := afero.ReadFile(filesystem, test.options.ExportFile)
+			if err != nil {
+				t.Fatalf("%s.%s: expected export file: %v", t.Name(), name, err)
+			}
+
+			var payload map[string]interface{}
+			if err := json.Unmarshal(contents, &payload); err != nil {
+				t.Fatalf("%s.%s: invalid export payload: %v", t.Name(), name, err)
+			}
+			if payload["sharedLayers"] != float64(14) {
+				t.Errorf("%s.%s: expected 14 shared layers, got %v", t.Name(), name, payload["sharedLayers"])
+			}
+		}
+	}
+}
+
+func TestDiffAnalysis(t *testing.T) {
+	img, err := (&defaultResolver{}).Fetch("")
+	if err != nil {
+		t.Fatalf("unable to fetch the test image: %v", err)
+	}
+	before := &image.Image{Trees: img.Trees[:5], Layers: img.Layers[:5]}
+
+	table := map[string]struct {
+		before  *image.Image
+		changed bool
+	}{
+		"same-image":      {before: img, changed: false},
+		"appended-layers": {before: before, changed: true},
+	}
+
+	for name, test := range table {
+		diff, _, err := image.Compare(test.before, img)
+		if err != nil {
+			t.Fatalf("%s: unable to compare images: %v", name, err)
+		}
+
+		analysis, err := diffAnalysis(DiffOptions{Before: "before", After: "after"}, diff)
+		if err != nil {
+			t.Fatalf("%s: unable to analyze the diff: %v", name, err)
+		}
+
+		if len(analysis.RefTrees) != 2 || analysis.RefTrees[1] != diff.ChangeTree {
+			t.Errorf("%s: expected the before and change trees as layers", name)
+		}
+		if changed := analysis.Layers[1].Size > 0; changed != test.changed {
+			t.Errorf("%s: expected changed files=%v, got a change layer of %d bytes", name, test.changed, analysis.Layers[1].Size)
+		}
+		if analysis.SizeBytes != analysis.Layers[0].Size+analysis.Layers[1].Size {
+			t.Errorf("%s: expected the size of both layers, got %d", name, analysis.SizeBytes)
+		}
+		if !test.changed && (analysis.WastedBytes != 0 || analysis.Efficiency != 1) {
+			t.Errorf("%s: expected no wasted space, got %d bytes (efficiency %f)", name, analysis.WastedBytes, analysis.Efficiency)
+		}
+	}
+}
@@ -1,12 +1,106 @@
 package runtime
 
+import (
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ci"
+)
+
 type eventChannel chan event
 
 type event struct {
 	stdout      string
+	data        string // output that is written to stdout as is (see StdoutPath)
 	stderr      string
 	err         error
 	errorOnExit bool
+	exitCode    int         // the exit code when errorOnExit is set (1 if not given)
+	payload     interface{} // the typed form of the event (see OutputJSONLines), nil for plain messages
+}
+
+// The types of the typed events.
+const (
+	EventMessage          = "message"
+	EventBuildStarted     = "build-started"
+	EventFetchStarted     = "fetch-started"
+	EventLayerParsed      = "layer-parsed"
+	EventImageFetched     = "image-fetched"
+	EventAnalysisComplete = "analysis-complete"
+	EventCiRuleResult     = "ci-rule-result"
+	EventError            = "error"
+)
+
+// MessageEvent is a progress or result message that has no typed form.
+type MessageEvent struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// BuildStartedEvent is sent before an image is built with the given build arguments.
+type BuildStartedEvent struct {
+	Type string   `json:"type"`
+	Args []string `json:"args"`
+}
+
+// FetchStartedEvent is sent before an image is fetched from its source.
+type FetchStartedEvent struct {
+	Type   string `json:"type"`
+	Image  string `json:"image"`
+	Source string `json:"source"`
+}
+
+// LayerParsedEvent is sent while an image is fetched, right after each of its layers has been parsed.
+type LayerParsedEvent struct {
+	Type   string `json:"type"`
+	Image  string `json:"image"`
+	Path   string `json:"path"`   // the path of the layer within the image archive
+	Parsed int    `json:"parsed"` // the number of layers of the image parsed so far
+}
+
+// ImageFetchedEvent is sent with the layers of an image once it has been fetched (and all of its layers parsed).
+type ImageFetchedEvent struct {
+	Type   string         `json:"type"`
+	Image  string         `json:"image"`
+	Layers []FetchedLayer `json:"layers"`
+}
+
+// FetchedLayer is a single layer of an ImageFetchedEvent.
+type FetchedLayer struct {
+	Index     int    `json:"index"`
+	ID        string `json:"id"`
+	Digest    string `json:"digest"`
+	Command   string `json:"command"`
+	SizeBytes uint64 `json:"sizeBytes"`
+}
+
+// AnalysisCompleteEvent is sent with the statistics of an analyzed image.
+type AnalysisCompleteEvent struct {
+	Type              string  `json:"type"`
+	Image             string  `json:"image"`
+	Layers            int     `json:"layers"`
+	BaseLayers        int     `json:"baseLayers"`
+	SizeBytes         uint64  `json:"sizeBytes"`
+	UserSizeBytes     uint64  `json:"userSizeBytes"`
+	EfficiencyScore   float64 `json:"efficiencyScore"`
+	WastedBytes       uint64  `json:"wastedBytes"`
+	UserWastedBytes   uint64  `json:"userWastedBytes"`
+	UserWastedPercent float64 `json:"userWastedPercent"`
+}
+
+// CiRuleResultEvent is sent with the outcome of every CI rule.
+type CiRuleResultEvent struct {
+	Type    string `json:"type"`
+	Image   string `json:"image"`
+	Rule    string `json:"rule"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// ErrorEvent is sent when a run fails.
+type ErrorEvent struct {
+	Type    string `json:"type"`
+	Message string `json:"message,omitempty"`
+	Error   string `json:"error,omitempty"`
 }
 
 func (ec eventChannel) message(msg string) {
@@ -15,6 +109,84 @@ func (ec eventChannel) message(msg string) {
 	}
 }
 
+func (ec eventChannel) data(data []byte) {
+	ec <- event{
+		data: string(data),
+	}
+}
+
+func (ec eventChannel) buildStarted(msg string, args []string) {
+	ec <- event{
+		stdout:  msg,
+		payload: BuildStartedEvent{Type: EventBuildStarted, Args: args},
+	}
+}
+
+func (ec eventChannel) fetchStarted(msg string, source dive.ImageSource, name string) {
+	ec <- event{
+		stdout:  msg,
+		payload: FetchStartedEvent{Type: EventFetchStarted, Image: name, Source: source.String()},
+	}
+}
+
+// layerParsed sends the progress of fetching the given image (which has no text form).
+func (ec eventChannel) layerParsed(name, layerPath string, parsed int) {
+	ec <- event{
+		payload: LayerParsedEvent{Type: EventLayerParsed, Image: name, Path: layerPath, Parsed: parsed},
+	}
+}
+
+// imageFetched sends the layers of the given fetched image (which have no text form).
+func (ec eventChannel) imageFetched(name string, img *image.Image) {
+	layers := make([]FetchedLayer, 0, len(img.Layers))
+	for _, layer := range img.Layers {
+		layers = append(layers, FetchedLayer{
+			Index:     layer.Index,
+			ID:        layer.Id,
+			Digest:    layer.Digest,
+			Command:   layer.Command,
+			SizeBytes: layer.Size,
+		})
+	}
+	ec <- event{
+		payload: ImageFetchedEvent{Type: EventImageFetched, Image: name, Layers: layers},
+	}
+}
+
+// analysisComplete sends the statistics of the given analysis (which have no text form).
+func (ec eventChannel) analysisComplete(name string, analysis *image.AnalysisResult) {
+	ec <- event{
+		payload: AnalysisCompleteEvent{
+			Type:              EventAnalysisComplete,
+			Image:             name,
+			Layers:            len(analysis.Layers),
+			BaseLayers:        analysis.BaseLayers,
+			SizeBytes:         analysis.SizeBytes,
+			UserSizeBytes:     analysis.UserSizeByes,
+			EfficiencyScore:   analysis.Efficiency,
+			WastedBytes:       analysis.WastedBytes,
+			UserWastedBytes:   analysis.WastedUserBytes,
+			UserWastedPercent: analysis.WastedUserPercent,
+		},
+	}
+}
+
+// ciRuleResults sends the outcome of every rule of the given evaluator (which have no text form, see the CI report).
+func (ec eventChannel) ciRuleResults(name string, evaluator *ci.CiEvaluator) {
+	for _, rule := range evaluator.SortedRules() {
+		result := evaluator.Results[rule]
+		ec <- event{
+			payload: CiRuleResultEvent{
+				Type:    EventCiRuleResult,
+				Image:   name,
+				Rule:    rule,
+				Status:  result.Status().Name(),
+				Message: result.Message(),
+			},
+		}
+	}
+}
+
 func (ec eventChannel) exitWithError(err error) {
 	ec <- event{
 		err:         err,
@@ -29,3 +201,10 @@ func (ec eventChannel) exitWithErrorMessage(msg string, err error) {
 		errorOnExit: true,
 	}
 }
+
+func (ec eventChannel) exitWithCode(code int) {
+	ec <- event{
+		errorOnExit: true,
+		exitCode:    code,
+	}
+}
@@ -0,0 +1,101 @@
+package runtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/lunixbochs/vtclean"
+)
+
+// OutputText and OutputJSONLines are the formats in which the events of a run are printed.
+const (
+	OutputText      = "text"
+	OutputJSONLines = "jsonl"
+)
+
+var Outputs = []string{OutputText, OutputJSONLines}
+
+// eventPrinter prints a single event, see consumeEvents.
+type eventPrinter interface {
+	print(e event) error
+}
+
+// newEventPrinter returns the printer of the given output format. When an output is written to stdout, the text
+// messages are written to stderr instead (so stdout only holds the output).
+func newEventPrinter(output string, messagesToStderr bool) eventPrinter {
+	if output == OutputJSONLines {
+		return &jsonLinesPrinter{writer: os.Stdout}
+	}
+	messages := os.Stdout
+	if messagesToStderr {
+		messages = os.Stderr
+	}
+	return &textPrinter{messages: messages, errors: os.Stderr}
+}
+
+// textPrinter prints the human readable text of every event.
+type textPrinter struct {
+	messages io.Writer
+	errors   io.Writer
+}
+
+func (printer *textPrinter) print(e event) error {
+	if e.stdout != "" {
+		if _, err := fmt.Fprintln(printer.messages, e.stdout); err != nil {
+			return err
+		}
+	}
+	if e.data != "" {
+		if _, err := fmt.Print(e.data); err != nil {
+			return err
+		}
+	}
+	if e.stderr != "" {
+		if _, err := fmt.Fprintln(printer.errors, e.stderr); err != nil {
+			return err
+		}
+	}
+	if e.err != nil {
+		if _, err := fmt.Fprintln(printer.errors, e.err.Error()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// jsonLinesPrinter prints every event as a single line of JSON: the typed form of the event if there is one, the text
+// of the event otherwise (see MessageEvent and ErrorEvent).
+type jsonLinesPrinter struct {
+	writer io.Writer
+}
+
+func (printer *jsonLinesPrinter) print(e event) error {
+	var line interface{}
+	switch {
+	case e.payload != nil:
+		line = e.payload
+	case e.stdout != "":
+		line = MessageEvent{Type: EventMessage, Message: vtclean.Clean(e.stdout, false)}
+	case e.stderr != "" || e.err != nil:
+		errorEvent := ErrorEvent{Type: EventError, Message: vtclean.Clean(e.stderr, false)}
+		if e.err != nil {
+			errorEvent.Error = e.err.Error()
+		}
+		line = errorEvent
+	case e.data != "":
+		// passed through as is (the CLI does not write an output to stdout along with the events)
+		_, err := io.WriteString(printer.writer, e.data)
+		return err
+	default:
+		return nil
+	}
+
+	payload, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	_, err = printer.writer.Write(append(payload, '\n'))
+	return err
+}
@@ -0,0 +1,161 @@
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/spf13/viper"
+	"github.com/wagoodman/dive/dive"
+	"github.com/wagoodman/dive/utils"
+)
+
+func TestRunTypedEvents(t *testing.T) {
+	config := viper.New()
+	config.Set("rules.lowestEfficiency", "0.9")
+	config.Set("rules.highestWastedBytes", "disabled")
+	config.Set("rules.highestUserWastedPercent", "0.1")
+
+	var ec = make(eventChannel)
+	options := Options{
+		Ci:       true,
+		CiConfig: config,
+		Image:    "dive-example",
+		Source:   dive.SourceDockerEngine,
+		Output:   OutputJSONLines,
+	}
+	go run(false, options, &defaultResolver{}, ec, afero.NewMemMapFs())
+
+	var fetches, layers, parsed int
+	var analysis *AnalysisCompleteEvent
+	rules := make(map[string]string)
+	for event := range ec {
+		switch payload := event.payload.(type) {
+		case FetchStartedEvent:
+			fetches++
+			if payload.Image != "dive-example" || payload.Source != "docker" {
+				t.Errorf("unexpected fetch event: %+v", payload)
+			}
+		case LayerParsedEvent:
+			parsed++
+			if payload.Image != "dive-example" || payload.Parsed != parsed || !strings.HasSuffix(payload.Path, ".tar") {
+				t.Errorf("unexpected layer event: %+v", payload)
+			}
+			if layers != 0 {
+				t.Errorf("expected the layer events before the image event: %+v", payload)
+			}
+		case ImageFetchedEvent:
+			if payload.Image != "dive-example" {
+				t.Errorf("unexpected image event: %+v", payload)
+			}
+			for idx, layer := range payload.Layers {
+				if layer.Index != idx {
+					t.Errorf("expected layer %d, got %+v", idx, layer)
+				}
+			}
+			layers += len(payload.Layers)
+		case AnalysisCompleteEvent:
+			analysis = &payload
+		case CiRuleResultEvent:
+			rules[payload.Rule] = payload.Status
+		}
+	}
+
+	if fetches != 1 || layers != 14 || parsed != 14 {
+		t.Errorf("expected 1 fetch, 14 fetched layers, and 14 parsed layers, got %d, %d, and %d", fetches, layers, parsed)
+	}
+	if analysis == nil || analysis.Image != "dive-example" || analysis.Layers != 14 || analysis.SizeBytes != 1220598 || analysis.WastedBytes != 32025 {
+		t.Errorf("unexpected analysis event: %+v", analysis)
+	}
+	expectedRules := "map[highestUserWastedPercent:fail highestWastedBytes:skip lowestEfficiency:pass]"
+	if fmt.Sprint(rules) != expectedRules {
+		t.Errorf("expected rule results %s, got %v", expectedRules, rules)
+	}
+}
+
+func TestRunBaseImageEvents(t *testing.T) {
+	var ec = make(eventChannel)
+	options := Options{
+		Ci:        true,
+		CiConfig:  viper.New(),
+		Image:     "dive-example",
+		Source:    dive.SourceDockerEngine,
+		BaseImage: "dive-base",
+		Output:    OutputJSONLines,
+	}
+	go run(false, options, &defaultResolver{}, ec, afero.NewMemMapFs())
+
+	var fetched, started []string
+	for event := range ec {
+		switch payload := event.payload.(type) {
+		case FetchStartedEvent:
+			started = append(started, payload.Image)
+		case ImageFetchedEvent:
+			fetched = append(fetched, payload.Image)
+		}
+	}
+
+	expected := "[dive-example dive-base]"
+	if fmt.Sprint(started) != expected || fmt.Sprint(fetched) != expected {
+		t.Errorf("expected fetch events for %s, got %v started and %v fetched", expected, started, fetched)
+	}
+}
+
+func TestJSONLinesPrinter(t *testing.T) {
+	var buffer bytes.Buffer
+	printer := &jsonLinesPrinter{writer: &buffer}
+
+	events := []event{
+		{stdout: utils.TitleFormat("Analyzing image...")},
+		{stdout: utils.TitleFormat("Fetching image..."), payload: FetchStartedEvent{Type: EventFetchStarted, Image: "dive-example", Source: "docker"}},
+		{payload: CiRuleResultEvent{Type: EventCiRuleResult, Image: "dive-example", Rule: "lowestEfficiency", Status: "pass"}},
+		{stderr: "cannot fetch image", err: fmt.Errorf("some fetch failure"), errorOnExit: true},
+		{errorOnExit: true, exitCode: 2},
+	}
+	for _, e := range events {
+		if err := printer.print(e); err != nil {
+			t.Fatalf("unable to print event: %v", err)
+		}
+	}
+
+	expected := []string{
+		`{"type":"message","message":"Analyzing image..."}`,
+		`{"type":"fetch-started","image":"dive-example","source":"docker"}`,
+		`{"type":"ci-rule-result","image":"dive-example","rule":"lowestEfficiency","status":"pass"}`,
+		`{"type":"error","message":"cannot fetch image","error":"some fetch failure"}`,
+	}
+	actual := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %d lines, got %d:\n%s", len(expected), len(actual), buffer.String())
+	}
+	for idx := range expected {
+		if actual[idx] != expected[idx] {
+			t.Errorf("line %d: expected %s, got %s", idx, expected[idx], actual[idx])
+		}
+	}
+}
+
+func TestTextPrinter(t *testing.T) {
+	var messages, errors bytes.Buffer
+	printer := &textPrinter{messages: &messages, errors: &errors}
+
+	events := []event{
+		{stdout: "Analyzing image..."},
+		{payload: CiRuleResultEvent{Type: EventCiRuleResult, Rule: "lowestEfficiency", Status: "pass"}},
+		{stderr: "cannot fetch image", err: fmt.Errorf("some fetch failure"), errorOnExit: true},
+	}
+	for _, e := range events {
+		if err := printer.print(e); err != nil {
+			t.Fatalf("unable to print event: %v", err)
+		}
+	}
+
+	if messages.String() != "Analyzing image...\n" {
+		t.Errorf("unexpected messages: %q", messages.String())
+	}
+	if errors.String() != "cannot fetch image\nsome fetch failure\n" {
+		t.Errorf("unexpected errors: %q", errors.String())
+	}
+}
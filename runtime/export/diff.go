@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/json"
+
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+type diffExport struct {
+	Before       diffImage        `json:"before"`
+	After        diffImage        `json:"after"`
+	SharedLayers int              `json:"sharedLayers"`
+	DeltaBytes   int64            `json:"sizeDeltaBytes"`
+	Files        diffFiles        `json:"files"`
+	Directories  []directoryDelta `json:"directories"`
+}
+
+type diffImage struct {
+	Name      string `json:"name"`
+	Layers    int    `json:"layers"`
+	SizeBytes uint64 `json:"sizeBytes"`
+}
+
+type diffFiles struct {
+	Added    int `json:"added"`
+	Removed  int `json:"removed"`
+	Modified int `json:"modified"`
+}
+
+type directoryDelta struct {
+	Path            string `json:"path"`
+	BeforeSizeBytes int64  `json:"beforeSizeBytes"`
+	AfterSizeBytes  int64  `json:"afterSizeBytes"`
+	DeltaBytes      int64  `json:"deltaBytes"`
+}
+
+// NewDiffExport summarizes the differences between two images, reporting the size changes of all directories up to
+// the given depth.
+func NewDiffExport(beforeName, afterName string, diff *diveImage.Diff, depth int) *diffExport {
+	changes := diff.FileChanges()
+	data := diffExport{
+		Before:       newDiffImage(beforeName, diff.Before),
+		After:        newDiffImage(afterName, diff.After),
+		SharedLayers: diff.SharedLayers,
+		Files: diffFiles{
+			Added:    changes.Added,
+			Removed:  changes.Removed,
+			Modified: changes.Modified,
+		},
+		Directories: make([]directoryDelta, 0),
+	}
+	data.DeltaBytes = int64(data.After.SizeBytes) - int64(data.Before.SizeBytes)
+
+	for _, delta := range diff.DirectoryDeltas(depth) {
+		data.Directories = append(data.Directories, directoryDelta{
+			Path:            delta.Path,
+			BeforeSizeBytes: delta.BeforeSize,
+			AfterSizeBytes:  delta.AfterSize,
+			DeltaBytes:      delta.Delta(),
+		})
+	}
+
+	return &data
+}
+
+func newDiffImage(name string, img *diveImage.Image) diffImage {
+	var sizeBytes uint64
+	for _, layer := range img.Layers {
+		sizeBytes += layer.Size
+	}
+	return diffImage{
+		Name:      name,
+		Layers:    len(img.Layers),
+		SizeBytes: sizeBytes,
+	}
+}
+
+func (exp *diffExport) Marshal() ([]byte, error) {
+	return json.MarshalIndent(&exp, "", "  ")
+}
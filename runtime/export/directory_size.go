@@ -0,0 +1,7 @@
+package export
+
+// DirectorySize is the total size of all files within a directory of the final (stacked) image filesystem.
+type DirectorySize struct {
+	Path      string `json:"path"`
+	SizeBytes int64  `json:"sizeBytes"`
+}
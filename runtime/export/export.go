@@ -2,28 +2,54 @@ package export
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/wagoodman/dive/dive/filetree"
 	diveImage "github.com/wagoodman/dive/dive/image"
 )
 
-type export struct {
-	Layer []layer `json:"layer"`
-	Image image   `json:"image"`
+// SchemaVersion is the version of the export payload schema. It is incremented whenever a field is removed or its
+// meaning changes (new fields do not require a new version). Payloads without a version predate versioning and are
+// read as version 0, which is compatible with version 1.
+const SchemaVersion = 1
+
+// directoryDepth is the number of directory levels (below "/") that are reported in the export.
+const directoryDepth = 3
+
+// Export is the (stable) JSON representation of an image analysis.
+type Export struct {
+	Version int     `json:"version"`
+	Layer   []Layer `json:"layer"`
+	Image   Image   `json:"image"`
+
+	// Regression is only present when the analysis was compared against a baseline
+	Regression *Regression `json:"regression,omitempty"`
+
+	// Tree is only present when the final filesystem was requested (see AddTree)
+	Tree []FileEntry `json:"tree,omitempty"`
 }
 
-func NewExport(analysis *diveImage.AnalysisResult) *export {
-	data := export{
-		Layer: make([]layer, len(analysis.Layers)),
-		Image: image{
-			InefficientFiles: make([]fileReference, len(analysis.Inefficiencies)),
-			SizeBytes:        analysis.SizeBytes,
-			EfficiencyScore:  analysis.Efficiency,
-			InefficientBytes: analysis.WastedBytes,
+func NewExport(analysis *diveImage.AnalysisResult) *Export {
+	data := Export{
+		Version: SchemaVersion,
+		Layer:   make([]Layer, len(analysis.Layers)),
+		Image: Image{
+			InefficientFiles:     make([]FileReference, len(analysis.Inefficiencies)),
+			SizeBytes:            analysis.SizeBytes,
+			EfficiencyScore:      analysis.Efficiency,
+			InefficientBytes:     analysis.WastedBytes,
+			BaseLayers:           analysis.BaseLayers,
+			UserSizeBytes:        analysis.UserSizeByes,
+			UserInefficientBytes: analysis.WastedUserBytes,
+			UserWastedPercent:    analysis.WastedUserPercent,
+			Directories:          make([]DirectorySize, 0),
 		},
 	}
 
 	// export layers in order
 	for idx, curLayer := range analysis.Layers {
-		data.Layer[idx] = layer{
+		data.Layer[idx] = Layer{
 			Index:     curLayer.Index,
 			ID:        curLayer.Id,
 			DigestID:  curLayer.Digest,
@@ -36,7 +62,7 @@ func NewExport(analysis *diveImage.AnalysisResult) *export {
 	for idx := 0; idx < len(analysis.Inefficiencies); idx++ {
 		fileData := analysis.Inefficiencies[len(analysis.Inefficiencies)-1-idx]
 
-		data.Image.InefficientFiles[idx] = fileReference{
+		data.Image.InefficientFiles[idx] = FileReference{
 			References: len(fileData.Nodes),
 			SizeBytes:  uint64(fileData.CumulativeSize),
 			Path:       fileData.Path,
@@ -46,6 +72,96 @@ func NewExport(analysis *diveImage.AnalysisResult) *export {
 	return &data
 }
 
-func (exp *export) Marshal() ([]byte, error) {
+// AddDirectories adds the sizes of the directories of the final (stacked) filesystem, which requires stacking all
+// layers (only the export payload, baseline comparisons, and metrics need them).
+func (exp *Export) AddDirectories(analysis *diveImage.AnalysisResult) error {
+	exp.Image.Directories = make([]DirectorySize, 0)
+	if len(analysis.RefTrees) == 0 {
+		return nil
+	}
+
+	tree, _, err := filetree.StackTreeRange(analysis.RefTrees, 0, len(analysis.RefTrees)-1)
+	if err != nil {
+		return fmt.Errorf("unable to stack layers: %v", err)
+	}
+	for dir, size := range diveImage.DirectorySizes(tree, directoryDepth) {
+		exp.Image.Directories = append(exp.Image.Directories, DirectorySize{Path: dir, SizeBytes: size})
+	}
+	sort.Slice(exp.Image.Directories, func(i, j int) bool {
+		return exp.Image.Directories[i].Path < exp.Image.Directories[j].Path
+	})
+	return nil
+}
+
+// AddLayerChanges lists the added, modified, and removed paths of every layer, relative to all layers below it.
+func (exp *Export) AddLayerChanges(analysis *diveImage.AnalysisResult) error {
+	changes, _, err := filetree.LayerChanges(analysis.RefTrees)
+	if err != nil {
+		return fmt.Errorf("unable to compare layers: %v", err)
+	}
+	exp.addLayerChanges(changes)
+	return nil
+}
+
+func (exp *Export) addLayerChanges(changes [][]filetree.FileChange) {
+	for idx := range exp.Layer {
+		if idx >= len(changes) {
+			break
+		}
+		layerChanges := LayerChanges{
+			Added:    make([]FileEntry, 0),
+			Modified: make([]FileEntry, 0),
+			Removed:  make([]FileEntry, 0),
+		}
+		for _, change := range changes[idx] {
+			entry := newFileEntry(change.Path, change.FileInfo)
+			switch change.DiffType {
+			case filetree.Added:
+				layerChanges.Added = append(layerChanges.Added, entry)
+			case filetree.Modified:
+				layerChanges.Modified = append(layerChanges.Modified, entry)
+			case filetree.Removed:
+				layerChanges.Removed = append(layerChanges.Removed, entry)
+			}
+		}
+		exp.Layer[idx].Changes = &layerChanges
+	}
+}
+
+// AddTree lists every path of the final (stacked) filesystem.
+func (exp *Export) AddTree(analysis *diveImage.AnalysisResult) error {
+	exp.Tree = make([]FileEntry, 0)
+	if len(analysis.RefTrees) == 0 {
+		return nil
+	}
+
+	tree, _, err := filetree.StackTreeRange(analysis.RefTrees, 0, len(analysis.RefTrees)-1)
+	if err != nil {
+		return fmt.Errorf("unable to stack layers: %v", err)
+	}
+	return exp.addTree(tree)
+}
+
+func (exp *Export) addTree(tree *filetree.FileTree) error {
+	exp.Tree = make([]FileEntry, 0)
+	return tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		exp.Tree = append(exp.Tree, newFileEntry(node.Path(), node.Data.FileInfo))
+		return nil
+	}, nil)
+}
+
+func (exp *Export) Marshal() ([]byte, error) {
 	return json.MarshalIndent(&exp, "", "  ")
 }
+
+// Unmarshal reads an export payload, rejecting payloads written with a newer (unknown) schema version.
+func Unmarshal(data []byte) (*Export, error) {
+	var exp Export
+	if err := json.Unmarshal(data, &exp); err != nil {
+		return nil, fmt.Errorf("invalid export payload: %v", err)
+	}
+	if exp.Version > SchemaVersion {
+		return nil, fmt.Errorf("unsupported export schema version: %d (supported up to %d)", exp.Version, SchemaVersion)
+	}
+	return &exp, nil
+}
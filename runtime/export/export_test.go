@@ -3,6 +3,7 @@ package export
 import (
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/wagoodman/dive/dive/image/docker"
+	"strings"
 	"testing"
 )
 
@@ -10,12 +11,16 @@ func Test_Export(t *testing.T) {
 	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
 
 	export := NewExport(result)
+	if err := export.AddDirectories(result); err != nil {
+		t.Fatalf("Test_Export: unable to add directories: %v", err)
+	}
 	payload, err := export.Marshal()
 	if err != nil {
 		t.Errorf("Test_Export: unable to export analysis: %v", err)
 	}
 
 	expectedResult := `{
+  "version": 1,
   "layer": [
     {
       "index": 0,
@@ -120,6 +125,10 @@ func Test_Export(t *testing.T) {
     "sizeBytes": 1220598,
     "inefficientBytes": 32025,
     "efficiencyScore": 0.9844212134184309,
+    "baseLayers": 1,
+    "userSizeBytes": 66237,
+    "userInefficientBytes": 32025,
+    "userWastedPercent": 0.4834911001404049,
     "fileReference": [
       {
         "count": 2,
@@ -136,6 +145,32 @@ func Test_Export(t *testing.T) {
         "sizeBytes": 6405,
         "file": "/root/example/somefile3.txt"
       }
+    ],
+    "directories": [
+      {
+        "path": "/",
+        "sizeBytes": 1188573
+      },
+      {
+        "path": "/bin",
+        "sizeBytes": 1153344
+      },
+      {
+        "path": "/etc",
+        "sizeBytes": 1017
+      },
+      {
+        "path": "/root",
+        "sizeBytes": 21402
+      },
+      {
+        "path": "/root/.data",
+        "sizeBytes": 8592
+      },
+      {
+        "path": "/tmp",
+        "sizeBytes": 6405
+      }
     ]
   }
 }`
@@ -147,3 +182,82 @@ func Test_Export(t *testing.T) {
 		t.Errorf("Test_Export: unexpected export result:\n%v", dmp.DiffPrettyText(diffs))
 	}
 }
+
+func Test_ExportLayerChanges(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	export := NewExport(result)
+	if err := export.AddLayerChanges(result); err != nil {
+		t.Fatalf("unable to add layer changes: %v", err)
+	}
+	if err := export.AddTree(result); err != nil {
+		t.Fatalf("unable to add tree: %v", err)
+	}
+
+	// the payload must survive a round trip
+	payload, err := export.Marshal()
+	if err != nil {
+		t.Fatalf("unable to marshal export: %v", err)
+	}
+	export, err = Unmarshal(payload)
+	if err != nil {
+		t.Fatalf("unable to unmarshal export: %v", err)
+	}
+
+	savedFile := FileEntry{Path: "/root/saved.txt", Type: "file", SizeBytes: 6405, Mode: "0644", Hash: "cf6e9cd1eb83e88a"}
+	table := map[string]struct {
+		layer    int
+		expected LayerChanges
+	}{
+		"add":    {1, LayerChanges{Added: []FileEntry{{Path: "/somefile.txt", Type: "file", SizeBytes: 6405, Mode: "0664", Hash: "cf6e9cd1eb83e88a"}}}},
+		"mkdir":  {2, LayerChanges{Added: []FileEntry{{Path: "/root/example", Type: "dir", Mode: "0755"}, {Path: "/root/example/really", Type: "dir", Mode: "0755"}, {Path: "/root/example/really/nested", Type: "dir", Mode: "0755"}}}},
+		"chmod":  {4, LayerChanges{Modified: []FileEntry{{Path: "/root/example/somefile1.txt", Type: "file", SizeBytes: 6405, Mode: "0444", Hash: "cf6e9cd1eb83e88a"}}}},
+		"move":   {7, LayerChanges{Added: []FileEntry{savedFile}, Removed: []FileEntry{{Path: "/root/example/somefile3.txt", Type: "file", SizeBytes: 6405, Mode: "0644", Hash: "cf6e9cd1eb83e88a"}}}},
+		"chmod2": {13, LayerChanges{Modified: []FileEntry{{Path: "/root/saved.txt", Type: "file", SizeBytes: 6405, Mode: "0755", Hash: "cf6e9cd1eb83e88a"}}}},
+	}
+
+	for name, test := range table {
+		actual := export.Layer[test.layer].Changes
+		if actual == nil {
+			t.Errorf("%s: missing layer changes", name)
+			continue
+		}
+		compareEntries(t, name+" added", test.expected.Added, actual.Added)
+		compareEntries(t, name+" modified", test.expected.Modified, actual.Modified)
+		compareEntries(t, name+" removed", test.expected.Removed, actual.Removed)
+	}
+
+	// removing a directory removes everything below it
+	if removed := export.Layer[9].Changes.Removed; len(removed) != 6 || removed[0].Path != "/root/example" {
+		t.Errorf("expected 6 removed paths below /root/example, got %+v", removed)
+	}
+
+	var found bool
+	for _, entry := range export.Tree {
+		if strings.HasPrefix(entry.Path, "/root/example") {
+			t.Errorf("the tree contains a removed path: %s", entry.Path)
+		}
+		if entry.Path == "/root/saved.txt" {
+			found = true
+			if entry.Mode != "0755" {
+				t.Errorf("expected the final mode of %s, got %s", entry.Path, entry.Mode)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("the tree is missing /root/saved.txt")
+	}
+}
+
+func compareEntries(t *testing.T, name string, expected, actual []FileEntry) {
+	t.Helper()
+	if len(expected) != len(actual) {
+		t.Errorf("%s: expected %d entries, got %d: %+v", name, len(expected), len(actual), actual)
+		return
+	}
+	for idx := range expected {
+		if expected[idx] != actual[idx] {
+			t.Errorf("%s: expected %+v, got %+v", name, expected[idx], actual[idx])
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+
+	"github.com/wagoodman/dive/dive/filetree"
+)
+
+// FileEntry describes a single path of a layer (or of the final filesystem).
+type FileEntry struct {
+	Path       string `json:"path"`
+	Type       string `json:"type"`
+	SizeBytes  int64  `json:"sizeBytes"`
+	Mode       string `json:"mode"` // the unix permission bits (including setuid, setgid, and sticky) in octal
+	Uid        int    `json:"uid"`
+	Gid        int    `json:"gid"`
+	LinkTarget string `json:"linkTarget,omitempty"`
+	Hash       string `json:"hash,omitempty"` // the xxhash64 of the file contents (not given for directories)
+}
+
+// LayerChanges are the paths that a layer changed relative to all layers below it.
+type LayerChanges struct {
+	Added    []FileEntry `json:"added"`
+	Modified []FileEntry `json:"modified"`
+	Removed  []FileEntry `json:"removed"`
+}
+
+func newFileEntry(path string, info filetree.FileInfo) FileEntry {
+	entry := FileEntry{
+		Path:       path,
+		Type:       fileType(info),
+		SizeBytes:  info.Size,
+		Mode:       fileMode(info.Mode),
+		Uid:        info.Uid,
+		Gid:        info.Gid,
+		LinkTarget: info.Linkname,
+	}
+	if !info.IsDir && info.Hash() != 0 {
+		entry.Hash = fmt.Sprintf("%016x", info.Hash())
+	}
+	return entry
+}
+
+// fileType names the kind of file based on its tar header type.
+func fileType(info filetree.FileInfo) string {
+	switch info.TypeFlag {
+	case tar.TypeDir:
+		return "dir"
+	case tar.TypeSymlink:
+		return "symlink"
+	case tar.TypeLink:
+		return "hardlink"
+	case tar.TypeChar:
+		return "char"
+	case tar.TypeBlock:
+		return "block"
+	case tar.TypeFifo:
+		return "fifo"
+	}
+	if info.IsDir {
+		return "dir"
+	}
+	return "file"
+}
+
+// fileMode renders the given mode as unix permission bits (e.g. "0755" or "4755").
+func fileMode(mode os.FileMode) string {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 04000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 02000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 01000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
@@ -1,6 +1,6 @@
 package export
 
-type fileReference struct {
+type FileReference struct {
 	References int    `json:"count"`
 	SizeBytes  uint64 `json:"sizeBytes"`
 	Path       string `json:"file"`
@@ -0,0 +1,211 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// htmlReport is the data shown by the HTML report, the same data that is shown in the UI.
+type htmlReport struct {
+	Name   string
+	Image  Image
+	Layers []htmlLayer
+	Tree   *htmlNode
+}
+
+type htmlLayer struct {
+	Layer
+	Tree *htmlNode // the paths changed by the layer
+}
+
+// htmlNode is a single path of a tree in the report, directories hold their children.
+type htmlNode struct {
+	Name      string
+	Entry     *FileEntry // nil for (unchanged) directories that are only implied by their children
+	Change    string     // "added", "modified", "removed" (or "" if unchanged)
+	SizeBytes int64
+	Children  []*htmlNode
+	children  map[string]*htmlNode
+}
+
+// NewHTMLReport renders a self-contained HTML page with the layers, the image efficiency, the inefficient files, the
+// changes of every layer, and the final filesystem of the analysis. The layers are stacked only once, for the changes
+// of every layer and the final filesystem alike.
+func NewHTMLReport(name string, analysis *diveImage.AnalysisResult) ([]byte, error) {
+	exp := NewExport(analysis)
+	changes, stacked, _, err := filetree.StackWithLayerChanges(analysis.RefTrees)
+	if err != nil {
+		return nil, fmt.Errorf("unable to compare layers: %v", err)
+	}
+	exp.addLayerChanges(changes)
+	if err := exp.addTree(stacked); err != nil {
+		return nil, err
+	}
+
+	report := htmlReport{
+		Name:  name,
+		Image: exp.Image,
+		Tree:  newHTMLTree(),
+	}
+	for _, layer := range exp.Layer {
+		tree := newHTMLTree()
+		tree.add(layer.Changes.Added, "added")
+		tree.add(layer.Changes.Modified, "modified")
+		tree.add(layer.Changes.Removed, "removed")
+		tree.finish()
+		report.Layers = append(report.Layers, htmlLayer{Layer: layer, Tree: tree})
+	}
+	report.Tree.add(exp.Tree, "")
+	report.Tree.finish()
+
+	var buffer bytes.Buffer
+	if err := htmlTemplate.Execute(&buffer, report); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+func newHTMLTree() *htmlNode {
+	return &htmlNode{children: make(map[string]*htmlNode)}
+}
+
+// add places the given entries in the tree, creating any missing parent directories.
+func (node *htmlNode) add(entries []FileEntry, change string) {
+	for idx := range entries {
+		entry := &entries[idx]
+		current := node
+		for _, name := range strings.Split(strings.Trim(entry.Path, "/"), "/") {
+			child, exists := current.children[name]
+			if !exists {
+				child = &htmlNode{Name: name, children: make(map[string]*htmlNode)}
+				current.children[name] = child
+			}
+			current = child
+		}
+		current.Entry = entry
+		current.Change = change
+	}
+}
+
+// finish orders the children of every node by name and sums up the size of every directory. Removed paths keep their
+// (former) size, but do not count towards the size of their directory.
+func (node *htmlNode) finish() int64 {
+	if node.Entry != nil && node.Entry.Type != "dir" {
+		node.SizeBytes = node.Entry.SizeBytes
+	}
+	for _, child := range node.children {
+		node.Children = append(node.Children, child)
+		size := child.finish()
+		if child.Change != "removed" {
+			node.SizeBytes += size
+		}
+	}
+	sort.Slice(node.Children, func(i, j int) bool {
+		return node.Children[i].Name < node.Children[j].Name
+	})
+	return node.SizeBytes
+}
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"bytes": func(size interface{}) string {
+		switch value := size.(type) {
+		case int64:
+			return humanize.Bytes(uint64(value))
+		case uint64:
+			return humanize.Bytes(value)
+		}
+		return ""
+	},
+	"percent": func(value float64) string {
+		return humanize.FtoaWithDigits(value*100, 2) + " %"
+	},
+	"permissions": func(entry *FileEntry) string {
+		info, err := entry.fileInfo()
+		if err != nil {
+			return entry.Mode
+		}
+		return info.Mode.String()
+	},
+}).Parse(htmlSource))
+
+const htmlSource = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>dive: {{.Name}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #24292e; }
+h1 { font-size: 1.5em; } h2 { font-size: 1.2em; margin-top: 2em; border-bottom: 1px solid #e1e4e8; }
+table { border-collapse: collapse; } th, td { text-align: left; padding: 2px 12px 2px 0; vertical-align: top; }
+td.number, th.number { text-align: right; }
+code, .tree { font-family: SFMono-Regular, Consolas, Menlo, monospace; font-size: 0.9em; }
+.tree ul { list-style: none; padding-left: 1.5em; margin: 0; } .tree > ul { padding-left: 0; }
+.tree summary { cursor: pointer; } .tree li { white-space: pre; }
+.meta { color: #6a737d; } .link { color: #6a737d; }
+.added { color: #22863a; } .modified { color: #b08800; } .removed { color: #cb2431; text-decoration: line-through; }
+details.layer > summary { cursor: pointer; padding: 2px 0; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+
+<h2>Image Details</h2>
+<table>
+<tr><th>Total Image size</th><td>{{bytes .Image.SizeBytes}}</td></tr>
+<tr><th>Image efficiency score</th><td>{{percent .Image.EfficiencyScore}}</td></tr>
+<tr><th>Potential wasted space</th><td>{{bytes .Image.InefficientBytes}}</td></tr>
+<tr><th>Base image layers</th><td>{{.Image.BaseLayers}} (user layers size: {{bytes .Image.UserSizeBytes}})</td></tr>
+<tr><th>Wasted space in user layers</th><td>{{bytes .Image.UserInefficientBytes}} ({{percent .Image.UserWastedPercent}})</td></tr>
+</table>
+
+<h2>Layers</h2>
+<table>
+<tr><th class="number">Index</th><th class="number">Size</th><th>Command</th></tr>
+{{- range .Layers}}
+<tr><td class="number">{{.Index}}</td><td class="number">{{bytes .SizeBytes}}</td><td><code>{{.Command}}</code></td></tr>
+{{- end}}
+</table>
+
+<h2>Inefficient Files</h2>
+{{- if .Image.InefficientFiles}}
+<table>
+<tr><th class="number">Count</th><th class="number">Total Space</th><th>Path</th></tr>
+{{- range .Image.InefficientFiles}}
+<tr><td class="number">{{.References}}</td><td class="number">{{bytes .SizeBytes}}</td><td><code>{{.Path}}</code></td></tr>
+{{- end}}
+</table>
+{{- else}}
+<p>None</p>
+{{- end}}
+
+<h2>Layer Contents</h2>
+<p class="tree"><span class="added">added</span> <span class="modified">modified</span> <span class="removed">removed</span></p>
+{{- range .Layers}}
+<details class="layer">
+<summary><strong>Layer {{.Index}}</strong> ({{bytes .SizeBytes}}) <code>{{.Command}}</code></summary>
+<table>
+<tr><th>Id</th><td><code>{{.ID}}</code></td></tr>
+<tr><th>Digest</th><td><code>{{.DigestID}}</code></td></tr>
+<tr><th>Changes</th><td>{{len .Changes.Added}} added, {{len .Changes.Modified}} modified, {{len .Changes.Removed}} removed</td></tr>
+</table>
+<div class="tree"><ul>{{template "nodes" .Tree}}</ul></div>
+</details>
+{{- end}}
+
+<h2>Final Filesystem</h2>
+<div class="tree"><ul>{{template "nodes" .Tree}}</ul></div>
+</body>
+</html>
+{{define "nodes"}}{{range .Children}}
+{{- if .Children}}<li><details><summary class="{{.Change}}">{{template "node" .}}</summary><ul>{{template "nodes" .}}</ul></details></li>
+{{- else}}<li class="{{.Change}}">{{template "node" .}}</li>
+{{- end}}{{end}}{{end}}
+{{define "node"}}{{if .Entry}}<span class="meta">{{permissions .Entry}} {{printf "%5s" (print .Entry.Uid ":" .Entry.Gid)}} {{printf "%9s" (bytes .SizeBytes)}}</span> {{.Name}}{{if .Entry.LinkTarget}} <span class="link">→ {{.Entry.LinkTarget}}</span>{{end}}{{else}}<span class="meta">{{printf "%25s" ""}}</span> {{.Name}}{{end}}{{end}}
+`
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func Test_HTMLReport(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	payload, err := NewHTMLReport("dive-example:<latest>", result)
+	if err != nil {
+		t.Fatalf("unable to render report: %v", err)
+	}
+	report := string(payload)
+
+	expected := []string{
+		// the image name is escaped
+		"<h1>dive-example:&lt;latest&gt;</h1>",
+		// image details
+		"<tr><th>Potential wasted space</th><td>32 kB</td></tr>",
+		// layer list
+		`<tr><td class="number">13</td><td class="number">6.4 kB</td><td><code>chmod &#43;x /root/saved.txt</code></td></tr>`,
+		// inefficient files
+		`<tr><td class="number">2</td><td class="number">13 kB</td><td><code>/root/saved.txt</code></td></tr>`,
+		// layer changes
+		"<tr><th>Changes</th><td>0 added, 0 modified, 6 removed</td></tr>",
+		`<li class="removed"><span class="meta">-r--r--r--   0:0    6.4 kB</span> somefile1.txt</li>`,
+		`<li class="modified"><span class="meta">-rwxr-xr-x   0:0    6.4 kB</span> saved.txt</li>`,
+		// links in the final filesystem
+		`<span class="link">→ bin/[</span>`,
+	}
+	for _, fragment := range expected {
+		if !strings.Contains(report, fragment) {
+			t.Errorf("expected the report to contain %q", fragment)
+		}
+	}
+
+	// the report does not depend on anything outside of the page
+	for _, reference := range []string{"<script src", "<link ", "http://", "https://"} {
+		if strings.Contains(report, reference) {
+			t.Errorf("expected a self-contained report, found %q", reference)
+		}
+	}
+}
+
+func Test_HTMLTreeSizes(t *testing.T) {
+	tree := newHTMLTree()
+	tree.add([]FileEntry{{Path: "/root/added.txt", Type: "file", SizeBytes: 10}}, "added")
+	tree.add([]FileEntry{{Path: "/root/removed.txt", Type: "file", SizeBytes: 20}}, "removed")
+	tree.finish()
+
+	root := tree.children["root"]
+	if root.SizeBytes != 10 {
+		t.Errorf("expected the directory to hold 10 bytes (without the removed file), got %d", root.SizeBytes)
+	}
+	if removed := root.children["removed.txt"]; removed.SizeBytes != 20 {
+		t.Errorf("expected the removed file to keep its size, got %d", removed.SizeBytes)
+	}
+}
@@ -1,8 +1,13 @@
 package export
 
-type image struct {
-	SizeBytes        uint64          `json:"sizeBytes"`
-	InefficientBytes uint64          `json:"inefficientBytes"`
-	EfficiencyScore  float64         `json:"efficiencyScore"`
-	InefficientFiles []fileReference `json:"fileReference"`
+type Image struct {
+	SizeBytes            uint64          `json:"sizeBytes"`
+	InefficientBytes     uint64          `json:"inefficientBytes"`
+	EfficiencyScore      float64         `json:"efficiencyScore"`
+	BaseLayers           int             `json:"baseLayers"`
+	UserSizeBytes        uint64          `json:"userSizeBytes"`
+	UserInefficientBytes uint64          `json:"userInefficientBytes"`
+	UserWastedPercent    float64         `json:"userWastedPercent"`
+	InefficientFiles     []FileReference `json:"fileReference"`
+	Directories          []DirectorySize `json:"directories"`
 }
@@ -1,9 +1,12 @@
 package export
 
-type layer struct {
+type Layer struct {
 	Index     int    `json:"index"`
 	ID        string `json:"id"`
 	DigestID  string `json:"digestId"`
 	SizeBytes uint64 `json:"sizeBytes"`
 	Command   string `json:"command"`
+
+	// Changes are only present in a detailed export (see AddLayerChanges)
+	Changes *LayerChanges `json:"changes,omitempty"`
 }
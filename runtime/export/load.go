@@ -0,0 +1,201 @@
+package export
+
+import (
+	"archive/tar"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// fileTypeFlags are the tar header types of the file types given in an export (see fileType).
+var fileTypeFlags = map[string]byte{
+	"file":     tar.TypeReg,
+	"dir":      tar.TypeDir,
+	"symlink":  tar.TypeSymlink,
+	"hardlink": tar.TypeLink,
+	"char":     tar.TypeChar,
+	"block":    tar.TypeBlock,
+	"fifo":     tar.TypeFifo,
+}
+
+// Analysis rebuilds the image analysis from a detailed export (see AddLayerChanges), such that it can be explored
+// without the image. The layer trees only hold the files that each layer changed (files that a layer re-added without
+// changes are not part of the export), so the efficiency statistics are taken from the export as they were analyzed.
+func (exp *Export) Analysis() (*diveImage.AnalysisResult, error) {
+	img := diveImage.Image{
+		Trees:  make([]*filetree.FileTree, 0, len(exp.Layer)),
+		Layers: make([]*diveImage.Layer, 0, len(exp.Layer)),
+	}
+
+	stacked := filetree.NewFileTree()
+	for _, layer := range exp.Layer {
+		if layer.Changes == nil {
+			return nil, fmt.Errorf("the export does not contain the file changes of layer %d (export the image with --json-detail)", layer.Index)
+		}
+
+		tree, err := newLayerTree(layer, stacked)
+		if err != nil {
+			return nil, fmt.Errorf("unable to rebuild layer %d: %v", layer.Index, err)
+		}
+		if _, err := stacked.Stack(tree); err != nil {
+			return nil, fmt.Errorf("unable to rebuild layer %d: %v", layer.Index, err)
+		}
+
+		img.Trees = append(img.Trees, tree)
+		img.Layers = append(img.Layers, &diveImage.Layer{
+			Id:      layer.ID,
+			Index:   layer.Index,
+			Command: layer.Command,
+			Size:    layer.SizeBytes,
+			Tree:    tree,
+			Digest:  layer.DigestID,
+		})
+	}
+
+	analysis, err := img.AnalyzeWithBaseLayers(exp.Image.BaseLayers)
+	if err != nil {
+		return nil, err
+	}
+
+	analysis.SizeBytes = exp.Image.SizeBytes
+	analysis.UserSizeByes = exp.Image.UserSizeBytes
+	analysis.Efficiency = exp.Image.EfficiencyScore
+	analysis.WastedBytes = exp.Image.InefficientBytes
+	analysis.WastedUserBytes = exp.Image.UserInefficientBytes
+	analysis.WastedUserPercent = exp.Image.UserWastedPercent
+	analysis.Inefficiencies = exp.inefficiencies(img.Trees)
+	return analysis, nil
+}
+
+// newLayerTree rebuilds the tree of a single layer from its changes: added and modified paths are added as-is and
+// removed paths are represented by whiteouts. Parent directories that the layer did not change keep the info they have
+// in the given (stacked) tree of all layers below.
+func newLayerTree(layer Layer, stacked *filetree.FileTree) (*filetree.FileTree, error) {
+	tree := filetree.NewFileTree()
+	tree.Name = layer.ID
+	tree.FileSize = layer.SizeBytes
+
+	removed := make(map[string]bool)
+	for _, entry := range layer.Changes.Removed {
+		removed[entry.Path] = true
+		// only the top-most removed path needs a whiteout
+		parent, name := path.Split(entry.Path)
+		if removed[path.Clean(parent)] {
+			continue
+		}
+		whiteout := path.Join(parent, ".wh."+name)
+		if _, _, err := tree.AddPath(whiteout, filetree.FileInfo{Path: strings.TrimPrefix(whiteout, "/")}); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, entries := range [][]FileEntry{layer.Changes.Added, layer.Changes.Modified} {
+		for _, entry := range entries {
+			info, err := entry.fileInfo()
+			if err != nil {
+				return nil, err
+			}
+			if _, _, err := tree.AddPath(entry.Path, info); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err := tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		if node.Data.FileInfo.Path != "" {
+			return nil
+		}
+		if lowerNode, _ := stacked.GetNode(node.Path()); lowerNode != nil {
+			node.Data.FileInfo = *lowerNode.Data.FileInfo.Copy()
+		}
+		return nil
+	}, nil)
+	return tree, err
+}
+
+func (entry FileEntry) fileInfo() (filetree.FileInfo, error) {
+	typeFlag, exists := fileTypeFlags[entry.Type]
+	if !exists {
+		return filetree.FileInfo{}, fmt.Errorf("unknown file type '%s' ('%s')", entry.Type, entry.Path)
+	}
+	bits, err := strconv.ParseUint(entry.Mode, 8, 32)
+	if err != nil {
+		return filetree.FileInfo{}, fmt.Errorf("invalid file mode '%s' ('%s')", entry.Mode, entry.Path)
+	}
+
+	mode := os.FileMode(bits & 0777)
+	if bits&04000 != 0 {
+		mode |= os.ModeSetuid
+	}
+	if bits&02000 != 0 {
+		mode |= os.ModeSetgid
+	}
+	if bits&01000 != 0 {
+		mode |= os.ModeSticky
+	}
+	switch typeFlag {
+	case tar.TypeDir:
+		mode |= os.ModeDir
+	case tar.TypeSymlink:
+		mode |= os.ModeSymlink
+	case tar.TypeChar:
+		mode |= os.ModeDevice | os.ModeCharDevice
+	case tar.TypeBlock:
+		mode |= os.ModeDevice
+	case tar.TypeFifo:
+		mode |= os.ModeNamedPipe
+	}
+
+	info := filetree.FileInfo{
+		Path:     strings.TrimPrefix(entry.Path, "/"),
+		TypeFlag: typeFlag,
+		Linkname: entry.LinkTarget,
+		Size:     entry.SizeBytes,
+		Mode:     mode,
+		Uid:      entry.Uid,
+		Gid:      entry.Gid,
+		IsDir:    typeFlag == tar.TypeDir,
+	}
+	if entry.Hash != "" {
+		hash, err := strconv.ParseUint(entry.Hash, 16, 64)
+		if err != nil {
+			return filetree.FileInfo{}, fmt.Errorf("invalid file hash '%s' ('%s')", entry.Hash, entry.Path)
+		}
+		info.SetHash(hash)
+	}
+	return info, nil
+}
+
+// inefficiencies rebuilds the inefficient files of the export (in ascending size, as analyzed), referring to the
+// nodes of the given layer trees. Since copies that a layer re-added without changes are not part of the export, the
+// last known node of a path stands in for them.
+func (exp *Export) inefficiencies(trees []*filetree.FileTree) filetree.EfficiencySlice {
+	result := make(filetree.EfficiencySlice, 0, len(exp.Image.InefficientFiles))
+	for idx := len(exp.Image.InefficientFiles) - 1; idx >= 0; idx-- {
+		file := exp.Image.InefficientFiles[idx]
+		data := &filetree.EfficiencyData{
+			Path:           file.Path,
+			Nodes:          make([]*filetree.FileNode, 0, file.References),
+			CumulativeSize: int64(file.SizeBytes),
+		}
+
+		parent, name := path.Split(file.Path)
+		for _, tree := range trees {
+			if node, _ := tree.GetNode(file.Path); node != nil {
+				data.Nodes = append(data.Nodes, node)
+			} else if node, _ := tree.GetNode(path.Join(parent, ".wh."+name)); node != nil {
+				data.Nodes = append(data.Nodes, node)
+			}
+		}
+		for len(data.Nodes) > 0 && len(data.Nodes) < file.References {
+			data.Nodes = append(data.Nodes, data.Nodes[len(data.Nodes)-1])
+		}
+		result = append(result, data)
+	}
+	return result
+}
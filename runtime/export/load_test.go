@@ -0,0 +1,103 @@
+package export
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func Test_ExportAnalysis(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	exp := NewExport(result)
+	if err := exp.AddLayerChanges(result); err != nil {
+		t.Fatalf("unable to add layer changes: %v", err)
+	}
+	payload, err := exp.Marshal()
+	if err != nil {
+		t.Fatalf("unable to marshal export: %v", err)
+	}
+	exp, err = Unmarshal(payload)
+	if err != nil {
+		t.Fatalf("unable to unmarshal export: %v", err)
+	}
+
+	analysis, err := exp.Analysis()
+	if err != nil {
+		t.Fatalf("unable to rebuild analysis: %v", err)
+	}
+
+	if len(analysis.Layers) != len(result.Layers) || len(analysis.RefTrees) != len(result.RefTrees) {
+		t.Fatalf("expected %d layers, got %d (%d trees)", len(result.Layers), len(analysis.Layers), len(analysis.RefTrees))
+	}
+	for idx, layer := range analysis.Layers {
+		expected := result.Layers[idx]
+		if layer.Id != expected.Id || layer.Digest != expected.Digest || layer.Command != expected.Command || layer.Size != expected.Size {
+			t.Errorf("layer %d: expected %+v, got %+v", idx, expected, layer)
+		}
+	}
+
+	if analysis.Efficiency != result.Efficiency || analysis.WastedBytes != result.WastedBytes || analysis.WastedUserPercent != result.WastedUserPercent {
+		t.Errorf("expected the statistics of the original analysis, got efficiency=%v wasted=%d userWasted=%v", analysis.Efficiency, analysis.WastedBytes, analysis.WastedUserPercent)
+	}
+	if len(analysis.Inefficiencies) != len(result.Inefficiencies) {
+		t.Fatalf("expected %d inefficient files, got %d", len(result.Inefficiencies), len(analysis.Inefficiencies))
+	}
+	for idx, data := range analysis.Inefficiencies {
+		expected := result.Inefficiencies[idx]
+		if data.Path != expected.Path || data.CumulativeSize != expected.CumulativeSize || len(data.Nodes) != len(expected.Nodes) {
+			t.Errorf("inefficiency %d: expected %s (%d bytes, %d copies), got %s (%d bytes, %d copies)", idx, expected.Path, expected.CumulativeSize, len(expected.Nodes), data.Path, data.CumulativeSize, len(data.Nodes))
+		}
+	}
+
+	// every layer shows the same changes as the original layer
+	expectedChanges, _, err := filetree.LayerChanges(result.RefTrees)
+	if err != nil {
+		t.Fatalf("unable to compare original layers: %v", err)
+	}
+	actualChanges, pathErrors, err := filetree.LayerChanges(analysis.RefTrees)
+	if err != nil || len(pathErrors) > 0 {
+		t.Fatalf("unable to compare rebuilt layers: %v %+v", err, pathErrors)
+	}
+	for idx := range expectedChanges {
+		if len(expectedChanges[idx]) != len(actualChanges[idx]) {
+			t.Errorf("layer %d: expected %d changes, got %d", idx, len(expectedChanges[idx]), len(actualChanges[idx]))
+			continue
+		}
+		for changeIdx, expected := range expectedChanges[idx] {
+			actual := actualChanges[idx][changeIdx]
+			if expected.Path != actual.Path || expected.DiffType != actual.DiffType || expected.FileInfo.Compare(actual.FileInfo) != filetree.Unmodified {
+				t.Errorf("layer %d: expected %s (%v), got %s (%v)", idx, expected.Path, expected.DiffType, actual.Path, actual.DiffType)
+			}
+		}
+	}
+
+	// the final filesystem is the same
+	expectedTree, _, _ := filetree.StackTreeRange(result.RefTrees, 0, len(result.RefTrees)-1)
+	actualTree, _, _ := filetree.StackTreeRange(analysis.RefTrees, 0, len(analysis.RefTrees)-1)
+	if !reflect.DeepEqual(treePaths(expectedTree), treePaths(actualTree)) {
+		t.Errorf("the rebuilt filesystem differs from the original filesystem")
+	}
+}
+
+func Test_ExportAnalysis_NotDetailed(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	_, err := NewExport(result).Analysis()
+	if err == nil || !strings.Contains(err.Error(), "--json-detail") {
+		t.Errorf("expected an error pointing to --json-detail, got %v", err)
+	}
+}
+
+// treePaths returns every path of the tree along with its (comparable) file info.
+func treePaths(tree *filetree.FileTree) map[string]FileEntry {
+	paths := make(map[string]FileEntry)
+	_ = tree.VisitDepthParentFirst(func(node *filetree.FileNode) error {
+		paths[node.Path()] = newFileEntry(node.Path(), node.Data.FileInfo)
+		return nil
+	}, nil)
+	return paths
+}
@@ -0,0 +1,127 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metricFamily is a single metric (with the samples of all images) in the Prometheus text format.
+type metricFamily struct {
+	name    string
+	help    string
+	samples []metricSample
+}
+
+// metricSample is a single line of a metric family, along with the image it belongs to.
+type metricSample struct {
+	image string
+	line  string
+}
+
+// Metrics renders the size statistics of one or more images in the Prometheus text format, e.g. for the textfile
+// collector of the node exporter or for a pushgateway. Every sample is labelled with the image, so an image has a single
+// series per metric (adding an image again replaces its samples).
+type Metrics struct {
+	topDirectories int
+	families       []*metricFamily
+}
+
+// NewMetrics reports the given number of largest directories (up to three levels below "/") of every image.
+func NewMetrics(topDirectories int) *Metrics {
+	families := []*metricFamily{
+		{name: "dive_image_size_bytes", help: "Total size of all layers of the image."},
+		{name: "dive_image_user_size_bytes", help: "Total size of the layers that are not part of the base image."},
+		{name: "dive_image_efficiency_ratio", help: "Image efficiency score (1 means that no bytes are wasted)."},
+		{name: "dive_image_wasted_bytes", help: "Bytes of files that are duplicated or removed across layers."},
+		{name: "dive_image_user_wasted_bytes", help: "Wasted bytes that involve the layers that are not part of the base image."},
+		{name: "dive_image_user_wasted_ratio", help: "User wasted bytes relative to the size of the user layers."},
+		{name: "dive_image_layer_count", help: "Number of layers of the image."},
+		{name: "dive_image_layer_size_bytes", help: "Size of a single layer of the image."},
+		{name: "dive_image_directory_size_bytes", help: "Total size of the files within one of the largest directories of the final filesystem."},
+	}
+	return &Metrics{topDirectories: topDirectories, families: families}
+}
+
+// Add reports the statistics of the given export, labelled with the given image reference. The samples of an image that
+// was added before are replaced.
+func (metrics *Metrics) Add(image string, exp *Export) {
+	metrics.remove(image)
+	labels := fmt.Sprintf(`image="%s"`, escapeLabel(image))
+
+	metrics.add(image, "dive_image_size_bytes", labels, float64(exp.Image.SizeBytes))
+	metrics.add(image, "dive_image_user_size_bytes", labels, float64(exp.Image.UserSizeBytes))
+	metrics.add(image, "dive_image_efficiency_ratio", labels, exp.Image.EfficiencyScore)
+	metrics.add(image, "dive_image_wasted_bytes", labels, float64(exp.Image.InefficientBytes))
+	metrics.add(image, "dive_image_user_wasted_bytes", labels, float64(exp.Image.UserInefficientBytes))
+	metrics.add(image, "dive_image_user_wasted_ratio", labels, exp.Image.UserWastedPercent)
+	metrics.add(image, "dive_image_layer_count", labels, float64(len(exp.Layer)))
+
+	for _, layer := range exp.Layer {
+		layerLabels := fmt.Sprintf(`%s,index="%d",digest="%s"`, labels, layer.Index, escapeLabel(layer.DigestID))
+		metrics.add(image, "dive_image_layer_size_bytes", layerLabels, float64(layer.SizeBytes))
+	}
+
+	directories := make([]DirectorySize, 0, len(exp.Image.Directories))
+	for _, directory := range exp.Image.Directories {
+		if directory.Path != "/" {
+			directories = append(directories, directory)
+		}
+	}
+	sort.SliceStable(directories, func(i, j int) bool {
+		return directories[i].SizeBytes > directories[j].SizeBytes
+	})
+	for idx, directory := range directories {
+		if idx >= metrics.topDirectories {
+			break
+		}
+		directoryLabels := fmt.Sprintf(`%s,path="%s"`, labels, escapeLabel(directory.Path))
+		metrics.add(image, "dive_image_directory_size_bytes", directoryLabels, float64(directory.SizeBytes))
+	}
+}
+
+func (metrics *Metrics) add(image, name, labels string, value float64) {
+	for _, family := range metrics.families {
+		if family.name == name {
+			line := fmt.Sprintf("%s{%s} %s", name, labels, strconv.FormatFloat(value, 'f', -1, 64))
+			family.samples = append(family.samples, metricSample{image: image, line: line})
+			return
+		}
+	}
+}
+
+// remove drops all samples of the given image.
+func (metrics *Metrics) remove(image string) {
+	for _, family := range metrics.families {
+		samples := family.samples[:0]
+		for _, sample := range family.samples {
+			if sample.image != image {
+				samples = append(samples, sample)
+			}
+		}
+		family.samples = samples
+	}
+}
+
+// Bytes renders all metrics, the samples of all images are grouped by metric.
+func (metrics *Metrics) Bytes() []byte {
+	var buffer bytes.Buffer
+	for _, family := range metrics.families {
+		if len(family.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buffer, "# HELP %s %s\n", family.name, family.help)
+		fmt.Fprintf(&buffer, "# TYPE %s gauge\n", family.name)
+		for _, sample := range family.samples {
+			buffer.WriteString(sample.line + "\n")
+		}
+	}
+	return buffer.Bytes()
+}
+
+// escapeLabel escapes a label value for the Prometheus text format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
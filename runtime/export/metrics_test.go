@@ -0,0 +1,85 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func Test_Metrics(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+	export := NewExport(result)
+	if err := export.AddDirectories(result); err != nil {
+		t.Fatalf("unable to add directories: %v", err)
+	}
+
+	metrics := NewMetrics(2)
+	metrics.Add("dive-example:latest", export)
+	metrics.Add("other\"image", export)
+	payload := string(metrics.Bytes())
+
+	expected := []string{
+		"# HELP dive_image_size_bytes Total size of all layers of the image.\n# TYPE dive_image_size_bytes gauge\n" +
+			"dive_image_size_bytes{image=\"dive-example:latest\"} 1220598\n" +
+			"dive_image_size_bytes{image=\"other\\\"image\"} 1220598\n",
+		"dive_image_efficiency_ratio{image=\"dive-example:latest\"} 0.98442",
+		"dive_image_wasted_bytes{image=\"dive-example:latest\"} 32025\n",
+		"dive_image_user_wasted_ratio{image=\"dive-example:latest\"} 0.48349",
+		"dive_image_layer_count{image=\"dive-example:latest\"} 14\n",
+		"dive_image_layer_size_bytes{image=\"dive-example:latest\",index=\"0\",digest=\"sha256:23bc2b70b2014dec0ac22f27bb93e9babd08cdd6f1115d0c955b9ff22b382f5a\"} 1154361\n",
+		"# TYPE dive_image_directory_size_bytes gauge\n" +
+			"dive_image_directory_size_bytes{image=\"dive-example:latest\",path=\"/bin\"} 1153344\n" +
+			"dive_image_directory_size_bytes{image=\"dive-example:latest\",path=\"/root\"} 21402\n" +
+			"dive_image_directory_size_bytes{image=\"other\\\"image\",path=\"/bin\"} 1153344\n",
+	}
+	for _, fragment := range expected {
+		if !strings.Contains(payload, fragment) {
+			t.Errorf("expected the metrics to contain %q, got:\n%s", fragment, payload)
+		}
+	}
+
+	if count := strings.Count(payload, "dive_image_layer_size_bytes{"); count != 28 {
+		t.Errorf("expected 28 layer samples, got %d", count)
+	}
+	if count := strings.Count(payload, "dive_image_directory_size_bytes{"); count != 4 {
+		t.Errorf("expected 4 directory samples, got %d", count)
+	}
+}
+
+func Test_Metrics_SameImage(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+	export := NewExport(result)
+	if err := export.AddDirectories(result); err != nil {
+		t.Fatalf("unable to add directories: %v", err)
+	}
+
+	metrics := NewMetrics(2)
+	metrics.Add("dive-example:latest", export)
+	metrics.Add("dive-example:latest", export)
+	payload := string(metrics.Bytes())
+
+	if count := strings.Count(payload, "dive_image_size_bytes{"); count != 1 {
+		t.Errorf("expected a single size sample, got %d", count)
+	}
+	if count := strings.Count(payload, "dive_image_layer_size_bytes{"); count != 14 {
+		t.Errorf("expected 14 layer samples, got %d", count)
+	}
+	if count := strings.Count(payload, "dive_image_directory_size_bytes{"); count != 2 {
+		t.Errorf("expected 2 directory samples, got %d", count)
+	}
+}
+
+func Test_EscapeLabel(t *testing.T) {
+	table := map[string]string{
+		"alpine:latest": "alpine:latest",
+		`quote"d`:       `quote\"d`,
+		`back\slash`:    `back\\slash`,
+		"multi\nline":   `multi\nline`,
+	}
+	for value, expected := range table {
+		if actual := escapeLabel(value); actual != expected {
+			t.Errorf("escapeLabel(%q): expected %q, got %q", value, expected, actual)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package export
+
+import (
+	"strings"
+
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+// Regression describes how an image analysis changed relative to a previously exported (baseline) analysis.
+type Regression struct {
+	BaselineSizeBytes      uint64           `json:"baselineSizeBytes"`
+	SizeDeltaBytes         int64            `json:"sizeDeltaBytes"`
+	EfficiencyDelta        float64          `json:"efficiencyDelta"`
+	InefficientBytesDelta  int64            `json:"inefficientBytesDelta"`
+	UserWastedPercentDelta float64          `json:"userWastedPercentDelta"`
+	AddedLayers            []Layer          `json:"addedLayers"`
+	RemovedLayers          []Layer          `json:"removedLayers"`
+	NewInefficientFiles    []FileReference  `json:"newInefficientFiles"`
+	Directories            []directoryDelta `json:"directories"`
+	DominantDirectory      string           `json:"dominantDirectory,omitempty"`
+}
+
+// NewRegression compares the current analysis against the baseline analysis. Layers are matched by digest, falling
+// back to the layer command (layers that were rebuilt without changes keep their command, but not their digest).
+func NewRegression(baseline, current *Export) *Regression {
+	regression := Regression{
+		BaselineSizeBytes:      baseline.Image.SizeBytes,
+		SizeDeltaBytes:         int64(current.Image.SizeBytes) - int64(baseline.Image.SizeBytes),
+		EfficiencyDelta:        current.Image.EfficiencyScore - baseline.Image.EfficiencyScore,
+		InefficientBytesDelta:  int64(current.Image.InefficientBytes) - int64(baseline.Image.InefficientBytes),
+		UserWastedPercentDelta: current.Image.UserWastedPercent - baseline.Image.UserWastedPercent,
+		NewInefficientFiles:    make([]FileReference, 0),
+		Directories:            make([]directoryDelta, 0),
+	}
+
+	regression.AddedLayers, regression.RemovedLayers = compareLayers(baseline.Layer, current.Layer)
+
+	baselineFiles := make(map[string]bool)
+	for _, file := range baseline.Image.InefficientFiles {
+		baselineFiles[file.Path] = true
+	}
+	for _, file := range current.Image.InefficientFiles {
+		if !baselineFiles[file.Path] {
+			regression.NewInefficientFiles = append(regression.NewInefficientFiles, file)
+		}
+	}
+
+	// legacy (unversioned) exports do not contain any directory sizes
+	if len(baseline.Image.Directories) > 0 && len(current.Image.Directories) > 0 {
+		deltas := diveImage.CompareDirectorySizes(directorySizes(baseline), directorySizes(current))
+		for _, delta := range deltas {
+			regression.Directories = append(regression.Directories, directoryDelta{
+				Path:            delta.Path,
+				BeforeSizeBytes: delta.BeforeSize,
+				AfterSizeBytes:  delta.AfterSize,
+				DeltaBytes:      delta.Delta(),
+			})
+		}
+		regression.DominantDirectory = dominantDirectory(regression.Directories)
+	}
+
+	return &regression
+}
+
+// compareLayers returns the layers that only exist in the current or only in the baseline analysis (without the file
+// changes of a detailed export).
+func compareLayers(baseline, current []Layer) (added, removed []Layer) {
+	matched := make([]bool, len(baseline))
+	unmatched := make([]Layer, 0)
+
+	for _, layer := range current {
+		found := false
+		for idx, candidate := range baseline {
+			if !matched[idx] && candidate.DigestID == layer.DigestID {
+				matched[idx], found = true, true
+				break
+			}
+		}
+		if !found {
+			unmatched = append(unmatched, layer)
+		}
+	}
+
+	added = make([]Layer, 0)
+	for _, layer := range unmatched {
+		found := false
+		for idx, candidate := range baseline {
+			if !matched[idx] && candidate.Command == layer.Command {
+				matched[idx], found = true, true
+				break
+			}
+		}
+		if !found {
+			layer.Changes = nil
+			added = append(added, layer)
+		}
+	}
+
+	removed = make([]Layer, 0)
+	for idx, layer := range baseline {
+		if !matched[idx] {
+			layer.Changes = nil
+			removed = append(removed, layer)
+		}
+	}
+	return added, removed
+}
+
+func directorySizes(exp *Export) map[string]int64 {
+	sizes := make(map[string]int64)
+	for _, dir := range exp.Image.Directories {
+		sizes[dir.Path] = dir.SizeBytes
+	}
+	return sizes
+}
+
+// dominantDirectory returns the deepest directory that accounts for at least half of the total size change (if any).
+func dominantDirectory(deltas []directoryDelta) string {
+	var total int64
+	for _, delta := range deltas {
+		if delta.Path == "/" {
+			total = delta.DeltaBytes
+		}
+	}
+	if total == 0 {
+		return ""
+	}
+
+	var dominant string
+	var depth int
+	for _, delta := range deltas {
+		// only consider directories that changed in the same direction as the whole image
+		if delta.Path == "/" || (delta.DeltaBytes < 0) != (total < 0) || abs(delta.DeltaBytes)*2 < abs(total) {
+			continue
+		}
+		if curDepth := strings.Count(delta.Path, "/"); curDepth > depth {
+			dominant, depth = delta.Path, curDepth
+		}
+	}
+	return dominant
+}
+
+func abs(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
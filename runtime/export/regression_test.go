@@ -0,0 +1,102 @@
+package export
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Unmarshal(t *testing.T) {
+	table := map[string]struct {
+		payload string
+		err     bool
+	}{
+		"legacy":  {payload: `{"layer": [], "image": {"sizeBytes": 10}}`},
+		"current": {payload: `{"version": 1, "layer": [], "image": {"sizeBytes": 10}}`},
+		"future":  {payload: `{"version": 2, "layer": [], "image": {"sizeBytes": 10}}`, err: true},
+		"invalid": {payload: `{"layer": 1}`, err: true},
+	}
+
+	for name, test := range table {
+		exp, err := Unmarshal([]byte(test.payload))
+		if test.err {
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", name, err)
+		}
+		if exp.Image.SizeBytes != 10 {
+			t.Errorf("%s: expected size 10, got %d", name, exp.Image.SizeBytes)
+		}
+	}
+}
+
+func Test_NewRegression(t *testing.T) {
+	baseline := &Export{
+		Layer: []Layer{
+			{DigestID: "sha256:base", Command: "ADD rootfs", SizeBytes: 100},
+			{DigestID: "sha256:deps-1", Command: "RUN install deps", SizeBytes: 50},
+			{DigestID: "sha256:old", Command: "RUN cleanup", SizeBytes: 1},
+		},
+		Image: Image{
+			SizeBytes:        151,
+			InefficientBytes: 10,
+			EfficiencyScore:  0.9,
+			InefficientFiles: []FileReference{{References: 2, SizeBytes: 10, Path: "/etc/old"}},
+			Directories: []DirectorySize{
+				{Path: "/", SizeBytes: 150},
+				{Path: "/usr", SizeBytes: 100},
+				{Path: "/usr/lib", SizeBytes: 80},
+				{Path: "/etc", SizeBytes: 50},
+			},
+		},
+	}
+	current := &Export{
+		Layer: []Layer{
+			{DigestID: "sha256:base", Command: "ADD rootfs", SizeBytes: 100},
+			{DigestID: "sha256:deps-2", Command: "RUN install deps", SizeBytes: 90},
+			{DigestID: "sha256:new", Command: "RUN install python", SizeBytes: 60},
+		},
+		Image: Image{
+			SizeBytes:        250,
+			InefficientBytes: 30,
+			EfficiencyScore:  0.8,
+			InefficientFiles: []FileReference{
+				{References: 2, SizeBytes: 20, Path: "/usr/lib/cache"},
+				{References: 2, SizeBytes: 10, Path: "/etc/old"},
+			},
+			Directories: []DirectorySize{
+				{Path: "/", SizeBytes: 250},
+				{Path: "/usr", SizeBytes: 200},
+				{Path: "/usr/lib", SizeBytes: 180},
+				{Path: "/etc", SizeBytes: 50},
+			},
+		},
+	}
+
+	regression := NewRegression(baseline, current)
+
+	if regression.SizeDeltaBytes != 99 {
+		t.Errorf("expected size delta 99, got %d", regression.SizeDeltaBytes)
+	}
+	if regression.InefficientBytesDelta != 20 {
+		t.Errorf("expected inefficient bytes delta 20, got %d", regression.InefficientBytesDelta)
+	}
+	if !reflect.DeepEqual(regression.AddedLayers, current.Layer[2:]) {
+		t.Errorf("unexpected added layers: %+v", regression.AddedLayers)
+	}
+	if !reflect.DeepEqual(regression.RemovedLayers, baseline.Layer[2:]) {
+		t.Errorf("unexpected removed layers: %+v", regression.RemovedLayers)
+	}
+	if !reflect.DeepEqual(regression.NewInefficientFiles, current.Image.InefficientFiles[:1]) {
+		t.Errorf("unexpected new inefficient files: %+v", regression.NewInefficientFiles)
+	}
+	if regression.DominantDirectory != "/usr/lib" {
+		t.Errorf("expected dominant directory '/usr/lib', got '%s'", regression.DominantDirectory)
+	}
+	if len(regression.Directories) != 3 {
+		t.Errorf("expected 3 changed directories, got %d", len(regression.Directories))
+	}
+}
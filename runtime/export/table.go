@@ -0,0 +1,97 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+)
+
+// Formats are the supported formats of the export (besides the default JSON payload).
+var Formats = []string{"csv-layers", "csv-files", "markdown"}
+
+// Format renders the export in the given format: the JSON payload ("json" or ""), the layer or the inefficient file
+// table as CSV, or both tables as Markdown.
+func (exp *Export) Format(format string) ([]byte, error) {
+	switch format {
+	case "", "json":
+		return exp.Marshal()
+	case "csv-layers":
+		return exp.LayersCSV()
+	case "csv-files":
+		return exp.InefficientFilesCSV()
+	case "markdown":
+		return exp.Markdown(), nil
+	default:
+		return nil, fmt.Errorf("unsupported export format: '%s' (supported: json, %s)", format, strings.Join(Formats, ", "))
+	}
+}
+
+// LayersCSV renders the layer table as CSV (with a header).
+func (exp *Export) LayersCSV() ([]byte, error) {
+	records := [][]string{{"index", "sizeBytes", "command", "digest"}}
+	for _, layer := range exp.Layer {
+		records = append(records, []string{strconv.Itoa(layer.Index), strconv.FormatUint(layer.SizeBytes, 10), strings.TrimSpace(layer.Command), layer.DigestID})
+	}
+	return writeCSV(records)
+}
+
+// InefficientFilesCSV renders the inefficient file table as CSV (with a header).
+func (exp *Export) InefficientFilesCSV() ([]byte, error) {
+	records := [][]string{{"count", "sizeBytes", "path"}}
+	for _, file := range exp.Image.InefficientFiles {
+		records = append(records, []string{strconv.Itoa(file.References), strconv.FormatUint(file.SizeBytes, 10), file.Path})
+	}
+	return writeCSV(records)
+}
+
+func writeCSV(records [][]string) ([]byte, error) {
+	var buffer bytes.Buffer
+	if err := csv.NewWriter(&buffer).WriteAll(records); err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// Markdown renders a summary of the image with the layer and inefficient file tables (e.g. for a pull request comment).
+func (exp *Export) Markdown() []byte {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "**Total Image size:** %s · **Efficiency:** %s %% · **Potential wasted space:** %s (%s in user layers)\n\n",
+		humanize.Bytes(exp.Image.SizeBytes), humanize.FtoaWithDigits(exp.Image.EfficiencyScore*100, 2),
+		humanize.Bytes(exp.Image.InefficientBytes), humanize.Bytes(exp.Image.UserInefficientBytes))
+
+	buffer.WriteString("### Layers\n\n")
+	buffer.WriteString("| Index | Size | Command | Digest |\n")
+	buffer.WriteString("| ---: | ---: | --- | --- |\n")
+	for _, layer := range exp.Layer {
+		fmt.Fprintf(&buffer, "| %d | %s | %s | %s |\n", layer.Index, humanize.Bytes(layer.SizeBytes), markdownCode(layer.Command), markdownCode(layer.DigestID))
+	}
+
+	buffer.WriteString("\n### Inefficient Files\n\n")
+	if len(exp.Image.InefficientFiles) == 0 {
+		buffer.WriteString("None\n")
+		return buffer.Bytes()
+	}
+	buffer.WriteString("| Count | Total Space | Path |\n")
+	buffer.WriteString("| ---: | ---: | --- |\n")
+	for _, file := range exp.Image.InefficientFiles {
+		fmt.Fprintf(&buffer, "| %d | %s | %s |\n", file.References, humanize.Bytes(file.SizeBytes), markdownCode(file.Path))
+	}
+	return buffer.Bytes()
+}
+
+// markdownCode renders the given text as a code span that can be placed in a table cell.
+func markdownCode(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if text == "" {
+		return ""
+	}
+	text = strings.Replace(text, "|", "\\|", -1)
+	if strings.Contains(text, "`") {
+		return "`` " + text + " ``"
+	}
+	return "`" + text + "`"
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func Test_ExportFormats(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+	export := NewExport(result)
+
+	table := map[string]struct {
+		format     string
+		expected   []string
+		unexpected string
+	}{
+		"csv-layers": {
+			format: "csv-layers",
+			expected: []string{
+				"index,sizeBytes,command,digest\n0,1154361,#(nop) ADD file:ce026b62356eec3ad1214f92be2c9dc063fe205bd5e600be3492c4dfb17148bd in /,sha256:23bc2b70b2014dec0ac22f27bb93e9babd08cdd6f1115d0c955b9ff22b382f5a\n",
+				"13,6405,chmod +x /root/saved.txt,sha256:",
+			},
+			unexpected: "count,sizeBytes,path",
+		},
+		"csv-files": {
+			format:     "csv-files",
+			expected:   []string{"count,sizeBytes,path\n2,12810,/root/saved.txt\n"},
+			unexpected: "index,sizeBytes,command,digest",
+		},
+		"markdown": {
+			format: "markdown",
+			expected: []string{
+				"**Total Image size:** 1.2 MB · **Efficiency:** 98.44 % · **Potential wasted space:** 32 kB (32 kB in user layers)\n",
+				"### Layers\n\n| Index | Size | Command | Digest |\n| ---: | ---: | --- | --- |\n",
+				"| 13 | 6.4 kB | `chmod +x /root/saved.txt` | `sha256:",
+				"### Inefficient Files\n\n| Count | Total Space | Path |\n| ---: | ---: | --- |\n| 2 | 13 kB | `/root/saved.txt` |\n",
+			},
+		},
+		"json": {
+			format:   "",
+			expected: []string{`"version": 1`},
+		},
+	}
+
+	for name, test := range table {
+		payload, err := export.Format(test.format)
+		if err != nil {
+			t.Fatalf("%s: unable to format export: %v", name, err)
+		}
+		for _, fragment := range test.expected {
+			if !strings.Contains(string(payload), fragment) {
+				t.Errorf("%s: expected the export to contain %q, got:\n%s", name, fragment, payload)
+			}
+		}
+		if test.unexpected != "" && strings.Contains(string(payload), test.unexpected) {
+			t.Errorf("%s: expected the export not to contain %q, got:\n%s", name, test.unexpected, payload)
+		}
+	}
+
+	if _, err := export.Format("xml"); err == nil {
+		t.Errorf("expected an error for an unsupported format")
+	}
+}
+
+func Test_MarkdownCode(t *testing.T) {
+	table := map[string]string{
+		"":                            "",
+		"apk add --no-cache curl":     "`apk add --no-cache curl`",
+		"echo a | tee b":              "`echo a \\| tee b`",
+		"echo `date`":                 "`` echo `date` ``",
+		"set -e;\n\tmake &&  install": "`set -e; make && install`",
+	}
+	for text, expected := range table {
+		if actual := markdownCode(text); actual != expected {
+			t.Errorf("expected %q, got %q", expected, actual)
+		}
+	}
+}
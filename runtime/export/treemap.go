@@ -0,0 +1,307 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/wagoodman/dive/dive/filetree"
+	diveImage "github.com/wagoodman/dive/dive/image"
+)
+
+const (
+	treemapWidth       = 1200
+	treemapHeight      = 800
+	treemapLabelHeight = 14
+	treemapLegendRow   = 18
+	// treemapMinSide is the smallest side (in pixels) of a path that is drawn, smaller paths only count towards the size
+	// of their directory
+	treemapMinSide = 2
+)
+
+// treemapRect is the area of a single path of the treemap.
+type treemapRect struct {
+	x, y, w, h float64
+}
+
+// treemapItem is a path that is laid out in the treemap.
+type treemapItem struct {
+	node *filetree.FileNode
+	size int64
+	rect treemapRect
+}
+
+// treemap renders the paths of a tree as nested rectangles, the area of every path is proportional to its size.
+type treemap struct {
+	sizes  map[*filetree.FileNode]int64
+	owners map[string]int // the index of the layer that provides the contents of each path
+	used   map[int]bool   // the layers that provide any of the shown files
+	layers []*diveImage.Layer
+	svg    strings.Builder
+}
+
+// NewTreemap renders an SVG treemap of the final filesystem of the analysis, colored by the layer that provides each
+// file. Given a layer index (not nil), only the files of that layer are shown instead.
+func NewTreemap(analysis *diveImage.AnalysisResult, layerIndex *int) ([]byte, error) {
+	if layerIndex != nil && (*layerIndex < 0 || *layerIndex >= len(analysis.RefTrees)) {
+		return nil, fmt.Errorf("invalid layer index: %d (image has %d layers)", *layerIndex, len(analysis.RefTrees))
+	}
+	if len(analysis.RefTrees) == 0 {
+		return nil, fmt.Errorf("image has no layers")
+	}
+
+	tm := treemap{
+		sizes:  make(map[*filetree.FileNode]int64),
+		owners: make(map[string]int),
+		used:   make(map[int]bool),
+		layers: analysis.Layers,
+	}
+
+	var tree *filetree.FileTree
+	notWhiteout := func(node *filetree.FileNode) bool {
+		return !node.IsWhiteout()
+	}
+	if layerIndex == nil {
+		var err error
+		tree, _, err = filetree.StackTreeRange(analysis.RefTrees, 0, len(analysis.RefTrees)-1)
+		if err != nil {
+			return nil, err
+		}
+		// later layers replace the contents of earlier layers
+		for idx, refTree := range analysis.RefTrees {
+			err = refTree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+				tm.owners[node.Path()] = idx
+				return nil
+			}, notWhiteout)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		tree = analysis.RefTrees[*layerIndex]
+		err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+			tm.owners[node.Path()] = *layerIndex
+			return nil
+		}, notWhiteout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// children are visited before their parents, so the size of every directory is complete once it is visited
+	err := tree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
+		if !node.Data.FileInfo.IsDir {
+			tm.sizes[node] += node.Data.FileInfo.Size
+			tm.used[tm.owners[node.Path()]] = true
+		}
+		if node.Parent != nil {
+			tm.sizes[node.Parent] += tm.sizes[node]
+		}
+		return nil
+	}, notWhiteout)
+	if err != nil {
+		return nil, err
+	}
+
+	legend := tm.legendLayers()
+	height := treemapHeight + treemapLegendRow*(len(legend)+1)
+
+	fmt.Fprintf(&tm.svg, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="monospace" font-size="10">`+"\n", treemapWidth, height, treemapWidth, height)
+	title := fmt.Sprintf("%s total", humanize.Bytes(uint64(tm.sizes[tree.Root])))
+	if layerIndex != nil {
+		title = fmt.Sprintf("layer %d: %s", *layerIndex, title)
+	}
+	fmt.Fprintf(&tm.svg, `<rect x="0" y="0" width="%d" height="%d" fill="#ffffff"/>`+"\n", treemapWidth, height)
+	fmt.Fprintf(&tm.svg, `<text x="4" y="11" font-weight="bold">/ (%s)</text>`+"\n", html.EscapeString(title))
+	tm.renderChildren(tree.Root, treemapRect{x: 0, y: treemapLabelHeight, w: treemapWidth, h: treemapHeight - treemapLabelHeight}, 0)
+	tm.renderLegend(legend, treemapHeight+treemapLegendRow/2)
+	tm.svg.WriteString("</svg>\n")
+
+	return []byte(tm.svg.String()), nil
+}
+
+// renderChildren lays out the children of the given directory within the given area.
+func (tm *treemap) renderChildren(node *filetree.FileNode, area treemapRect, depth int) {
+	items := make([]*treemapItem, 0, len(node.Children))
+	for _, child := range node.Children {
+		if size, exists := tm.sizes[child]; exists && size > 0 {
+			items = append(items, &treemapItem{node: child, size: size})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].size != items[j].size {
+			return items[i].size > items[j].size
+		}
+		return items[i].node.Name < items[j].node.Name
+	})
+	squarify(items, area)
+
+	for _, item := range items {
+		if item.rect.w < treemapMinSide || item.rect.h < treemapMinSide {
+			continue
+		}
+		tm.renderItem(item, depth)
+	}
+}
+
+// renderItem draws a file as a rectangle in the color of its layer, and a directory as an outline around its children.
+func (tm *treemap) renderItem(item *treemapItem, depth int) {
+	rect := item.rect
+	path := item.node.Path()
+	owner := tm.owners[path]
+	tooltip := fmt.Sprintf("%s (%s)", path, humanize.Bytes(uint64(item.size)))
+
+	if item.node.Data.FileInfo.IsDir || len(item.node.Children) > 0 {
+		fmt.Fprintf(&tm.svg, `<g><title>%s</title><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="#555555" stroke-width="0.5"/>`,
+			html.EscapeString(tooltip), rect.x, rect.y, rect.w, rect.h, directoryColor(depth))
+		inner := treemapRect{x: rect.x + 1, y: rect.y + 1, w: rect.w - 2, h: rect.h - 2}
+		if rect.h > 3*treemapLabelHeight && rect.w > 40 {
+			tm.renderLabel(item.node.Name, item.size, rect)
+			inner.y += treemapLabelHeight - 1
+			inner.h -= treemapLabelHeight - 1
+		}
+		tm.svg.WriteString("</g>\n")
+		tm.renderChildren(item.node, inner, depth+1)
+		return
+	}
+
+	if owner < len(tm.layers) {
+		tooltip += fmt.Sprintf(" layer %d: %s", owner, strings.TrimSpace(tm.layers[owner].Command))
+	}
+	fmt.Fprintf(&tm.svg, `<g><title>%s</title><rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s" stroke="#ffffff" stroke-width="0.5"/>`,
+		html.EscapeString(tooltip), rect.x, rect.y, rect.w, rect.h, layerColor(owner))
+	if rect.h > treemapLabelHeight && rect.w > 40 {
+		tm.renderLabel(item.node.Name, item.size, rect)
+	}
+	tm.svg.WriteString("</g>\n")
+}
+
+// renderLabel writes the name (and size, if there is room) at the top of the given area.
+func (tm *treemap) renderLabel(name string, size int64, rect treemapRect) {
+	// roughly 6 pixels per character at the given font size
+	maxChars := int(rect.w-4) / 6
+	label := fmt.Sprintf("%s (%s)", name, humanize.Bytes(uint64(size)))
+	if len(label) > maxChars {
+		label = name
+	}
+	if len(label) > maxChars {
+		if maxChars < 2 {
+			return
+		}
+		label = label[:maxChars-1] + "…"
+	}
+	fmt.Fprintf(&tm.svg, `<text x="%.1f" y="%.1f">%s</text>`, rect.x+2, rect.y+11, html.EscapeString(label))
+}
+
+// legendLayers returns the layers that provide any of the files of the treemap.
+func (tm *treemap) legendLayers() []int {
+	var legend []int
+	for idx := range tm.layers {
+		if tm.used[idx] {
+			legend = append(legend, idx)
+		}
+	}
+	return legend
+}
+
+func (tm *treemap) renderLegend(layers []int, y int) {
+	for row, idx := range layers {
+		top := y + row*treemapLegendRow
+		command := strings.TrimSpace(tm.layers[idx].Command)
+		if len(command) > 150 {
+			command = command[:149] + "…"
+		}
+		fmt.Fprintf(&tm.svg, `<rect x="4" y="%d" width="12" height="12" fill="%s"/><text x="22" y="%d">%d</text><text x="50" y="%d">%s</text><text x="110" y="%d">%s</text>`+"\n",
+			top, layerColor(idx), top+10, idx, top+10, humanize.Bytes(tm.layers[idx].Size), top+10, html.EscapeString(command))
+	}
+}
+
+// layerColor returns a distinct color for every layer index.
+func layerColor(index int) string {
+	// the golden angle spreads consecutive layers around the color wheel
+	hue := math.Mod(float64(index)*137.508, 360)
+	return fmt.Sprintf("hsl(%.0f,65%%,60%%)", hue)
+}
+
+// directoryColor returns the background of a directory, which darkens with the depth of the directory.
+func directoryColor(depth int) string {
+	lightness := 96 - 4*depth
+	if lightness < 60 {
+		lightness = 60
+	}
+	return fmt.Sprintf("hsl(0,0%%,%d%%)", lightness)
+}
+
+// squarify lays out the given items (ordered by descending size) within the given area, keeping the aspect ratio of
+// every item close to 1 (see "Squarified Treemaps" by Bruls, Huizing, and van Wijk).
+func squarify(items []*treemapItem, area treemapRect) {
+	var total int64
+	for _, item := range items {
+		total += item.size
+	}
+	if total == 0 || area.w <= 0 || area.h <= 0 {
+		return
+	}
+	scale := area.w * area.h / float64(total)
+
+	for len(items) > 0 {
+		side := math.Min(area.w, area.h)
+		count, best := 1, math.Inf(1)
+		for ; count <= len(items); count++ {
+			ratio := worstRatio(items[:count], side, scale)
+			if ratio > best {
+				break
+			}
+			best = ratio
+		}
+		count--
+
+		row := items[:count]
+		var rowSize int64
+		for _, item := range row {
+			rowSize += item.size
+		}
+		rowArea := float64(rowSize) * scale
+
+		if area.w >= area.h {
+			// a column along the left side
+			width := rowArea / area.h
+			y := area.y
+			for _, item := range row {
+				height := float64(item.size) * scale / width
+				item.rect = treemapRect{x: area.x, y: y, w: width, h: height}
+				y += height
+			}
+			area.x += width
+			area.w -= width
+		} else {
+			// a row along the top side
+			height := rowArea / area.w
+			x := area.x
+			for _, item := range row {
+				width := float64(item.size) * scale / height
+				item.rect = treemapRect{x: x, y: area.y, w: width, h: height}
+				x += width
+			}
+			area.y += height
+			area.h -= height
+		}
+		items = items[count:]
+	}
+}
+
+// worstRatio returns the largest aspect ratio of the given items when laid out along a side of the given length.
+func worstRatio(row []*treemapItem, side, scale float64) float64 {
+	var sum float64
+	minArea, maxArea := math.Inf(1), 0.0
+	for _, item := range row {
+		itemArea := float64(item.size) * scale
+		sum += itemArea
+		minArea = math.Min(minArea, itemArea)
+		maxArea = math.Max(maxArea, itemArea)
+	}
+	return math.Max(side*side*maxArea/(sum*sum), sum*sum/(side*side*minArea))
+}
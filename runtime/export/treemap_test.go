@@ -0,0 +1,123 @@
+package export
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/wagoodman/dive/dive/image/docker"
+)
+
+func Test_Treemap(t *testing.T) {
+	result := docker.TestAnalysisFromArchive(t, "../../.data/test-docker-image.tar")
+
+	layer := 7
+	invalidLayer := 14
+	table := map[string]struct {
+		layer    *int
+		legend   []string // the commands of the layers listed in the legend
+		expected []string
+		missing  []string
+	}{
+		"final-filesystem": {
+			layer: nil,
+			// the legend lists the layers that provide any of the files
+			legend: []string{
+				"#(nop) ADD file:ce026b62356eec3ad1214f92be2c9dc063fe205bd5e600be3492c4dfb17148bd in /",
+				"#(nop) ADD file:139c3708fb6261126453e34483abd8bf7b26ed16d952fd976994d68e72d93be2 in /somefile.txt",
+				"cp /root/saved.txt /root/.saved.txt",
+				"#(nop) ADD dir:7ec14b81316baa1a31c38c97686a8f030c98cba2035c968412749e33e0c4427e in /root/.data/",
+				"cp /root/saved.txt /tmp/saved.again1.txt",
+				"cp /root/saved.txt /root/.data/saved.again2.txt",
+				"chmod +x /root/saved.txt",
+			},
+			expected: []string{
+				"/ (1.2 MB total)",
+				// files are colored by the layer that provides them
+				`<title>/root/saved.txt (6.4 kB) layer 13: chmod +x /root/saved.txt</title>`,
+				`<title>/bin/[ (1.1 MB) layer 0: `,
+				`fill="hsl(348,65%,60%)"`,
+			},
+			missing: []string{
+				// removed files and layers that no longer provide any file
+				"/root/example",
+				"rm -rf /root/example/",
+			},
+		},
+		"single-layer": {
+			layer:  &layer,
+			legend: []string{"mv /root/example/somefile3.txt /root/saved.txt"},
+			expected: []string{
+				"/ (layer 7: 6.4 kB total)",
+				`<title>/root/saved.txt (6.4 kB) layer 7: mv /root/example/somefile3.txt /root/saved.txt</title>`,
+			},
+			missing: []string{
+				"/bin",
+				// the whiteout of the moved file
+				"somefile3.txt (",
+			},
+		},
+	}
+
+	for name, test := range table {
+		payload, err := NewTreemap(result, test.layer)
+		if err != nil {
+			t.Fatalf("%s: unable to render treemap: %v", name, err)
+		}
+		svg := string(payload)
+
+		// the legend (a color swatch and the index, size, and command of every layer) follows the background and
+		// the title of the treemap, all other elements are grouped by file
+		var document struct {
+			Rects []struct{} `xml:"rect"`
+			Texts []string   `xml:"text"`
+		}
+		if err := xml.Unmarshal(payload, &document); err != nil {
+			t.Fatalf("%s: invalid SVG document: %v", name, err)
+		}
+		if len(document.Rects) != len(test.legend)+1 {
+			t.Errorf("%s: expected %d legend swatches, got %d", name, len(test.legend), len(document.Rects)-1)
+		}
+		var legend []string
+		for idx := 3; idx < len(document.Texts); idx += 3 {
+			legend = append(legend, document.Texts[idx])
+		}
+		if strings.Join(legend, "\n") != strings.Join(test.legend, "\n") {
+			t.Errorf("%s: expected the legend %q, got %q", name, test.legend, legend)
+		}
+		for _, fragment := range test.expected {
+			if !strings.Contains(svg, fragment) {
+				t.Errorf("%s: expected the treemap to contain %q", name, fragment)
+			}
+		}
+		for _, fragment := range test.missing {
+			if strings.Contains(svg, fragment) {
+				t.Errorf("%s: expected the treemap not to contain %q", name, fragment)
+			}
+		}
+	}
+
+	if _, err := NewTreemap(result, &invalidLayer); err == nil {
+		t.Errorf("expected an error for an invalid layer index")
+	}
+}
+
+func Test_Squarify(t *testing.T) {
+	items := []*treemapItem{{size: 6}, {size: 6}, {size: 4}, {size: 3}, {size: 2}, {size: 2}, {size: 1}}
+	squarify(items, treemapRect{w: 6, h: 4})
+
+	var area float64
+	for idx, item := range items {
+		area += item.rect.w * item.rect.h
+		if item.rect.x < 0 || item.rect.y < 0 || item.rect.x+item.rect.w > 6.0001 || item.rect.y+item.rect.h > 4.0001 {
+			t.Errorf("item %d is outside of the area: %+v", idx, item.rect)
+		}
+		// the area of every item is proportional to its size (the total size fills the area exactly)
+		if expected := float64(item.size); item.rect.w*item.rect.h < expected-0.0001 || item.rect.w*item.rect.h > expected+0.0001 {
+			t.Errorf("item %d: expected an area of %v, got %v", idx, expected, item.rect.w*item.rect.h)
+		}
+	}
+	if area < 23.999 || area > 24.001 {
+		t.Errorf("expected the items to fill the area, got %v", area)
+	}
+}
@@ -0,0 +1,72 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/runtime/ui"
+	"github.com/wagoodman/dive/utils"
+)
+
+type LoadOptions struct {
+	ExportFile   string
+	IgnoreErrors bool
+}
+
+// runLoad rebuilds the analysis of a detailed export (see Options.ExportDetail) and explores it, without fetching the
+// image.
+func runLoad(enableUi bool, options LoadOptions, events eventChannel, filesystem afero.Fs) {
+	defer close(events)
+
+	events.message(utils.TitleFormat(fmt.Sprintf("Loading analysis from '%s'...", options.ExportFile)))
+	data, err := afero.ReadFile(filesystem, options.ExportFile)
+	if err != nil {
+		events.exitWithErrorMessage("cannot read export file", err)
+		return
+	}
+	exp, err := export.Unmarshal(data)
+	if err != nil {
+		events.exitWithErrorMessage("cannot read export file", err)
+		return
+	}
+	analysis, err := exp.Analysis()
+	if err != nil {
+		events.exitWithErrorMessage("cannot rebuild analysis", err)
+		return
+	}
+
+	events.message(utils.TitleFormat("Building cache..."))
+	treeStack := filetree.NewComparer(analysis.RefTrees)
+	errors := treeStack.BuildCache()
+	if errors != nil {
+		for _, err := range errors {
+			events.message("  " + err.Error())
+		}
+		if !options.IgnoreErrors {
+			events.exitWithError(fmt.Errorf("file tree has path errors (use '--ignore-errors' to attempt to continue)"))
+			return
+		}
+	}
+
+	if enableUi {
+		// see the note in run() regarding the termbox startup race
+		time.Sleep(100 * time.Millisecond)
+
+		err = ui.Run(filepath.Base(options.ExportFile), analysis, treeStack)
+		if err != nil {
+			events.exitWithError(err)
+			return
+		}
+	}
+}
+
+func RunLoad(options LoadOptions) {
+	var events = make(eventChannel)
+	go runLoad(true, options, events, afero.NewOsFs())
+	os.Exit(consumeEvents(events, newEventPrinter(OutputText, false)))
+}
@@ -0,0 +1,81 @@
+package runtime
+
+import (
+	"testing"
+
+	"github.com/lunixbochs/vtclean"
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/dive/image/docker"
+	"github.com/wagoodman/dive/runtime/export"
+)
+
+func TestRunLoad(t *testing.T) {
+	analysis := docker.TestAnalysisFromArchive(t, "../.data/test-docker-image.tar")
+
+	detailed := export.NewExport(analysis)
+	if err := detailed.AddLayerChanges(analysis); err != nil {
+		t.Fatalf("unable to add layer changes: %v", err)
+	}
+	detailedPayload, err := detailed.Marshal()
+	if err != nil {
+		t.Fatalf("unable to marshal export: %v", err)
+	}
+	payload, err := export.NewExport(analysis).Marshal()
+	if err != nil {
+		t.Fatalf("unable to marshal export: %v", err)
+	}
+
+	table := map[string]struct {
+		file   string
+		events []testEvent
+	}{
+		"detailed-export": {
+			file: "detailed.json",
+			events: []testEvent{
+				{stdout: "Loading analysis from 'detailed.json'...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Building cache...", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
+		"export-without-details": {
+			file: "analysis.json",
+			events: []testEvent{
+				{stdout: "Loading analysis from 'analysis.json'...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "cannot rebuild analysis", errorOnExit: true, errMessage: "the export does not contain the file changes of layer 0 (export the image with --json-detail)"},
+			},
+		},
+		"missing-file": {
+			file: "missing.json",
+			events: []testEvent{
+				{stdout: "Loading analysis from 'missing.json'...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "cannot read export file", errorOnExit: true, errMessage: "open missing.json: file does not exist"},
+			},
+		},
+	}
+
+	for name, test := range table {
+		var ec = make(eventChannel)
+		var events = make([]testEvent, 0)
+		var filesystem = afero.NewMemMapFs()
+		_ = afero.WriteFile(filesystem, "detailed.json", detailedPayload, 0644)
+		_ = afero.WriteFile(filesystem, "analysis.json", payload, 0644)
+
+		go runLoad(false, LoadOptions{ExportFile: test.file}, ec, filesystem)
+
+		for event := range ec {
+			events = append(events, newTestEvent(event))
+		}
+
+		if len(test.events) != len(events) {
+			t.Fatalf("%s.%s: expected # events='%v', got '%v': %+v", t.Name(), name, len(test.events), len(events), events)
+		}
+
+		for idx, actualEvent := range events {
+			actualEvent.stdout = vtclean.Clean(actualEvent.stdout, false)
+			actualEvent.stderr = vtclean.Clean(actualEvent.stderr, false)
+
+			if test.events[idx] != actualEvent {
+				t.Errorf("%s.%s: expected event %+v, got %+v", t.Name(), name, test.events[idx], actualEvent)
+			}
+		}
+	}
+}
@@ -1,16 +1,59 @@
 package runtime
 
 import (
+	"strings"
+
 	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
 )
 
 type Options struct {
-	Ci           bool
+	Ci             bool
+	Image          string
+	Source         dive.ImageSource
+	IgnoreErrors   bool
+	ExportFile     string
+	ExportFormat   string // json (the default), csv-layers, csv-files, or markdown (see export.Formats)
+	ExportDetail   bool   // include the file changes of every layer in the export
+	ExportTree     bool   // include the final filesystem in the export
+	HTMLFile       string
+	TreemapFile    string
+	TreemapLayer   *int // show only the files of the given layer in the treemap (nil for the final filesystem)
+	MetricsFile    string
+	MetricsTopDirs int    // the number of largest directories reported in the metrics
+	Output         string // the format of the events: text (the default) or jsonl (see Outputs)
+	CiConfig       *viper.Viper
+	BuildArgs      []string
+	BaseImage      string
+	BaseLayers     int
+	BaselineFile   string
+	CiReportFormat string
+	CiReportFile   string
+	CiImages       []CiImage // evaluate several images in a single CI run (instead of Image)
+}
+
+// CiImage is one of several images that are evaluated in a single CI run.
+type CiImage struct {
 	Image        string
 	Source       dive.ImageSource
-	IgnoreErrors bool
-	ExportFile   string
 	CiConfig     *viper.Viper
-	BuildArgs    []string
+	CiConfigFile string // the file the CI config was read from ("" when the shared CI config is used)
+}
+
+// imageName describes the analyzed image in reports (the build arguments when the image was built).
+func (options Options) imageName() string {
+	if options.Image == "" {
+		return strings.Join(options.BuildArgs, " ")
+	}
+	return options.Image
+}
+
+// writesToStdout returns true if any of the outputs is written to stdout (see StdoutPath).
+func (options Options) writesToStdout() bool {
+	for _, path := range []string{options.ExportFile, options.HTMLFile, options.TreemapFile, options.MetricsFile, options.CiReportFile} {
+		if path == StdoutPath {
+			return true
+		}
+	}
+	return false
 }
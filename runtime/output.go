@@ -0,0 +1,56 @@
+package runtime
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// StdoutPath is given instead of a file name to write an output to stdout.
+const StdoutPath = "-"
+
+// outputName describes where an output is written to in progress messages.
+func outputName(path string) string {
+	if path == StdoutPath {
+		return "stdout"
+	}
+	return fmt.Sprintf("'%s'", path)
+}
+
+// writeOutput writes the given data to stdout (see StdoutPath) or to the given file.
+func writeOutput(path string, data []byte, events eventChannel, filesystem afero.Fs) error {
+	if path == StdoutPath {
+		events.data(data)
+		return nil
+	}
+	return writeFileAtomic(filesystem, path, data, 0644)
+}
+
+// writeFileAtomic replaces the given file with the given data. The data is written to a temporary file in the same
+// directory that is then renamed, so the file is never seen partially written (e.g. by the node exporter) and a
+// previous, larger file leaves no stale bytes behind.
+func writeFileAtomic(filesystem afero.Fs, path string, data []byte, perm os.FileMode) error {
+	file, err := afero.TempFile(filesystem, filepath.Dir(path), "."+filepath.Base(path)+".")
+	if err != nil {
+		return err
+	}
+	tempPath := file.Name()
+
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = filesystem.Chmod(tempPath, perm)
+	}
+	if err == nil {
+		err = filesystem.Rename(tempPath, path)
+	}
+	if err != nil {
+		_ = filesystem.Remove(tempPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package runtime
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/lunixbochs/vtclean"
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/dive"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	filesystem := afero.NewMemMapFs()
+
+	if err := filesystem.Mkdir("out", 0755); err != nil {
+		t.Fatalf("unable to create directory: %v", err)
+	}
+	for _, contents := range []string{"a much longer previous export", "short"} {
+		if err := writeFileAtomic(filesystem, "out/analysis.json", []byte(contents), 0644); err != nil {
+			t.Fatalf("unable to write file: %v", err)
+		}
+	}
+
+	actual, err := afero.ReadFile(filesystem, "out/analysis.json")
+	if err != nil {
+		t.Fatalf("unable to read file: %v", err)
+	}
+	if string(actual) != "short" {
+		t.Errorf("expected the file to be replaced, got '%s'", actual)
+	}
+
+	info, err := filesystem.Stat("out/analysis.json")
+	if err != nil {
+		t.Fatalf("unable to stat file: %v", err)
+	}
+	if info.Mode().Perm() != 0644 {
+		t.Errorf("expected mode 0644, got %v", info.Mode().Perm())
+	}
+
+	files, err := afero.ReadDir(filesystem, "out")
+	if err != nil {
+		t.Fatalf("unable to read directory: %v", err)
+	}
+	if len(files) != 1 {
+		t.Errorf("expected no temporary files to remain, got %d files", len(files))
+	}
+}
+
+func TestRunExportToStdout(t *testing.T) {
+	var ec = make(eventChannel)
+	var filesystem = afero.NewMemMapFs()
+	options := Options{
+		Image:      "dive-example",
+		Source:     dive.SourceDockerEngine,
+		ExportFile: StdoutPath,
+	}
+
+	go run(false, options, &defaultResolver{}, ec, filesystem)
+
+	var messages []string
+	var data strings.Builder
+	for event := range ec {
+		if event.errorOnExit {
+			t.Fatalf("unexpected error: %s %v", event.stderr, event.err)
+		}
+		if event.stdout != "" {
+			messages = append(messages, vtclean.Clean(event.stdout, false))
+		}
+		data.WriteString(event.data)
+	}
+
+	if messages[len(messages)-1] != "Exporting image to stdout..." {
+		t.Errorf("expected export message, got '%s'", messages[len(messages)-1])
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal([]byte(data.String()), &payload); err != nil {
+		t.Fatalf("expected the export on stdout, got '%s': %v", data.String(), err)
+	}
+	if _, exists := payload["layer"]; !exists {
+		t.Errorf("expected the export to contain the layers, got: %s", data.String())
+	}
+
+	if exists, _ := afero.Exists(filesystem, StdoutPath); exists {
+		t.Errorf("expected no file to be written")
+	}
+}
@@ -0,0 +1,60 @@
+package runtime
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/afero"
+	"github.com/wagoodman/dive/runtime/export"
+	"github.com/wagoodman/dive/utils"
+)
+
+// loadBaseline reads a previously exported analysis (see --json) from the given file.
+func loadBaseline(filesystem afero.Fs, path string) (*export.Export, error) {
+	data, err := afero.ReadFile(filesystem, path)
+	if err != nil {
+		return nil, err
+	}
+	return export.Unmarshal(data)
+}
+
+// renderRegression reports how the analysis changed relative to the baseline analysis.
+func renderRegression(baselineFile string, regression *export.Regression) string {
+	var report strings.Builder
+	report.WriteString(utils.TitleFormat(fmt.Sprintf("Regression (vs '%s'):", baselineFile)) + "\n")
+	report.WriteString("  " + regressionHeadline(regression) + "\n")
+	report.WriteString(fmt.Sprintf("  sizeDelta: %s\n", signedBytes(regression.SizeDeltaBytes)))
+	report.WriteString(fmt.Sprintf("  efficiencyDelta: %+2.4f %%\n", regression.EfficiencyDelta*100))
+	report.WriteString(fmt.Sprintf("  wastedBytesDelta: %s\n", signedBytes(regression.InefficientBytesDelta)))
+	report.WriteString(fmt.Sprintf("  userWastedPercentDelta: %+2.4f %%\n", regression.UserWastedPercentDelta*100))
+	report.WriteString(fmt.Sprintf("  layers: %d added, %d removed\n", len(regression.AddedLayers), len(regression.RemovedLayers)))
+	for _, layer := range regression.AddedLayers {
+		report.WriteString(fmt.Sprintf("    + %7s  %s\n", humanize.Bytes(layer.SizeBytes), strings.TrimSpace(layer.Command)))
+	}
+	for _, layer := range regression.RemovedLayers {
+		report.WriteString(fmt.Sprintf("    - %7s  %s\n", humanize.Bytes(layer.SizeBytes), strings.TrimSpace(layer.Command)))
+	}
+	report.WriteString(fmt.Sprintf("  newInefficientFiles: %d", len(regression.NewInefficientFiles)))
+	for _, file := range regression.NewInefficientFiles {
+		report.WriteString(fmt.Sprintf("\n    %7s  %s", humanize.Bytes(file.SizeBytes), file.Path))
+	}
+	return report.String()
+}
+
+// regressionHeadline summarizes the size change in a single sentence (e.g. "image grew by 48 MB, mostly /usr/lib").
+func regressionHeadline(regression *export.Regression) string {
+	var headline string
+	switch {
+	case regression.SizeDeltaBytes > 0:
+		headline = "image grew by " + humanize.Bytes(uint64(regression.SizeDeltaBytes))
+	case regression.SizeDeltaBytes < 0:
+		headline = "image shrank by " + humanize.Bytes(uint64(-regression.SizeDeltaBytes))
+	default:
+		return "image size is unchanged"
+	}
+	if regression.DominantDirectory != "" {
+		headline += ", mostly " + regression.DominantDirectory
+	}
+	return headline
+}
@@ -5,9 +5,11 @@ import (
 	"github.com/dustin/go-humanize"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/afero"
+	"github.com/spf13/viper"
 	"github.com/wagoodman/dive/dive"
 	"github.com/wagoodman/dive/dive/filetree"
 	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/dive/image/docker"
 	"github.com/wagoodman/dive/runtime/ci"
 	"github.com/wagoodman/dive/runtime/export"
 	"github.com/wagoodman/dive/runtime/ui"
@@ -25,7 +27,8 @@ func run(enableUi bool, options Options, imageResolver image.Resolver, events ev
 	doBuild := len(options.BuildArgs) > 0
 
 	if doBuild {
-		events.message(utils.TitleFormat("Building image..."))
+		events.buildStarted(utils.TitleFormat("Building image..."), options.BuildArgs)
+		reportParsedLayers(imageResolver, options.imageName(), events)
 		img, err = imageResolver.Build(options.BuildArgs)
 		if err != nil {
 			events.exitWithErrorMessage("cannot build image", err)
@@ -33,7 +36,8 @@ func run(enableUi bool, options Options, imageResolver image.Resolver, events ev
 		}
 	} else {
 		events.message(utils.TitleFormat("Image Source: ") + options.Source.String() + "://" + options.Image)
-		events.message(utils.TitleFormat("Fetching image...") + " (this can take a while for large images)")
+		events.fetchStarted(utils.TitleFormat("Fetching image...")+" (this can take a while for large images)", options.Source, options.Image)
+		reportParsedLayers(imageResolver, options.Image, events)
 		img, err = imageResolver.Fetch(options.Image)
 		if err != nil {
 			events.exitWithErrorMessage("cannot fetch image", err)
@@ -41,45 +45,100 @@ func run(enableUi bool, options Options, imageResolver image.Resolver, events ev
 		}
 	}
 
-	events.message(utils.TitleFormat("Analyzing image..."))
-	analysis, err := img.Analyze()
-	if err != nil {
-		events.exitWithErrorMessage("cannot analyze image", err)
+	events.imageFetched(options.imageName(), img)
+
+	analysis := analyzeImage(img, options, options.Source, imageResolver, events)
+	if analysis == nil {
+		return
+	}
+	events.analysisComplete(options.imageName(), analysis)
+
+	baselineFile := options.BaselineFile
+	if baselineFile == "" && options.Ci && options.CiConfig != nil {
+		baselineFile = options.CiConfig.GetString("baseline")
+	}
+
+	// the export is only built when needed, as it requires stacking all layers
+	var exp *export.Export
+	var regression *export.Regression
+	if doExport || baselineFile != "" || options.MetricsFile != "" {
+		exp = newExport(analysis, events)
+		if exp == nil {
+			return
+		}
+		if baselineFile != "" && !compareToBaseline(exp, baselineFile, events, filesystem) {
+			return
+		}
+		regression = exp.Regression
+	}
+
+	if options.HTMLFile != "" && !writeHTMLReport(options, analysis, events, filesystem) {
+		return
+	}
+	if options.TreemapFile != "" && !writeTreemap(options, analysis, events, filesystem) {
+		return
+	}
+	if options.MetricsFile != "" {
+		metrics := export.NewMetrics(options.MetricsTopDirs)
+		metrics.Add(options.imageName(), exp)
+		if !writeMetrics(options, metrics, events, filesystem) {
+			return
+		}
+	}
+	// like the export, the reports (and the event stream) replace the UI
+	doReport := options.HTMLFile != "" || options.TreemapFile != "" || options.MetricsFile != "" || options.Output == OutputJSONLines
+	if doReport && !doExport && !options.Ci {
 		return
 	}
 
 	if doExport {
-		events.message(utils.TitleFormat(fmt.Sprintf("Exporting image to '%s'...", options.ExportFile)))
-		bytes, err := export.NewExport(analysis).Marshal()
+		events.message(utils.TitleFormat(fmt.Sprintf("Exporting image to %s...", outputName(options.ExportFile))))
+		if options.ExportDetail {
+			if err := exp.AddLayerChanges(analysis); err != nil {
+				events.exitWithErrorMessage("cannot export layer changes", err)
+				return
+			}
+		}
+		if options.ExportTree {
+			if err := exp.AddTree(analysis); err != nil {
+				events.exitWithErrorMessage("cannot export file tree", err)
+				return
+			}
+		}
+		bytes, err := exp.Format(options.ExportFormat)
 		if err != nil {
 			events.exitWithErrorMessage("cannot marshal export payload", err)
 			return
 		}
 
-		file, err := filesystem.OpenFile(options.ExportFile, os.O_RDWR|os.O_CREATE, 0644)
-		if err != nil {
-			events.exitWithErrorMessage("cannot open export file", err)
+		if err := writeOutput(options.ExportFile, bytes, events, filesystem); err != nil {
+			events.exitWithErrorMessage("cannot write to export file", err)
 			return
 		}
-		defer file.Close()
 
-		_, err = file.Write(bytes)
-		if err != nil {
-			events.exitWithErrorMessage("cannot write to export file", err)
+		if regression != nil {
+			events.message(renderRegression(baselineFile, regression))
 		}
 		return
 	}
 
 	if options.Ci {
-		events.message(fmt.Sprintf("  efficiency: %2.4f %%", analysis.Efficiency*100))
-		events.message(fmt.Sprintf("  wastedBytes: %d bytes (%s)", analysis.WastedBytes, humanize.Bytes(analysis.WastedBytes)))
-		events.message(fmt.Sprintf("  userWastedPercent: %2.4f %%", analysis.WastedUserPercent*100))
+		evaluator, pass := evaluateCi(options.CiConfig, analysis, regression, baselineFile, events)
+		events.ciRuleResults(options.imageName(), evaluator)
 
-		evaluator := ci.NewCiEvaluator(options.CiConfig)
-		pass := evaluator.Evaluate(analysis)
-		events.message(evaluator.Report())
+		// the text report is replaced by the machine-readable report, unless the latter is written to a file
+		if options.CiReportFormat == "" || options.CiReportFile != "" {
+			events.message(evaluator.Report())
+		}
+		if options.CiReportFormat != "" {
+			if !writeCiReport(options, evaluator, events, filesystem) {
+				return
+			}
+		}
 
-		if !pass {
+		if evaluator.Misconfigured {
+			events.exitWithCode(ci.ExitCodeMisconfigured)
+		} else if !pass {
 			events.exitWithError(nil)
 		}
 
@@ -116,44 +175,209 @@ func run(enableUi bool, options Options, imageResolver image.Resolver, events ev
 	}
 }
 
-func Run(options Options) {
-	var exitCode int
-	var events = make(eventChannel)
+// layerProgressResolver is a resolver that reports every layer while it is parsed.
+type layerProgressResolver interface {
+	SetLayerParsedFunc(layerParsed docker.LayerParsedFunc)
+}
 
+// reportParsedLayers sends a layer-parsed event for every layer of the given image that the resolver parses (when it
+// supports it), it has to be called before fetching (or building) the image.
+func reportParsedLayers(resolver image.Resolver, name string, events eventChannel) {
+	progressResolver, ok := resolver.(layerProgressResolver)
+	if !ok {
+		return
+	}
+	var parsed int
+	progressResolver.SetLayerParsedFunc(func(layerPath string) {
+		parsed++
+		events.layerParsed(name, layerPath, parsed)
+	})
+}
+
+// analyzeImage analyzes the image with the base image boundary given in the options (the base image is fetched from
+// the given source), nil is returned if the image could not be analyzed (which has been reported already).
+func analyzeImage(img *image.Image, options Options, source dive.ImageSource, imageResolver image.Resolver, events eventChannel) *image.AnalysisResult {
+	baseLayers := options.BaseLayers
+	if options.BaseImage != "" {
+		events.fetchStarted(utils.TitleFormat("Fetching base image...")+" "+options.BaseImage, source, options.BaseImage)
+		reportParsedLayers(imageResolver, options.BaseImage, events)
+		baseImg, err := imageResolver.Fetch(options.BaseImage)
+		if err != nil {
+			events.exitWithErrorMessage("cannot fetch base image", err)
+			return nil
+		}
+		events.imageFetched(options.BaseImage, baseImg)
+		baseLayers = image.BaseLayerCount(img, baseImg)
+		if baseLayers == 0 {
+			events.exitWithError(fmt.Errorf("image does not share any layers with base image '%s'", options.BaseImage))
+			return nil
+		}
+	}
+	if baseLayers == 0 {
+		baseLayers = 1
+	}
+
+	events.message(utils.TitleFormat("Analyzing image..."))
+	analysis, err := img.AnalyzeWithBaseLayers(baseLayers)
+	if err != nil {
+		events.exitWithErrorMessage("cannot analyze image", err)
+		return nil
+	}
+	return analysis
+}
+
+// newExport creates the export of the analysis, nil is returned if the export could not be created (which has been
+// reported already).
+func newExport(analysis *image.AnalysisResult, events eventChannel) *export.Export {
+	exp := export.NewExport(analysis)
+	if err := exp.AddDirectories(analysis); err != nil {
+		events.exitWithErrorMessage("cannot export directory sizes", err)
+		return nil
+	}
+	return exp
+}
+
+// compareToBaseline adds the regression relative to the given baseline export to the export, false is returned if the
+// baseline could not be read (which has been reported already).
+func compareToBaseline(exp *export.Export, baselineFile string, events eventChannel, filesystem afero.Fs) bool {
+	baseline, err := loadBaseline(filesystem, baselineFile)
+	if err != nil {
+		events.exitWithErrorMessage("cannot read baseline", err)
+		return false
+	}
+	exp.Regression = export.NewRegression(baseline, exp)
+	return true
+}
+
+// evaluateCi reports the analysis statistics and evaluates the CI rules against the analysis.
+func evaluateCi(config *viper.Viper, analysis *image.AnalysisResult, regression *export.Regression, baselineFile string, events eventChannel) (*ci.CiEvaluator, bool) {
+	if analysis.BaseLayers != 1 {
+		events.message(fmt.Sprintf("  baseLayers: %d", analysis.BaseLayers))
+	}
+	events.message(fmt.Sprintf("  efficiency: %2.4f %%", analysis.Efficiency*100))
+	events.message(fmt.Sprintf("  wastedBytes: %d bytes (%s)", analysis.WastedBytes, humanize.Bytes(analysis.WastedBytes)))
+	events.message(fmt.Sprintf("  userWastedPercent: %2.4f %%", analysis.WastedUserPercent*100))
+
+	if regression != nil {
+		events.message(renderRegression(baselineFile, regression))
+	}
+
+	evaluator := ci.NewCiEvaluator(config)
+	if regression != nil {
+		evaluator.SetRegression(regression)
+	}
+	pass := evaluator.Evaluate(analysis)
+	return evaluator, pass
+}
+
+// writeCiReport renders the CI results in the requested format, either to the report file or to stdout. False is
+// returned if the report could not be written (which has been reported already).
+func writeCiReport(options Options, evaluator *ci.CiEvaluator, events eventChannel, filesystem afero.Fs) bool {
+	report, err := evaluator.FormatReport(options.CiReportFormat, options.imageName())
+	if err != nil {
+		events.exitWithErrorMessage("cannot render CI report", err)
+		return false
+	}
+	return outputCiReport(options, report, events, filesystem)
+}
+
+// writeHTMLReport writes the HTML report of the analysis, false is returned if the report could not be written (which
+// has been reported already).
+func writeHTMLReport(options Options, analysis *image.AnalysisResult, events eventChannel, filesystem afero.Fs) bool {
+	events.message(utils.TitleFormat(fmt.Sprintf("Writing HTML report to %s...", outputName(options.HTMLFile))))
+	report, err := export.NewHTMLReport(options.imageName(), analysis)
+	if err != nil {
+		events.exitWithErrorMessage("cannot render HTML report", err)
+		return false
+	}
+	if err := writeOutput(options.HTMLFile, report, events, filesystem); err != nil {
+		events.exitWithErrorMessage("cannot write HTML report", err)
+		return false
+	}
+	return true
+}
+
+// writeTreemap writes the SVG treemap of the analysis, false is returned if the treemap could not be written (which
+// has been reported already).
+func writeTreemap(options Options, analysis *image.AnalysisResult, events eventChannel, filesystem afero.Fs) bool {
+	events.message(utils.TitleFormat(fmt.Sprintf("Writing treemap to %s...", outputName(options.TreemapFile))))
+	treemap, err := export.NewTreemap(analysis, options.TreemapLayer)
+	if err != nil {
+		events.exitWithErrorMessage("cannot render treemap", err)
+		return false
+	}
+	if err := writeOutput(options.TreemapFile, treemap, events, filesystem); err != nil {
+		events.exitWithErrorMessage("cannot write treemap", err)
+		return false
+	}
+	return true
+}
+
+// writeMetrics writes the given metrics in the Prometheus text format, false is returned if the metrics could not be
+// written (which has been reported already).
+func writeMetrics(options Options, metrics *export.Metrics, events eventChannel, filesystem afero.Fs) bool {
+	events.message(utils.TitleFormat(fmt.Sprintf("Writing metrics to %s...", outputName(options.MetricsFile))))
+	if err := writeOutput(options.MetricsFile, metrics.Bytes(), events, filesystem); err != nil {
+		events.exitWithErrorMessage("cannot write metrics", err)
+		return false
+	}
+	return true
+}
+
+// outputCiReport writes the rendered CI report to the report file, or to stdout if there is none.
+func outputCiReport(options Options, report []byte, events eventChannel, filesystem afero.Fs) bool {
+	if options.CiReportFile == "" {
+		events.message(string(report))
+		return true
+	}
+
+	events.message(utils.TitleFormat(fmt.Sprintf("Writing %s CI report to %s...", options.CiReportFormat, outputName(options.CiReportFile))))
+	if err := writeOutput(options.CiReportFile, report, events, filesystem); err != nil {
+		events.exitWithErrorMessage("cannot write CI report", err)
+		return false
+	}
+	return true
+}
+
+func Run(options Options) {
 	imageResolver, err := dive.GetImageResolver(options.Source)
 	if err != nil {
-		message := "cannot determine image provider"
-		logrus.Error(message)
-		logrus.Error(err)
-		fmt.Fprintf(os.Stderr, "%s: %+v\n", message, err)
-		os.Exit(1)
+		exitWithResolverError(err)
 	}
 
+	var events = make(eventChannel)
 	go run(true, options, imageResolver, events, afero.NewOsFs())
+	os.Exit(consumeEvents(events, newEventPrinter(options.Output, options.writesToStdout())))
+}
+
+// exitWithResolverError reports that no image resolver could be found and exits.
+func exitWithResolverError(err error) {
+	message := "cannot determine image provider"
+	logrus.Error(message)
+	logrus.Error(err)
+	fmt.Fprintf(os.Stderr, "%s: %+v\n", message, err)
+	os.Exit(1)
+}
 
+// consumeEvents prints all events from the given channel with the given printer until the channel is closed, returning
+// the resulting exit code.
+func consumeEvents(events eventChannel, printer eventPrinter) int {
+	var exitCode int
 	for event := range events {
-		if event.stdout != "" {
-			fmt.Println(event.stdout)
+		if event.err != nil {
+			logrus.Error(event.err)
 		}
 
-		if event.stderr != "" {
-			_, err := fmt.Fprintln(os.Stderr, event.stderr)
-			if err != nil {
-				fmt.Println("error: could not write to buffer:", err)
-			}
+		if err := printer.print(event); err != nil {
+			fmt.Fprintln(os.Stderr, "error: could not write to buffer:", err)
 		}
 
-		if event.err != nil {
-			logrus.Error(event.err)
-			_, err := fmt.Fprintln(os.Stderr, event.err.Error())
-			if err != nil {
-				fmt.Println("error: could not write to buffer:", err)
+		if event.errorOnExit && exitCode != ci.ExitCodeMisconfigured {
+			exitCode = ci.ExitCodeFailed
+			if event.exitCode != 0 {
+				exitCode = event.exitCode
 			}
 		}
-
-		if event.errorOnExit {
-			exitCode = 1
-		}
 	}
-	os.Exit(exitCode)
+	return exitCode
 }
@@ -8,20 +8,30 @@ import (
 	"github.com/wagoodman/dive/dive"
 	"github.com/wagoodman/dive/dive/image"
 	"github.com/wagoodman/dive/dive/image/docker"
+	"github.com/wagoodman/dive/runtime/ci"
+	"io"
 	"os"
 	"testing"
 )
 
-type defaultResolver struct{}
+type defaultResolver struct {
+	layerParsed docker.LayerParsedFunc
+}
 
 func (r *defaultResolver) Fetch(id string) (*image.Image, error) {
-	archive, err := docker.TestLoadArchive("../.data/test-docker-image.tar")
+	archive, err := docker.NewImageArchiveWithCache(func() (io.ReadCloser, error) {
+		return os.Open("../.data/test-docker-image.tar")
+	}, nil, r.layerParsed)
 	if err != nil {
 		return nil, err
 	}
 	return archive.ToImage()
 }
 
+func (r *defaultResolver) SetLayerParsedFunc(layerParsed docker.LayerParsedFunc) {
+	r.layerParsed = layerParsed
+}
+
 func (r *defaultResolver) Build(args []string) (*image.Image, error) {
 	return r.Fetch("")
 }
@@ -88,10 +98,48 @@ func configureCi() *viper.Viper {
 	return ciConfig
 }
 
+// testBaseline is an export of the first two layers of the test image
+const testBaseline = `{
+  "version": 1,
+  "layer": [
+    {
+      "index": 0,
+      "id": "28cfe03618aa2e914e81fdd90345245c15f4478e35252c06ca52d238fd3cc694",
+      "digestId": "sha256:23bc2b70b2014dec0ac22f27bb93e9babd08cdd6f1115d0c955b9ff22b382f5a",
+      "sizeBytes": 1154361,
+      "command": "#(nop) ADD file:ce026b62356eec3ad1214f92be2c9dc063fe205bd5e600be3492c4dfb17148bd in / "
+    },
+    {
+      "index": 1,
+      "id": "1871059774abe6914075e4a919b778fa1561f577d620ae52438a9635e6241936",
+      "digestId": "sha256:a65b7d7ac139a0e4337bc3c73ce511f937d6140ef61a0108f7d4b8aab8d67274",
+      "sizeBytes": 6405,
+      "command": "#(nop) ADD file:139c3708fb6261126453e34483abd8bf7b26ed16d952fd976994d68e72d93be2 in /somefile.txt "
+    }
+  ],
+  "image": {
+    "sizeBytes": 1160766,
+    "inefficientBytes": 0,
+    "efficiencyScore": 1,
+    "baseLayers": 1,
+    "userSizeBytes": 6405,
+    "userInefficientBytes": 0,
+    "userWastedPercent": 0,
+    "fileReference": [],
+    "directories": [
+      {"path": "/", "sizeBytes": 1160766},
+      {"path": "/bin", "sizeBytes": 1153344},
+      {"path": "/etc", "sizeBytes": 1017}
+    ]
+  }
+}`
+
 func TestRun(t *testing.T) {
+	invalidLayer := 99
 	table := map[string]struct {
 		resolver image.Resolver
 		options  Options
+		files    map[string]string
 		events   []testEvent
 	}{
 		"fetch-case": {
@@ -196,6 +244,69 @@ func TestRun(t *testing.T) {
 				{stdout: "", stderr: "", errorOnExit: true, errMessage: ""},
 			},
 		},
+		"ci-report-file-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Ci:             true,
+				Image:          "doesn't-matter",
+				Source:         dive.SourceDockerEngine,
+				CiConfig:       configureCi(),
+				BuildArgs:      []string{"an-option"},
+				CiReportFormat: "junit",
+				CiReportFile:   "report.xml",
+			},
+			events: []testEvent{
+				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  efficiency: 98.4421 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  wastedBytes: 32025 bytes (32 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  userWastedPercent: 48.3491 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\n    2         13 kB  /root/saved.txt\n    2         13 kB  /root/example/somefile1.txt\n    2        6.4 kB  /root/example/somefile3.txt\nResults:\n  FAIL: highestUserWastedPercent: too many bytes wasted, relative to the user bytes added (%-user-wasted-bytes=0.4834911001404049 > threshold=0.1)\n  FAIL: highestWastedBytes: too many bytes wasted (wasted-bytes=32025 > threshold=1000)\n  PASS: lowestEfficiency\nResult:FAIL [Total:3] [Passed:1] [Failed:2] [Warn:0] [Skipped:0]\n", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Writing junit CI report to 'report.xml'...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "", errorOnExit: true, errMessage: ""},
+			},
+		},
+		"ci-base-layers-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Ci:         true,
+				Image:      "doesn't-matter",
+				Source:     dive.SourceDockerEngine,
+				ExportFile: "",
+				CiConfig:   configureCi(),
+				BuildArgs:  []string{"an-option"},
+				BaseLayers: 5,
+			},
+			events: []testEvent{
+				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  baseLayers: 5", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  efficiency: 98.4421 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  wastedBytes: 32025 bytes (32 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  userWastedPercent: 40.8639 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\n    2         13 kB  /root/saved.txt\n    2         13 kB  /root/example/somefile1.txt\n    2        6.4 kB  /root/example/somefile3.txt\nResults:\n  FAIL: highestUserWastedPercent: too many bytes wasted, relative to the user bytes added (%-user-wasted-bytes=0.40863850963378845 > threshold=0.1)\n  FAIL: highestWastedBytes: too many bytes wasted (wasted-bytes=32025 > threshold=1000)\n  PASS: lowestEfficiency\nResult:FAIL [Total:3] [Passed:1] [Failed:2] [Warn:0] [Skipped:0]\n", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "", errorOnExit: true, errMessage: ""},
+			},
+		},
+		"base-image-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Ci:         false,
+				Image:      "dive-example",
+				Source:     dive.SourceDockerEngine,
+				ExportFile: "",
+				CiConfig:   nil,
+				BuildArgs:  nil,
+				BaseImage:  "dive-example",
+			},
+			events: []testEvent{
+				{stdout: "Image Source: docker://dive-example", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching base image... dive-example", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Building cache...", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
 		"empty-ci-config-case": {
 			resolver: &defaultResolver{},
 			options: Options{
@@ -232,16 +343,127 @@ func TestRun(t *testing.T) {
 				{stdout: "Exporting image to 'some-file.json'...", stderr: "", errorOnExit: false, errMessage: ""},
 			},
 		},
+		"ci-baseline-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Ci:           true,
+				Image:        "doesn't-matter",
+				Source:       dive.SourceDockerEngine,
+				ExportFile:   "",
+				CiConfig:     configureCi(),
+				BuildArgs:    []string{"an-option"},
+				BaselineFile: "baseline.json",
+			},
+			files: map[string]string{"baseline.json": testBaseline},
+			events: []testEvent{
+				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  efficiency: 98.4421 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  wastedBytes: 32025 bytes (32 kB)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "  userWastedPercent: 48.3491 %", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Regression (vs 'baseline.json'):\n  image grew by 60 kB, mostly /root\n  sizeDelta: +60 kB\n  efficiencyDelta: -1.5579 %\n  wastedBytesDelta: +32 kB\n  userWastedPercentDelta: +48.3491 %\n  layers: 12 added, 0 removed\n    +     0 B  mkdir -p /root/example/really/nested\n    +  6.4 kB  cp /somefile.txt /root/example/somefile1.txt\n    +  6.4 kB  chmod 444 /root/example/somefile1.txt\n    +  6.4 kB  cp /somefile.txt /root/example/somefile2.txt\n    +  6.4 kB  cp /somefile.txt /root/example/somefile3.txt\n    +  6.4 kB  mv /root/example/somefile3.txt /root/saved.txt\n    +  6.4 kB  cp /root/saved.txt /root/.saved.txt\n    +     0 B  rm -rf /root/example/\n    +  2.2 kB  #(nop) ADD dir:7ec14b81316baa1a31c38c97686a8f030c98cba2035c968412749e33e0c4427e in /root/.data/\n    +  6.4 kB  cp /root/saved.txt /tmp/saved.again1.txt\n    +  6.4 kB  cp /root/saved.txt /root/.data/saved.again2.txt\n    +  6.4 kB  chmod +x /root/saved.txt\n  newInefficientFiles: 3\n      13 kB  /root/saved.txt\n      13 kB  /root/example/somefile1.txt\n     6.4 kB  /root/example/somefile3.txt", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Inefficient Files:\nCount  Wasted Space  File Path\n    2         13 kB  /root/saved.txt\n    2         13 kB  /root/example/somefile1.txt\n    2        6.4 kB  /root/example/somefile3.txt\nResults:\n  FAIL: highestUserWastedPercent: too many bytes wasted, relative to the user bytes added (%-user-wasted-bytes=0.4834911001404049 > threshold=0.1)\n  FAIL: highestWastedBytes: too many bytes wasted (wasted-bytes=32025 > threshold=1000)\n  PASS: lowestEfficiency\nResult:FAIL [Total:3] [Passed:1] [Failed:2] [Warn:0] [Skipped:0]\n", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "", errorOnExit: true, errMessage: ""},
+			},
+		},
+		"export-baseline-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Ci:           false,
+				Image:        "doesn't-matter",
+				Source:       dive.SourceDockerEngine,
+				ExportFile:   "some-file.json",
+				BuildArgs:    []string{"an-option"},
+				BaselineFile: "baseline.json",
+			},
+			files: map[string]string{"baseline.json": testBaseline},
+			events: []testEvent{
+				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Exporting image to 'some-file.json'...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Regression (vs 'baseline.json'):\n  image grew by 60 kB, mostly /root\n  sizeDelta: +60 kB\n  efficiencyDelta: -1.5579 %\n  wastedBytesDelta: +32 kB\n  userWastedPercentDelta: +48.3491 %\n  layers: 12 added, 0 removed\n    +     0 B  mkdir -p /root/example/really/nested\n    +  6.4 kB  cp /somefile.txt /root/example/somefile1.txt\n    +  6.4 kB  chmod 444 /root/example/somefile1.txt\n    +  6.4 kB  cp /somefile.txt /root/example/somefile2.txt\n    +  6.4 kB  cp /somefile.txt /root/example/somefile3.txt\n    +  6.4 kB  mv /root/example/somefile3.txt /root/saved.txt\n    +  6.4 kB  cp /root/saved.txt /root/.saved.txt\n    +     0 B  rm -rf /root/example/\n    +  2.2 kB  #(nop) ADD dir:7ec14b81316baa1a31c38c97686a8f030c98cba2035c968412749e33e0c4427e in /root/.data/\n    +  6.4 kB  cp /root/saved.txt /tmp/saved.again1.txt\n    +  6.4 kB  cp /root/saved.txt /root/.data/saved.again2.txt\n    +  6.4 kB  chmod +x /root/saved.txt\n  newInefficientFiles: 3\n      13 kB  /root/saved.txt\n      13 kB  /root/example/somefile1.txt\n     6.4 kB  /root/example/somefile3.txt", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
+		"missing-baseline-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Ci:           true,
+				Image:        "doesn't-matter",
+				Source:       dive.SourceDockerEngine,
+				CiConfig:     configureCi(),
+				BuildArgs:    []string{"an-option"},
+				BaselineFile: "baseline.json",
+			},
+			events: []testEvent{
+				{stdout: "Building image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "cannot read baseline", errorOnExit: true, errMessage: "open baseline.json: file does not exist"},
+			},
+		},
+		"html-report-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Image:    "dive-example",
+				Source:   dive.SourceDockerEngine,
+				HTMLFile: "report.html",
+			},
+			events: []testEvent{
+				{stdout: "Image Source: docker://dive-example", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Writing HTML report to 'report.html'...", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
+		"treemap-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Image:        "dive-example",
+				Source:       dive.SourceDockerEngine,
+				TreemapFile:  "treemap.svg",
+				TreemapLayer: &invalidLayer,
+			},
+			events: []testEvent{
+				{stdout: "Image Source: docker://dive-example", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Writing treemap to 'treemap.svg'...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "", stderr: "cannot render treemap", errorOnExit: true, errMessage: "invalid layer index: 99 (image has 14 layers)"},
+			},
+		},
+		"metrics-case": {
+			resolver: &defaultResolver{},
+			options: Options{
+				Image:          "dive-example",
+				Source:         dive.SourceDockerEngine,
+				MetricsFile:    "dive.prom",
+				MetricsTopDirs: 5,
+			},
+			events: []testEvent{
+				{stdout: "Image Source: docker://dive-example", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Fetching image... (this can take a while for large images)", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Analyzing image...", stderr: "", errorOnExit: false, errMessage: ""},
+				{stdout: "Writing metrics to 'dive.prom'...", stderr: "", errorOnExit: false, errMessage: ""},
+			},
+		},
 	}
 
 	for name, test := range table {
 		var ec = make(eventChannel)
 		var events = make([]testEvent, 0)
 		var filesystem = afero.NewMemMapFs()
+		for path, contents := range test.files {
+			if err := afero.WriteFile(filesystem, path, []byte(contents), 0644); err != nil {
+				t.Fatalf("%s.%s: unable to write '%s': %v", t.Name(), name, path, err)
+			}
+		}
 
 		go run(false, test.options, test.resolver, ec, filesystem)
 
 		for event := range ec {
+			// the events without a text form are covered by TestRunTypedEvents
+			if event.payload != nil && event.stdout == "" {
+				continue
+			}
 			events = append(events, newTestEvent(event))
 		}
 
@@ -278,11 +500,58 @@ func TestRun(t *testing.T) {
 				t.Errorf("%s.%s: expected error='%v', got '%v'", t.Name(), name, expectedEvent.errMessage, actualEvent.errMessage)
 			}
 
+			if test.options.CiReportFile != "" {
+				if _, err := filesystem.Stat(test.options.CiReportFile); os.IsNotExist(err) {
+					t.Errorf("%s.%s: expected CI report file but did not find one", t.Name(), name)
+				}
+			}
+
 			if test.options.ExportFile != "" {
 				if _, err := filesystem.Stat(test.options.ExportFile); os.IsNotExist(err) {
 					t.Errorf("%s.%s: expected export file but did not find one", t.Name(), name)
 				}
 			}
+
+			if test.options.HTMLFile != "" {
+				if _, err := filesystem.Stat(test.options.HTMLFile); os.IsNotExist(err) {
+					t.Errorf("%s.%s: expected HTML report but did not find one", t.Name(), name)
+				}
+			}
+
+			if test.options.MetricsFile != "" {
+				if _, err := filesystem.Stat(test.options.MetricsFile); os.IsNotExist(err) {
+					t.Errorf("%s.%s: expected metrics file but did not find one", t.Name(), name)
+				}
+			}
+		}
+	}
+}
+
+func TestConsumeEventsExitCode(t *testing.T) {
+	table := map[string]struct {
+		events   []event
+		expected int
+	}{
+		"success":       {events: []event{{stdout: "ok"}}, expected: 0},
+		"failed":        {events: []event{{errorOnExit: true}}, expected: ci.ExitCodeFailed},
+		"misconfigured": {events: []event{{errorOnExit: true, exitCode: ci.ExitCodeMisconfigured}}, expected: ci.ExitCodeMisconfigured},
+		"misconfiguredTakesPrecedence": {
+			events:   []event{{errorOnExit: true, exitCode: ci.ExitCodeMisconfigured}, {errorOnExit: true}},
+			expected: ci.ExitCodeMisconfigured,
+		},
+	}
+
+	for name, test := range table {
+		var ec = make(eventChannel)
+		go func(events []event) {
+			for _, e := range events {
+				ec <- e
+			}
+			close(ec)
+		}(test.events)
+
+		if actual := consumeEvents(ec, newEventPrinter(OutputText, false)); actual != test.expected {
+			t.Errorf("%s.%s: expected exit code %d, got %d", t.Name(), name, test.expected, actual)
 		}
 	}
 }
@@ -42,7 +42,9 @@ func newApp(gui *gocui.Gui, imageName string, analysis *image.AnalysisResult, ca
 		lm := layout.NewManager()
 		lm.Add(controller.views.Status, layout.LocationFooter)
 		lm.Add(controller.views.Filter, layout.LocationFooter)
+		lm.Add(controller.views.History, layout.LocationFooter)
 		lm.Add(compound.NewLayerDetailsCompoundLayout(controller.views.Layer, controller.views.Details), layout.LocationColumn)
+		lm.Add(controller.views.Compare, layout.LocationColumn)
 		lm.Add(controller.views.Tree, layout.LocationColumn)
 
 		// todo: access this more programmatically
@@ -82,6 +84,18 @@ func newApp(gui *gocui.Gui, imageName string, analysis *image.AnalysisResult, ca
 				IsSelected: controller.views.Filter.IsVisible,
 				Display:    "Filter",
 			},
+			{
+				ConfigKeys: []string{"keybinding.toggle-path-history"},
+				OnAction:   controller.TogglePathHistoryView,
+				IsSelected: controller.views.History.IsVisible,
+				Display:    "Path history",
+			},
+			{
+				ConfigKeys: []string{"keybinding.toggle-side-by-side"},
+				OnAction:   controller.ToggleSideBySide,
+				IsSelected: controller.views.Tree.IsSideBySide,
+				Display:    "Side-by-side",
+			},
 		}
 
 		globalHelpKeys, err = key.GenerateBindings(gui, "", infos)
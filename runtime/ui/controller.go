@@ -1,6 +1,7 @@
 package ui
 
 import (
+	"fmt"
 	"github.com/awesome-gocui/gocui"
 	"github.com/sirupsen/logrus"
 	"github.com/wagoodman/dive/dive/filetree"
@@ -35,6 +36,9 @@ func NewCollection(g *gocui.Gui, imageName string, analysis *image.AnalysisResul
 	// update the tree view while the user types into the filter view
 	controller.views.Filter.AddFilterEditListener(controller.onFilterEdit)
 
+	// follow the selected path in the path history pane while it is open
+	controller.views.Tree.AddSelectionChangeListener(controller.onFileTreeSelectionChange)
+
 	// propagate initial conditions to necessary views
 	err = controller.onLayerChange(viewmodel.LayerSelection{
 		Layer:           controller.views.Layer.CurrentLayer(),
@@ -59,6 +63,14 @@ func (c *Controller) onFileTreeViewOptionChange() error {
 	return c.views.Status.Render()
 }
 
+func (c *Controller) onFileTreeSelectionChange(node *filetree.FileNode) error {
+	if !c.views.History.IsVisible() || node == nil {
+		return nil
+	}
+	c.views.History.SetPath(node.Path())
+	return c.views.History.Render()
+}
+
 func (c *Controller) onFilterEdit(filter string) error {
 	var filterRegex *regexp.Regexp
 	var err error
@@ -77,7 +89,13 @@ func (c *Controller) onFilterEdit(filter string) error {
 		return err
 	}
 
-	return c.views.Tree.Render()
+	err = c.views.Tree.Render()
+	if err != nil {
+		return err
+	}
+
+	// the filter may have moved the cursor to another path
+	return c.onFileTreeSelectionChange(c.views.Tree.CurrentNode())
 }
 
 func (c *Controller) onLayerChange(selection viewmodel.LayerSelection) error {
@@ -90,9 +108,12 @@ func (c *Controller) onLayerChange(selection viewmodel.LayerSelection) error {
 		return err
 	}
 
-	if c.views.Layer.CompareMode() == viewmodel.CompareAllLayers {
+	switch c.views.Layer.CompareMode() {
+	case viewmodel.CompareAllLayers:
 		c.views.Tree.SetTitle("Aggregated Layer Contents")
-	} else {
+	case viewmodel.CompareRangeLayers:
+		c.views.Tree.SetTitle(fmt.Sprintf("Layer Range Contents (%d-%d)", selection.TopTreeStart, selection.TopTreeStop))
+	default:
 		c.views.Tree.SetTitle("Current Layer Contents")
 	}
 
@@ -183,3 +204,27 @@ func (c *Controller) ToggleFilterView() error {
 
 	return c.UpdateAndRender()
 }
+
+// TogglePathHistoryView shows/hides the layer history of the path currently selected in the file tree.
+func (c *Controller) TogglePathHistoryView() error {
+	if !c.views.History.IsVisible() {
+		node := c.views.Tree.CurrentNode()
+		if node == nil {
+			return nil
+		}
+		c.views.History.SetPath(node.Path())
+	}
+	c.views.History.ToggleVisible()
+
+	return c.UpdateAndRender()
+}
+
+// ToggleSideBySide shows/hides the filesystem before the selected layers next to the file tree.
+func (c *Controller) ToggleSideBySide() error {
+	err := c.views.Tree.ToggleSideBySide()
+	if err != nil {
+		return err
+	}
+
+	return c.UpdateAndRender()
+}
@@ -0,0 +1,134 @@
+package view
+
+import (
+	"fmt"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/utils"
+)
+
+// CompareTree holds the UI objects for populating the left column of the side-by-side layout. Specifically the pane
+// that shows the filesystem before the selected layers, aligned row by row with the file tree pane. The pane has no
+// state of its own: the cursor, collapse state, and filters are driven by the file tree pane.
+type CompareTree struct {
+	name   string
+	gui    *gocui.Gui
+	view   *gocui.View
+	header *gocui.View
+	tree   *FileTree
+}
+
+// newCompareTreeView creates a new view object attached the the global [gocui] screen object.
+func newCompareTreeView(gui *gocui.Gui, tree *FileTree) (controller *CompareTree) {
+	controller = new(CompareTree)
+
+	// populate main fields
+	controller.name = "comparetree"
+	controller.gui = gui
+	controller.tree = tree
+	tree.compare = controller
+
+	return controller
+}
+
+func (v *CompareTree) Name() string {
+	return v.name
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *CompareTree) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	return v.tree.Render()
+}
+
+// IsVisible indicates if the side-by-side layout is currently enabled.
+func (v *CompareTree) IsVisible() bool {
+	if v == nil {
+		return false
+	}
+	return v.tree.vm.SideBySide
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed (rendering is driven by the file tree pane).
+func (v *CompareTree) OnLayoutChange() error {
+	return nil
+}
+
+// render writes the aligned "before" tree to the pane. This must be called within a gocui update (after the file tree
+// view model has been rendered).
+func (v *CompareTree) render(g *gocui.Gui) error {
+	if v.view == nil || v.header == nil {
+		return nil
+	}
+
+	v.header.Clear()
+	width, _ := g.Size()
+	headerStr := format.RenderHeader("Before", width, false)
+	if v.tree.vm.ShowAttributes {
+		headerStr += fmt.Sprintf(filetree.AttributeFormat+" %s", "P", "ermission", "UID:GID", "Size", "Filetree")
+	}
+	_, _ = fmt.Fprintln(v.header, headerStr)
+
+	v.view.Clear()
+	_, err := fmt.Fprint(v.view, v.tree.vm.BeforeBuffer.String())
+	return err
+}
+
+func (v *CompareTree) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, v.Name())
+
+	if !v.IsVisible() {
+		if view, _ := g.View(v.Name()); view != nil {
+			// take note: deleting a view will invoke layout again, so ensure this call is protected from an infinite loop
+			if err := g.DeleteView(v.Name()); err != nil {
+				return err
+			}
+			if err := g.DeleteView(v.Name() + "header"); err != nil {
+				return err
+			}
+			v.view, v.header = nil, nil
+		}
+		return nil
+	}
+
+	attributeRowSize := 0
+	if v.tree.vm.ShowAttributes {
+		attributeRowSize = 1
+	}
+
+	// header + attribute header (kept in parity with the file tree pane so the rows line up)
+	headerSize := 1 + attributeRowSize
+	// note: maxY needs to account for the (invisible) border, thus a +1
+	header, headerErr := g.SetView(v.Name()+"header", minX, minY, maxX, minY+headerSize+1, 0)
+	// we are going to overlap the view over the (invisible) border (so minY will be one less than expected).
+	// additionally, maxY will be bumped by one to include the border
+	view, viewErr := g.SetView(v.Name(), minX, minY+headerSize, maxX, maxY+1, 0)
+	if utils.IsNewView(viewErr, headerErr) {
+		err := v.Setup(view, header)
+		if err != nil {
+			logrus.Error("unable to setup compare tree controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RequestedSize shares the available width with the file tree pane.
+func (v *CompareTree) RequestedSize(available int) *int {
+	return nil
+}
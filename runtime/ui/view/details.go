@@ -26,21 +26,27 @@ type Details struct {
 	efficiency     float64
 	inefficiencies filetree.EfficiencySlice
 	imageSize      uint64
+	userSize       uint64
+	userWasted     uint64
+	baseLayers     int
 
 	currentLayer *image.Layer
 }
 
 // newDetailsView creates a new view object attached the the global [gocui] screen object.
-func newDetailsView(gui *gocui.Gui, imageName string, efficiency float64, inefficiencies filetree.EfficiencySlice, imageSize uint64) (controller *Details) {
+func newDetailsView(gui *gocui.Gui, imageName string, analysis *image.AnalysisResult) (controller *Details) {
 	controller = new(Details)
 
 	// populate main fields
 	controller.name = "details"
 	controller.gui = gui
 	controller.imageName = imageName
-	controller.efficiency = efficiency
-	controller.inefficiencies = inefficiencies
-	controller.imageSize = imageSize
+	controller.efficiency = analysis.Efficiency
+	controller.inefficiencies = analysis.Inefficiencies
+	controller.imageSize = analysis.SizeBytes
+	controller.userSize = analysis.UserSizeByes
+	controller.userWasted = analysis.WastedUserBytes
+	controller.baseLayers = analysis.BaseLayers
 
 	return controller
 }
@@ -122,7 +128,7 @@ func (v *Details) SetCurrentLayer(layer *image.Layer) {
 // Render flushes the state objects to the screen. The details pane reports:
 // 1. the current selected layer's command string
 // 2. the image efficiency score
-// 3. the estimated wasted image space
+// 3. the estimated wasted image space (in total and within the user layers)
 // 4. a list of inefficient file allocations
 func (v *Details) Render() error {
 	logrus.Tracef("view.Render() %s", v.Name())
@@ -155,6 +161,13 @@ func (v *Details) Render() error {
 	imageSizeStr := fmt.Sprintf("%s %s", format.Header("Total Image size:"), humanize.Bytes(v.imageSize))
 	effStr := fmt.Sprintf("%s %d %%", format.Header("Image efficiency score:"), int(100.0*v.efficiency))
 	wastedSpaceStr := fmt.Sprintf("%s %s", format.Header("Potential wasted space:"), humanize.Bytes(uint64(wastedSpace)))
+	baseLayersStr := fmt.Sprintf("%s %d (user layers size: %s)", format.Header("Base image layers:"), v.baseLayers, humanize.Bytes(v.userSize))
+	userWastedStr := fmt.Sprintf("%s %s", format.Header("Wasted space in user layers:"), humanize.Bytes(v.userWasted))
+
+	layerOrigin := "user"
+	if v.currentLayer.Index < v.baseLayers {
+		layerOrigin = "base image"
+	}
 
 	v.gui.Update(func(g *gocui.Gui) error {
 		// update header
@@ -180,12 +193,15 @@ func (v *Details) Render() error {
 		}
 		lines = append(lines, format.Header("Id:     ")+v.currentLayer.Id)
 		lines = append(lines, format.Header("Digest: ")+v.currentLayer.Digest)
+		lines = append(lines, format.Header("Origin: ")+layerOrigin)
 		lines = append(lines, format.Header("Command:"))
 		lines = append(lines, v.currentLayer.Command)
 		lines = append(lines, "\n"+imageHeaderStr)
 		lines = append(lines, imageNameStr)
 		lines = append(lines, imageSizeStr)
 		lines = append(lines, wastedSpaceStr)
+		lines = append(lines, baseLayersStr)
+		lines = append(lines, userWastedStr)
 		lines = append(lines, effStr+"\n")
 		lines = append(lines, inefficiencyReport)
 
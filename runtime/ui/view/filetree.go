@@ -16,6 +16,10 @@ import (
 
 type ViewOptionChangeListener func() error
 
+// SelectionChangeListener is called with the node under the cursor whenever the cursor moves to another node (nil if
+// the tree is empty).
+type SelectionChangeListener func(node *filetree.FileNode) error
+
 // FileTree holds the UI objects and data models for populating the right pane. Specifically the pane that
 // shows selected layer or aggregate file ASCII tree.
 type FileTree struct {
@@ -26,8 +30,12 @@ type FileTree struct {
 	vm     *viewmodel.FileTree
 	title  string
 
+	// compare is the "before" column of the side-by-side layout (driven by this view)
+	compare *CompareTree
+
 	filterRegex         *regexp.Regexp
 	listeners           []ViewOptionChangeListener
+	selectionListeners  []SelectionChangeListener
 	helpKeys            []*key.Binding
 	requestedWidthRatio float64
 }
@@ -59,6 +67,11 @@ func (v *FileTree) AddViewOptionChangeListener(listener ...ViewOptionChangeListe
 	v.listeners = append(v.listeners, listener...)
 }
 
+// AddSelectionChangeListener registers listeners that are notified when the cursor selects another node.
+func (v *FileTree) AddSelectionChangeListener(listener ...SelectionChangeListener) {
+	v.selectionListeners = append(v.selectionListeners, listener...)
+}
+
 func (v *FileTree) SetTitle(title string) {
 	v.title = title
 }
@@ -121,6 +134,12 @@ func (v *FileTree) Setup(view *gocui.View, header *gocui.View) error {
 			IsSelected: func() bool { return !v.vm.HiddenDiffTypes[filetree.Unmodified] },
 			Display:    "Unmodified",
 		},
+		{
+			ConfigKeys: []string{"keybinding.toggle-permission-audit"},
+			OnAction:   v.toggleAuditFilter,
+			IsSelected: func() bool { return v.vm.AuditFilter },
+			Display:    "Audit",
+		},
 		{
 			ConfigKeys: []string{"keybinding.toggle-filetree-attributes"},
 			OnAction:   v.toggleAttributes,
@@ -196,7 +215,7 @@ func (v *FileTree) SetTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTre
 	}
 
 	_ = v.Update()
-	return v.Render()
+	return v.renderSelectionChange()
 }
 
 // CursorDown moves the cursor down and renders the view.
@@ -205,7 +224,7 @@ func (v *FileTree) SetTree(bottomTreeStart, bottomTreeStop, topTreeStart, topTre
 // this range into the view buffer. This is much faster when tree sizes are large.
 func (v *FileTree) CursorDown() error {
 	if v.vm.CursorDown() {
-		return v.Render()
+		return v.renderSelectionChange()
 	}
 	return nil
 }
@@ -216,7 +235,7 @@ func (v *FileTree) CursorDown() error {
 // this range into the view buffer. This is much faster when tree sizes are large.
 func (v *FileTree) CursorUp() error {
 	if v.vm.CursorUp() {
-		return v.Render()
+		return v.renderSelectionChange()
 	}
 	return nil
 }
@@ -228,7 +247,7 @@ func (v *FileTree) CursorLeft() error {
 		return err
 	}
 	_ = v.Update()
-	return v.Render()
+	return v.renderSelectionChange()
 }
 
 // CursorRight descends into directory expanding it if needed
@@ -238,7 +257,7 @@ func (v *FileTree) CursorRight() error {
 		return err
 	}
 	_ = v.Update()
-	return v.Render()
+	return v.renderSelectionChange()
 }
 
 // PageDown moves to next page putting the cursor on top
@@ -247,7 +266,7 @@ func (v *FileTree) PageDown() error {
 	if err != nil {
 		return err
 	}
-	return v.Render()
+	return v.renderSelectionChange()
 }
 
 // PageUp moves to previous page putting the cursor on top
@@ -256,7 +275,12 @@ func (v *FileTree) PageUp() error {
 	if err != nil {
 		return err
 	}
-	return v.Render()
+	return v.renderSelectionChange()
+}
+
+// CurrentNode returns the FileNode currently selected by the cursor.
+func (v *FileTree) CurrentNode() *filetree.FileNode {
+	return v.vm.CurrentNode(v.filterRegex)
 }
 
 // getAbsPositionNode determines the selected screen cursor's location in the file tree, returning the selected FileNode.
@@ -284,7 +308,7 @@ func (v *FileTree) toggleCollapseAll() error {
 		v.resetCursor()
 	}
 	_ = v.Update()
-	return v.Render()
+	return v.renderSelectionChange()
 }
 
 func (v *FileTree) toggleWrapTree() error {
@@ -292,6 +316,26 @@ func (v *FileTree) toggleWrapTree() error {
 	return nil
 }
 
+// renderSelectionChange renders the view after the cursor moved and notifies the selection change listeners.
+func (v *FileTree) renderSelectionChange() error {
+	if err := v.Render(); err != nil {
+		return err
+	}
+	return v.notifyOnSelectionChangeListeners()
+}
+
+func (v *FileTree) notifyOnSelectionChangeListeners() error {
+	node := v.CurrentNode()
+	for _, listener := range v.selectionListeners {
+		err := listener(node)
+		if err != nil {
+			logrus.Errorf("notifyOnSelectionChangeListeners error: %+v", err)
+			return err
+		}
+	}
+	return nil
+}
+
 func (v *FileTree) notifyOnViewOptionChangeListeners() error {
 	for _, listener := range v.listeners {
 		err := listener()
@@ -323,6 +367,44 @@ func (v *FileTree) toggleAttributes() error {
 	return v.notifyOnViewOptionChangeListeners()
 }
 
+// ToggleSideBySide will show/hide the filesystem before the selected layers next to the file tree pane.
+func (v *FileTree) ToggleSideBySide() error {
+	v.vm.ToggleSideBySide()
+
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	err = v.Render()
+	if err != nil {
+		return err
+	}
+
+	return v.notifyOnViewOptionChangeListeners()
+}
+
+// IsSideBySide indicates if the filesystem before the selected layers is shown next to the file tree pane.
+func (v *FileTree) IsSideBySide() bool {
+	return v.vm.SideBySide
+}
+
+// toggleAuditFilter will show only the files with risky permissions or ownership (or all files again).
+func (v *FileTree) toggleAuditFilter() error {
+	v.vm.ToggleAuditFilter()
+
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	err = v.Render()
+	if err != nil {
+		return err
+	}
+
+	// we need to render the changes to the status pane as well (not just this contoller/view)
+	return v.notifyOnViewOptionChangeListeners()
+}
+
 // ToggleShowDiffType will show/hide the selected DiffType in the filetree pane.
 func (v *FileTree) toggleShowDiffType(diffType filetree.DiffType) error {
 	v.vm.ToggleShowDiffType(diffType)
@@ -387,8 +469,14 @@ func (v *FileTree) Render() error {
 			return err
 		}
 		_, err = fmt.Fprint(v.view, v.vm.Buffer.String())
+		if err != nil {
+			return err
+		}
 
-		return err
+		if v.compare != nil && v.vm.SideBySide {
+			return v.compare.render(g)
+		}
+		return nil
 	})
 	return nil
 }
@@ -107,6 +107,12 @@ func (v *Layer) Setup(view *gocui.View, header *gocui.View) error {
 			IsSelected: func() bool { return v.vm.CompareMode == viewmodel.CompareAllLayers },
 			Display:    "Show aggregated changes",
 		},
+		{
+			ConfigKeys: []string{"keybinding.compare-range"},
+			OnAction:   v.setCompareRangeStart,
+			IsSelected: func() bool { return v.vm.CompareMode == viewmodel.CompareRangeLayers },
+			Display:    "Show range changes",
+		},
 		{
 			Key:      gocui.KeyArrowDown,
 			Modifier: gocui.ModNone,
@@ -241,6 +247,18 @@ func (v *Layer) setCompareMode(compareMode viewmodel.LayerCompareMode) error {
 	return v.notifyLayerChangeListeners()
 }
 
+// setCompareRangeStart marks the selected layer as the bottom of a layer range. The top of the range follows the
+// cursor, showing the aggregated changes between the two layers.
+func (v *Layer) setCompareRangeStart() error {
+	v.vm.RangeStartIndex = v.vm.LayerIndex
+	v.vm.CompareMode = viewmodel.CompareRangeLayers
+	err := v.notifyLayerChangeListeners()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
 // renderCompareBar returns the formatted string for the given layer.
 func (v *Layer) renderCompareBar(layerIdx int) string {
 	bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop := v.vm.GetCompareIndexes()
@@ -0,0 +1,229 @@
+package view
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/awesome-gocui/gocui"
+	"github.com/dustin/go-humanize"
+	"github.com/phayes/permbits"
+	"github.com/sirupsen/logrus"
+	"github.com/wagoodman/dive/dive/filetree"
+	"github.com/wagoodman/dive/dive/image"
+	"github.com/wagoodman/dive/runtime/ui/format"
+	"github.com/wagoodman/dive/utils"
+)
+
+const pathHistoryFormat = "%5s  %-10s  %9s  %-10s  %11s  %-8s  %s"
+
+// PathHistory holds the UI objects and data models for populating the pane above the status bar. Specifically the
+// pane that lists every layer that touched the path selected in the file tree.
+type PathHistory struct {
+	name   string
+	gui    *gocui.Gui
+	view   *gocui.View
+	header *gocui.View
+	hidden bool
+
+	layers   []*image.Layer
+	refTrees []*filetree.FileTree
+	path     string
+	changes  []filetree.PathChange
+}
+
+// newPathHistoryView creates a new view object attached the the global [gocui] screen object.
+func newPathHistoryView(gui *gocui.Gui, layers []*image.Layer, refTrees []*filetree.FileTree) (controller *PathHistory) {
+	controller = new(PathHistory)
+
+	// populate main fields
+	controller.name = "history"
+	controller.gui = gui
+	controller.layers = layers
+	controller.refTrees = refTrees
+	controller.hidden = true
+
+	return controller
+}
+
+func (v *PathHistory) Name() string {
+	return v.name
+}
+
+// SetPath selects the file tree path to show the layer history for.
+func (v *PathHistory) SetPath(path string) {
+	v.path = path
+	v.changes = filetree.PathHistory(v.refTrees, path)
+}
+
+// Setup initializes the UI concerns within the context of a global [gocui] view object.
+func (v *PathHistory) Setup(view *gocui.View, header *gocui.View) error {
+	logrus.Tracef("view.Setup() %s", v.Name())
+
+	// set controller options
+	v.view = view
+	v.view.Editable = false
+	v.view.Wrap = false
+	v.view.Frame = false
+
+	v.header = header
+	v.header.Editable = false
+	v.header.Wrap = false
+	v.header.Frame = false
+
+	return v.Render()
+}
+
+// ToggleVisible shows/hides the path history pane.
+func (v *PathHistory) ToggleVisible() {
+	v.hidden = !v.hidden
+}
+
+// IsVisible indicates if the path history pane is currently initialized and shown.
+func (v *PathHistory) IsVisible() bool {
+	if v == nil {
+		return false
+	}
+	return !v.hidden
+}
+
+// OnLayoutChange is called whenever the screen dimensions are changed
+func (v *PathHistory) OnLayoutChange() error {
+	err := v.Update()
+	if err != nil {
+		return err
+	}
+	return v.Render()
+}
+
+// Update refreshes the state objects for future rendering (currently does nothing).
+func (v *PathHistory) Update() error {
+	return nil
+}
+
+// renderChange returns the formatted history row for the given layer change.
+func (v *PathHistory) renderChange(change filetree.PathChange) string {
+	var command string
+	if change.TreeIndex < len(v.layers) {
+		command = v.layers[change.TreeIndex].Command
+	}
+
+	info := change.FileInfo
+	content := "-"
+	switch change.DiffType {
+	case filetree.Added:
+		content = "new"
+	case filetree.Modified, filetree.Unmodified:
+		content = "same"
+		if change.HashChanged {
+			content = "changed"
+		}
+	}
+
+	dir := "-"
+	if info.IsDir {
+		dir = "d"
+	}
+
+	return fmt.Sprintf(pathHistoryFormat,
+		fmt.Sprintf("%d", change.TreeIndex),
+		change.DiffType.String(),
+		humanize.Bytes(uint64(info.Size)),
+		dir+permbits.FileMode(info.Mode).String(),
+		fmt.Sprintf("%d:%d", info.Uid, info.Gid),
+		content,
+		command,
+	)
+}
+
+// Render flushes the state objects to the screen. The path history pane reports each layer that touched the selected
+// path, the kind of change, the resulting attributes, and the layer command.
+func (v *PathHistory) Render() error {
+	logrus.Tracef("view.Render() %s", v.Name())
+
+	if v.view == nil {
+		return nil
+	}
+
+	v.gui.Update(func(g *gocui.Gui) error {
+		// update header
+		v.header.Clear()
+		width, _ := g.Size()
+		title := fmt.Sprintf("History: %s (%d layers)", v.path, len(v.changes))
+		headerStr := format.RenderHeader(title, width, false)
+		headerStr += fmt.Sprintf(pathHistoryFormat, "Layer", "Change", "Size", "Permission", "UID:GID", "Content", "Command")
+		_, err := fmt.Fprintln(v.header, headerStr)
+		if err != nil {
+			return err
+		}
+
+		// update contents
+		v.view.Clear()
+		var lines = make([]string, 0, len(v.changes))
+		for _, change := range v.changes {
+			lines = append(lines, v.renderChange(change))
+		}
+		if len(lines) == 0 {
+			lines = append(lines, "No layer touches this path")
+		}
+
+		_, err = fmt.Fprintln(v.view, strings.Join(lines, "\n"))
+		if err != nil {
+			logrus.Debug("unable to write to buffer: ", err)
+		}
+		return err
+	})
+	return nil
+}
+
+// KeyHelp indicates all the possible actions a user can take while the current pane is selected (currently does nothing).
+func (v *PathHistory) KeyHelp() string {
+	return ""
+}
+
+func (v *PathHistory) Layout(g *gocui.Gui, minX, minY, maxX, maxY int) error {
+	logrus.Tracef("view.Layout(minX: %d, minY: %d, maxX: %d, maxY: %d) %s", minX, minY, maxX, maxY, v.Name())
+
+	if !v.IsVisible() {
+		if view, _ := g.View(v.Name()); view != nil {
+			// take note: deleting a view will invoke layout again, so ensure this call is protected from an infinite loop
+			if err := g.DeleteView(v.Name()); err != nil {
+				return err
+			}
+			if err := g.DeleteView(v.Name() + "header"); err != nil {
+				return err
+			}
+			v.view, v.header = nil, nil
+		}
+		return nil
+	}
+
+	// title + column header
+	headerHeight := 2
+
+	// note: maxY needs to account for the (invisible) border, thus a +1
+	header, headerErr := g.SetView(v.Name()+"header", minX, minY, maxX, minY+headerHeight+1, 0)
+	// we are going to overlap the view over the (invisible) border (so minY will be one less than expected)
+	view, viewErr := g.SetView(v.Name(), minX, minY+headerHeight, maxX, maxY, 0)
+
+	if utils.IsNewView(viewErr, headerErr) {
+		err := v.Setup(view, header)
+		if err != nil {
+			logrus.Error("unable to setup path history controller", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RequestedSize asks for enough rows to show every change, up to a third of the available screen height.
+func (v *PathHistory) RequestedSize(available int) *int {
+	// header + title + (at least) one row
+	height := 2 + len(v.changes)
+	if len(v.changes) == 0 {
+		height++
+	}
+	if limit := available / 3; height > limit {
+		height = limit
+	}
+	return &height
+}
@@ -8,10 +8,12 @@ import (
 
 type Views struct {
 	Tree    *FileTree
+	Compare *CompareTree
 	Layer   *Layer
 	Status  *Status
 	Filter  *Filter
 	Details *Details
+	History *PathHistory
 	Debug   *Debug
 }
 
@@ -27,6 +29,8 @@ func NewViews(g *gocui.Gui, imageName string, analysis *image.AnalysisResult, ca
 		return nil, err
 	}
 
+	Compare := newCompareTreeView(g, Tree)
+
 	Status := newStatusView(g)
 
 	// set the layer view as the first selected view
@@ -34,16 +38,20 @@ func NewViews(g *gocui.Gui, imageName string, analysis *image.AnalysisResult, ca
 
 	Filter := newFilterView(g)
 
-	Details := newDetailsView(g, imageName, analysis.Efficiency, analysis.Inefficiencies, analysis.SizeBytes)
+	Details := newDetailsView(g, imageName, analysis)
+
+	History := newPathHistoryView(g, analysis.Layers, analysis.RefTrees)
 
 	Debug := newDebugView(g)
 
 	return &Views{
 		Tree:    Tree,
+		Compare: Compare,
 		Layer:   Layer,
 		Status:  Status,
 		Filter:  Filter,
 		Details: Details,
+		History: History,
 		Debug:   Debug,
 	}, nil
 }
@@ -55,5 +63,6 @@ func (views *Views) All() []Renderer {
 		views.Status,
 		views.Filter,
 		views.Details,
+		views.History,
 	}
 }
@@ -27,6 +27,8 @@ type FileTree struct {
 	ShowAttributes              bool
 	unconstrainedShowAttributes bool
 	HiddenDiffTypes             []bool
+	AuditFilter                 bool                      // only show files with risky permissions or ownership
+	audit                       *filetree.PermissionAudit // the audit of the ModelTree (nil until the audit filter needs it)
 	TreeIndex                   int
 	bufferIndex                 int
 	bufferIndexLowerBound       int
@@ -34,7 +36,14 @@ type FileTree struct {
 	refHeight int
 	refWidth  int
 
-	Buffer bytes.Buffer
+	// side-by-side mode: the filesystem before (left) and after (right) the selected layers, aligned row by row
+	SideBySide     bool
+	treeIndexes    [4]int
+	BeforeViewTree *filetree.FileTree
+	AfterViewTree  *filetree.FileTree
+
+	Buffer       bytes.Buffer
+	BeforeBuffer bytes.Buffer
 }
 
 // NewFileTreeViewModel creates a new view object attached the the global [gocui] screen object.
@@ -49,6 +58,7 @@ func NewFileTreeViewModel(tree *filetree.FileTree, refTrees []*filetree.FileTree
 	treeViewModel.RefTrees = refTrees
 	treeViewModel.cache = cache
 	treeViewModel.HiddenDiffTypes = make([]bool, 4)
+	treeViewModel.SideBySide = viper.GetBool("filetree.side-by-side")
 
 	hiddenTypes := viper.GetStringSlice("diff.hide")
 	for _, hType := range hiddenTypes {
@@ -126,6 +136,8 @@ func (vm *FileTree) SetTreeByLayer(bottomTreeStart, bottomTreeStop, topTreeStart
 	}
 
 	vm.ModelTree = newTree
+	vm.audit = nil
+	vm.treeIndexes = [4]int{bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop}
 	return nil
 }
 
@@ -325,6 +337,11 @@ func (vm *FileTree) getAbsPositionNode(filterRegex *regexp.Regexp) (node *filetr
 	return node
 }
 
+// CurrentNode returns the FileNode currently selected by the cursor.
+func (vm *FileTree) CurrentNode(filterRegex *regexp.Regexp) *filetree.FileNode {
+	return vm.getAbsPositionNode(filterRegex)
+}
+
 // ToggleCollapse will collapse/expand the selected FileNode.
 func (vm *FileTree) ToggleCollapse(filterRegex *regexp.Regexp) error {
 	node := vm.getAbsPositionNode(filterRegex)
@@ -382,6 +399,16 @@ func (vm *FileTree) ToggleAttributes() error {
 	return nil
 }
 
+// ToggleSideBySide will show/hide the filesystem before the selected layers next to the current file tree.
+func (vm *FileTree) ToggleSideBySide() {
+	vm.SideBySide = !vm.SideBySide
+}
+
+// ToggleAuditFilter will show only the files with risky permissions or ownership (or all files again).
+func (vm *FileTree) ToggleAuditFilter() {
+	vm.AuditFilter = !vm.AuditFilter
+}
+
 // ToggleShowDiffType will show/hide the selected DiffType in the filetree pane.
 func (vm *FileTree) ToggleShowDiffType(diffType filetree.DiffType) {
 	vm.HiddenDiffTypes[diffType] = !vm.HiddenDiffTypes[diffType]
@@ -392,6 +419,15 @@ func (vm *FileTree) Update(filterRegex *regexp.Regexp, width, height int) error
 	vm.refWidth = width
 	vm.refHeight = height
 
+	// the audit only changes with the layer selection (see SetTreeByLayer)
+	var audit *filetree.PermissionAudit
+	if vm.AuditFilter {
+		if vm.audit == nil {
+			vm.audit = filetree.NewPermissionAudit(vm.ModelTree, filetree.DefaultExecutablePaths)
+		}
+		audit = vm.audit
+	}
+
 	// keep the vm selection in parity with the current DiffType selection
 	err := vm.ModelTree.VisitDepthChildFirst(func(node *filetree.FileNode) error {
 		node.Data.ViewInfo.Hidden = vm.HiddenDiffTypes[node.Data.DiffType]
@@ -407,6 +443,10 @@ func (vm *FileTree) Update(filterRegex *regexp.Regexp, width, height int) error
 			match := filterRegex.FindString(node.Path())
 			node.Data.ViewInfo.Hidden = len(match) == 0
 		}
+		// hide nodes without any permission issues (unless they contain a visible child)
+		if audit != nil && !visibleChild && !node.Data.ViewInfo.Hidden {
+			node.Data.ViewInfo.Hidden = audit.Issues(node) == 0
+		}
 		return nil
 	}, nil)
 
@@ -432,25 +472,62 @@ func (vm *FileTree) Update(filterRegex *regexp.Regexp, width, height int) error
 		return err
 	}
 
+	if vm.SideBySide {
+		return vm.updateSideBySide()
+	}
+	return nil
+}
+
+// updateSideBySide aligns the filesystem before and after the selected layers with the current view tree, such that
+// both share the same rows (and thus the same cursor and collapse state).
+func (vm *FileTree) updateSideBySide() error {
+	bottomTreeStart, bottomTreeStop, _, topTreeStop := vm.treeIndexes[0], vm.treeIndexes[1], vm.treeIndexes[2], vm.treeIndexes[3]
+
+	before, err := vm.cache.GetStackedTree(bottomTreeStart, bottomTreeStop)
+	if err != nil {
+		logrus.Errorf("unable to stack the lower trees: %+v", err)
+		return err
+	}
+
+	after, err := vm.cache.GetStackedTree(bottomTreeStart, topTreeStop)
+	if err != nil {
+		logrus.Errorf("unable to stack the upper trees: %+v", err)
+		return err
+	}
+
+	vm.BeforeViewTree = filetree.NewAlignedTree(vm.ViewTree, before)
+	vm.AfterViewTree = filetree.NewAlignedTree(vm.ViewTree, after)
 	return nil
 }
 
 // Render flushes the state objects (file tree) to the pane.
 func (vm *FileTree) Render() error {
-	treeString := vm.ViewTree.StringBetween(vm.bufferIndexLowerBound, vm.bufferIndexUpperBound(), vm.ShowAttributes)
+	if vm.SideBySide && vm.AfterViewTree != nil && vm.BeforeViewTree != nil {
+		err := vm.renderTree(vm.BeforeViewTree, &vm.BeforeBuffer)
+		if err != nil {
+			return err
+		}
+		return vm.renderTree(vm.AfterViewTree, &vm.Buffer)
+	}
+	return vm.renderTree(vm.ViewTree, &vm.Buffer)
+}
+
+// renderTree writes the visible rows of the given tree to the given buffer, highlighting the selected row.
+func (vm *FileTree) renderTree(tree *filetree.FileTree, buffer *bytes.Buffer) error {
+	treeString := tree.StringBetween(vm.bufferIndexLowerBound, vm.bufferIndexUpperBound(), vm.ShowAttributes)
 	lines := strings.Split(treeString, "\n")
 
 	// update the contents
-	vm.Buffer.Reset()
+	buffer.Reset()
 	for idx, line := range lines {
 		if idx == vm.bufferIndex {
-			_, err := fmt.Fprintln(&vm.Buffer, format.Selected(vtclean.Clean(line, false)))
+			_, err := fmt.Fprintln(buffer, format.Selected(vtclean.Clean(line, false)))
 			if err != nil {
 				logrus.Debug("unable to write to buffer: ", err)
 				return err
 			}
 		} else {
-			_, err := fmt.Fprintln(&vm.Buffer, line)
+			_, err := fmt.Fprintln(buffer, line)
 			if err != nil {
 				logrus.Debug("unable to write to buffer: ", err)
 				return err
@@ -2,15 +2,18 @@ package viewmodel
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/wagoodman/dive/dive/image/docker"
 	"github.com/wagoodman/dive/runtime/ui/format"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/fatih/color"
+	"github.com/lunixbochs/vtclean"
 	"github.com/sergi/go-diff/diffmatchpatch"
 	"github.com/wagoodman/dive/dive/filetree"
 )
@@ -396,3 +399,78 @@ func TestFileTreeHideTypeWithFilter(t *testing.T) {
 
 	runTestCase(t, vm, width, height, regex)
 }
+
+func TestFileTreeSideBySide(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 100
+	vm.Setup(0, height)
+	vm.ShowAttributes = true
+
+	// collapse /bin
+	err := vm.ToggleCollapse(nil)
+	checkError(t, err, "unable to collapse /bin")
+
+	// select the layer that removes /root/example, compareMode = layer
+	err = vm.SetTreeByLayer(0, 8, 9, 9)
+	checkError(t, err, "unable to SetTreeByLayer")
+
+	vm.ToggleSideBySide()
+
+	err = vm.Update(nil, width, height)
+	checkError(t, err, "failed to update viewmodel")
+
+	err = vm.Render()
+	checkError(t, err, "failed to render viewmodel")
+
+	beforeLines := strings.Split(vm.BeforeBuffer.String(), "\n")
+	afterLines := strings.Split(vm.Buffer.String(), "\n")
+	if len(beforeLines) != len(afterLines) {
+		t.Fatalf("expected aligned rows, got %d before and %d after", len(beforeLines), len(afterLines))
+	}
+
+	var actual bytes.Buffer
+	for idx := range beforeLines {
+		actual.WriteString(fmt.Sprintf("%-80s | %s\n", vtclean.Clean(beforeLines[idx], false), vtclean.Clean(afterLines[idx], false)))
+	}
+	assertTestData(t, actual.Bytes())
+}
+
+func TestFileTreePermissionAudit(t *testing.T) {
+	vm := initializeTestViewModel(t)
+
+	width, height := 100, 100
+	vm.Setup(0, height)
+	vm.ShowAttributes = true
+
+	// select the last layer, compareMode = aggregated
+	err := vm.SetTreeByLayer(0, 0, 1, 13)
+	checkError(t, err, "unable to SetTreeByLayer")
+
+	vm.ToggleAuditFilter()
+
+	runTestCase(t, vm, width, height, nil)
+}
+
+func TestFileTreePermissionAuditPerLayerSelection(t *testing.T) {
+	vm := initializeTestViewModel(t)
+	vm.Setup(0, 100)
+	vm.ToggleAuditFilter()
+
+	err := vm.SetTreeByLayer(0, 0, 1, 13)
+	checkError(t, err, "unable to SetTreeByLayer")
+	checkError(t, vm.Update(nil, 100, 100), "unable to update")
+	audit := vm.audit
+
+	checkError(t, vm.Update(nil, 100, 100), "unable to update")
+	if audit == nil || vm.audit != audit {
+		t.Errorf("expected the audit to be reused while the layer selection is unchanged")
+	}
+
+	err = vm.SetTreeByLayer(0, 0, 1, 12)
+	checkError(t, err, "unable to SetTreeByLayer")
+	checkError(t, vm.Update(nil, 100, 100), "unable to update")
+	if vm.audit == audit {
+		t.Errorf("expected a new audit for a new layer selection")
+	}
+}
@@ -3,6 +3,7 @@ package viewmodel
 const (
 	CompareSingleLayer LayerCompareMode = iota
 	CompareAllLayers
+	CompareRangeLayers
 )
 
 type LayerCompareMode int
@@ -7,6 +7,7 @@ type LayerSetState struct {
 	Layers            []*image.Layer
 	CompareMode       LayerCompareMode
 	CompareStartIndex int
+	RangeStartIndex   int
 }
 
 func NewLayerSetState(layers []*image.Layer, compareMode LayerCompareMode) *LayerSetState {
@@ -18,6 +19,10 @@ func NewLayerSetState(layers []*image.Layer, compareMode LayerCompareMode) *Laye
 
 // getCompareIndexes determines the layer boundaries to use for comparison (based on the current compare mode)
 func (state *LayerSetState) GetCompareIndexes() (bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop int) {
+	if state.CompareMode == CompareRangeLayers {
+		return state.getRangeCompareIndexes()
+	}
+
 	bottomTreeStart = state.CompareStartIndex
 	topTreeStop = state.LayerIndex
 
@@ -34,3 +39,19 @@ func (state *LayerSetState) GetCompareIndexes() (bottomTreeStart, bottomTreeStop
 
 	return bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop
 }
+
+// getRangeCompareIndexes compares the aggregated changes of every layer of the marked range, from the range start up to
+// (and including) the selected layer, against the image as it was below the range. The selected layer may be below
+// the range start, in which case the bounds are swapped. A range starting at the base layer is compared against the
+// base layer itself (as there is nothing below it).
+func (state *LayerSetState) getRangeCompareIndexes() (bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop int) {
+	lower, upper := state.RangeStartIndex, state.LayerIndex
+	if lower > upper {
+		lower, upper = upper, lower
+	}
+
+	if lower == 0 {
+		return 0, 0, 0, upper
+	}
+	return 0, lower - 1, lower, upper
+}
@@ -0,0 +1,36 @@
+package viewmodel
+
+import (
+	"testing"
+)
+
+func TestLayerSetState_GetCompareIndexes(t *testing.T) {
+	table := map[string]struct {
+		mode       LayerCompareMode
+		layerIndex int
+		rangeStart int
+		expected   [4]int
+	}{
+		"single-layer":        {CompareSingleLayer, 3, 0, [4]int{0, 2, 3, 3}},
+		"single-layer-base":   {CompareSingleLayer, 0, 0, [4]int{0, 0, 0, 0}},
+		"all-layers":          {CompareAllLayers, 3, 0, [4]int{0, 0, 1, 3}},
+		"range":               {CompareRangeLayers, 5, 2, [4]int{0, 1, 2, 5}},
+		"range-cursor-below":  {CompareRangeLayers, 1, 4, [4]int{0, 0, 1, 4}},
+		"range-single-layer":  {CompareRangeLayers, 3, 3, [4]int{0, 2, 3, 3}},
+		"range-from-the-base": {CompareRangeLayers, 4, 0, [4]int{0, 0, 0, 4}},
+		"range-base-layer":    {CompareRangeLayers, 0, 0, [4]int{0, 0, 0, 0}},
+	}
+
+	for name, test := range table {
+		state := NewLayerSetState(nil, test.mode)
+		state.LayerIndex = test.layerIndex
+		state.RangeStartIndex = test.rangeStart
+
+		bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop := state.GetCompareIndexes()
+		actual := [4]int{bottomTreeStart, bottomTreeStop, topTreeStart, topTreeStop}
+
+		if test.expected != actual {
+			t.Errorf("%s: expected indexes %v, got %v", name, test.expected, actual)
+		}
+	}
+}